@@ -28,20 +28,56 @@ type KubebuilderSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Foo is an example field of Kubebuilder. Edit kubebuilder_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
-
-	Bar string `json:"bar,omitempty`
+	// WebstoreImage defines the web store image
+	//+kubebuilder:default="nginx:1.17"
+	//+kubebuilder:validation:Optional
+	WebstoreImage string `json:"webstoreImage,omitempty"`
+
+	// ServiceName defines the name of the web store service
+	//+kubebuilder:default="webstore-svc"
+	//+kubebuilder:validation:Optional
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// WebStoreReplicas defines the number of web store replicas
+	//+kubebuilder:default=2
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	WebStoreReplicas *int32 `json:"webStoreReplicas,omitempty"`
 }
 
+// Condition types for the Kubebuilder status
+const (
+	// ConditionTypeReady indicates that all of the child resources are ready
+	ConditionTypeReady = "Ready"
+
+	// ConditionTypeDeploymentReady indicates that the web store deployment is ready
+	ConditionTypeDeploymentReady = "DeploymentReady"
+
+	// ConditionTypeServiceReady indicates that the web store service is ready
+	ConditionTypeServiceReady = "ServiceReady"
+
+	// ConditionTypeIngressReady indicates that the web store ingress is ready
+	ConditionTypeIngressReady = "IngressReady"
+)
+
 // KubebuilderStatus defines the observed state of Kubebuilder
 type KubebuilderStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// ObservedGeneration is the most recent generation observed by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the Kubebuilder state
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Kubebuilder is the Schema for the kubebuilders API
 type Kubebuilder struct {
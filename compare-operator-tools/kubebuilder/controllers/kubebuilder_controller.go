@@ -18,15 +18,48 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	demov1alpha1 "github.com/scottd018/demos/api/v1alpha1"
 )
 
+const (
+	// deploymentName is the name of the web store deployment
+	deploymentName = "webstore-deploy"
+
+	// ingressName is the name of the web store ingress
+	ingressName = "webstore-ing"
+
+	// ingressHost is the host which the web store ingress routes traffic for
+	ingressHost = "app.acme.com"
+
+	// containerPort is the port which the web store container listens on
+	containerPort = 8080
+
+	// servicePort is the port which the web store service exposes
+	servicePort = 80
+
+	// defaultReplicas is the number of web store replicas when the spec does not set them
+	defaultReplicas = 2
+
+	// requeueAfter is how long to wait before checking the readiness of the child resources again
+	requeueAfter = 5 * time.Second
+)
+
 // KubebuilderReconciler reconciles a Kubebuilder object
 type KubebuilderReconciler struct {
 	client.Client
@@ -36,27 +69,287 @@ type KubebuilderReconciler struct {
 //+kubebuilder:rbac:groups=demo.apps.acme.com,resources=kubebuilders,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=demo.apps.acme.com,resources=kubebuilders/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=demo.apps.acme.com,resources=kubebuilders/finalizers,verbs=update
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Kubebuilder object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// move the current state of the cluster closer to the desired state.  It
+// creates or updates the web store Deployment, Service and Ingress from the
+// Kubebuilder spec and reports their readiness as status conditions.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.2/pkg/reconcile
 func (r *KubebuilderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
+
+	webstore := &demov1alpha1.Kubebuilder{}
+	if err := r.Get(ctx, req.NamespacedName, webstore); err != nil {
+		// child resources are garbage collected through their owner references
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	deploymentReady, err := r.reconcileDeployment(ctx, webstore)
+	if err != nil {
+		return r.fail(ctx, webstore, demov1alpha1.ConditionTypeDeploymentReady, err)
+	}
+
+	serviceReady, err := r.reconcileService(ctx, webstore)
+	if err != nil {
+		return r.fail(ctx, webstore, demov1alpha1.ConditionTypeServiceReady, err)
+	}
+
+	if err := r.reconcileIngress(ctx, webstore); err != nil {
+		return r.fail(ctx, webstore, demov1alpha1.ConditionTypeIngressReady, err)
+	}
+
+	// the previous services are only removed once the ingress routes to the current service
+	if err := r.pruneServices(ctx, webstore); err != nil {
+		return r.fail(ctx, webstore, demov1alpha1.ConditionTypeServiceReady, err)
+	}
+
+	setReadyCondition(webstore, demov1alpha1.ConditionTypeDeploymentReady, deploymentReady, "Deployment")
+	setReadyCondition(webstore, demov1alpha1.ConditionTypeServiceReady, serviceReady, "Service")
+	setReadyCondition(webstore, demov1alpha1.ConditionTypeIngressReady, true, "Ingress")
+
+	ready := deploymentReady && serviceReady
+	setReadyCondition(webstore, demov1alpha1.ConditionTypeReady, ready, "child resources")
+
+	if err := r.updateStatus(ctx, webstore); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !ready {
+		logger.V(1).Info("child resources are not ready; requeuing")
 
-	// your logic here
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	logger.V(1).Info("successfully reconciled")
 
 	return ctrl.Result{}, nil
 }
 
+// reconcileDeployment creates or updates the web store deployment and returns
+// whether it is ready.
+func (r *KubebuilderReconciler) reconcileDeployment(ctx context.Context, webstore *demov1alpha1.Kubebuilder) (bool, error) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: webstore.Namespace},
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+		labels := selectorLabels()
+
+		// the replicas are copied so that the deployment does not share the spec of the Kubebuilder
+		replicas := int32(defaultReplicas)
+		if webstore.Spec.WebStoreReplicas != nil {
+			replicas = *webstore.Spec.WebStoreReplicas
+		}
+
+		deployment.Spec.Replicas = &replicas
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		deployment.Spec.Template.Labels = labels
+		deployment.Spec.Template.Spec.Containers = mergeContainer(deployment.Spec.Template.Spec.Containers, corev1.Container{
+			Name:  "webstore-container",
+			Image: webstore.Spec.WebstoreImage,
+			Ports: []corev1.ContainerPort{{ContainerPort: containerPort, Protocol: corev1.ProtocolTCP}},
+		})
+
+		return ctrl.SetControllerReference(webstore, deployment, r.Scheme)
+	}); err != nil {
+		return false, fmt.Errorf("unable to create or update deployment %s; %w", deploymentName, err)
+	}
+
+	return deploymentIsReady(deployment), nil
+}
+
+// reconcileService creates or updates the web store service and returns
+// whether it is ready.
+func (r *KubebuilderReconciler) reconcileService(ctx context.Context, webstore *demov1alpha1.Kubebuilder) (bool, error) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: webstore.Spec.ServiceName, Namespace: webstore.Namespace},
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+		// the cluster ip and other defaulted fields are left untouched on update
+		service.Spec.Selector = selectorLabels()
+		service.Spec.Ports = []corev1.ServicePort{{
+			Protocol:   corev1.ProtocolTCP,
+			Port:       servicePort,
+			TargetPort: intstr.FromInt(containerPort),
+		}}
+
+		return ctrl.SetControllerReference(webstore, service, r.Scheme)
+	}); err != nil {
+		return false, fmt.Errorf("unable to create or update service %s; %w", webstore.Spec.ServiceName, err)
+	}
+
+	return service.Spec.ClusterIP != "", nil
+}
+
+// pruneServices deletes the services owned by the Kubebuilder other than the
+// current web store service, which remain when the service name is changed.
+func (r *KubebuilderReconciler) pruneServices(ctx context.Context, webstore *demov1alpha1.Kubebuilder) error {
+	services := &corev1.ServiceList{}
+	if err := r.List(ctx, services, client.InNamespace(webstore.Namespace)); err != nil {
+		return fmt.Errorf("unable to list services; %w", err)
+	}
+
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Name == webstore.Spec.ServiceName || !metav1.IsControlledBy(service, webstore) {
+			continue
+		}
+
+		log.FromContext(ctx).V(1).Info("deleting previous service", "service", service.Name)
+
+		if err := r.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete service %s; %w", service.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// reconcileIngress creates or updates the web store ingress.  The ingress is
+// served from networking.k8s.io/v1beta1, as declared by the web store manifests.
+func (r *KubebuilderReconciler) reconcileIngress(ctx context.Context, webstore *demov1alpha1.Kubebuilder) error {
+	ingress := &networkingv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: ingressName, Namespace: webstore.Namespace},
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, ingress, func() error {
+		if ingress.Annotations == nil {
+			ingress.Annotations = map[string]string{}
+		}
+
+		ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] = "/"
+		ingress.Spec.Rules = []networkingv1beta1.IngressRule{{
+			Host: ingressHost,
+			IngressRuleValue: networkingv1beta1.IngressRuleValue{
+				HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+					Paths: []networkingv1beta1.HTTPIngressPath{{
+						Path: "/",
+						Backend: networkingv1beta1.IngressBackend{
+							ServiceName: webstore.Spec.ServiceName,
+							ServicePort: intstr.FromInt(servicePort),
+						},
+					}},
+				},
+			},
+		}}
+
+		return ctrl.SetControllerReference(webstore, ingress, r.Scheme)
+	}); err != nil {
+		return fmt.Errorf("unable to create or update ingress %s; %w", ingressName, err)
+	}
+
+	return nil
+}
+
+// fail records a failed condition on the Kubebuilder status and returns the
+// original error so that the request is retried with backoff.
+func (r *KubebuilderReconciler) fail(
+	ctx context.Context,
+	webstore *demov1alpha1.Kubebuilder,
+	conditionType string,
+	err error,
+) (ctrl.Result, error) {
+	for _, t := range []string{conditionType, demov1alpha1.ConditionTypeReady} {
+		meta.SetStatusCondition(&webstore.Status.Conditions, metav1.Condition{
+			Type:               t,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ReconcileFailed",
+			Message:            err.Error(),
+			ObservedGeneration: webstore.Generation,
+		})
+	}
+
+	if updateErr := r.updateStatus(ctx, webstore); updateErr != nil {
+		log.FromContext(ctx).Error(updateErr, "unable to update status")
+	}
+
+	return ctrl.Result{}, err
+}
+
+// updateStatus updates the status of the Kubebuilder with the observed generation.
+func (r *KubebuilderReconciler) updateStatus(ctx context.Context, webstore *demov1alpha1.Kubebuilder) error {
+	webstore.Status.ObservedGeneration = webstore.Generation
+
+	if err := r.Status().Update(ctx, webstore); err != nil {
+		// a conflict means that a newer version of the object will be reconciled
+		if apierrors.IsConflict(err) {
+			return nil
+		}
+
+		return fmt.Errorf("unable to update status; %w", err)
+	}
+
+	return nil
+}
+
+// setReadyCondition sets a readiness condition on the Kubebuilder status.
+func setReadyCondition(webstore *demov1alpha1.Kubebuilder, conditionType string, ready bool, subject string) {
+	condition := metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Ready",
+		Message:            fmt.Sprintf("%s is ready", subject),
+		ObservedGeneration: webstore.Generation,
+	}
+
+	if !ready {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NotReady"
+		condition.Message = fmt.Sprintf("%s is not ready", subject)
+	}
+
+	meta.SetStatusCondition(&webstore.Status.Conditions, condition)
+}
+
+// deploymentIsReady returns whether the deployment has rolled out all of its
+// replicas for its current generation.
+func deploymentIsReady(deployment *appsv1.Deployment) bool {
+	if deployment.Generation != deployment.Status.ObservedGeneration {
+		return false
+	}
+
+	var replicas int32 = 1
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	return deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.ReadyReplicas == replicas
+}
+
+// mergeContainer sets the fields that the controller manages on the named
+// container, leaving fields defaulted by the API server untouched so that
+// CreateOrUpdate does not issue an update on every reconcile.
+func mergeContainer(containers []corev1.Container, desired corev1.Container) []corev1.Container {
+	for i := range containers {
+		if containers[i].Name == desired.Name {
+			containers[i].Image = desired.Image
+			containers[i].Ports = desired.Ports
+
+			return containers[i : i+1]
+		}
+	}
+
+	return []corev1.Container{desired}
+}
+
+// selectorLabels returns the labels which select the web store pods.
+func selectorLabels() map[string]string {
+	return map[string]string{"app": "webstore"}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *KubebuilderReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&demov1alpha1.Kubebuilder{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&networkingv1beta1.Ingress{}).
 		Complete(r)
 }
@@ -0,0 +1,168 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	demov1alpha1 "github.com/scottd018/demos/api/v1alpha1"
+)
+
+var _ = Describe("Kubebuilder controller", func() {
+	const (
+		timeout  = 10 * time.Second
+		interval = 250 * time.Millisecond
+	)
+
+	var (
+		ctx       context.Context
+		namespace *corev1.Namespace
+		webstore  *demov1alpha1.Kubebuilder
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "kubebuilder-"}}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+
+		webstore = &demov1alpha1.Kubebuilder{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubebuilder-sample", Namespace: namespace.Name},
+		}
+		Expect(k8sClient.Create(ctx, webstore)).To(Succeed())
+	})
+
+	It("applies the spec defaults", func() {
+		Expect(webstore.Spec.WebstoreImage).To(Equal("nginx:1.17"))
+		Expect(webstore.Spec.ServiceName).To(Equal("webstore-svc"))
+		Expect(webstore.Spec.WebStoreReplicas).NotTo(BeNil())
+		Expect(*webstore.Spec.WebStoreReplicas).To(Equal(int32(2)))
+	})
+
+	It("creates the child resources owned by the Kubebuilder", func() {
+		deployment := &appsv1.Deployment{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: namespace.Name}, deployment)
+		}, timeout, interval).Should(Succeed())
+
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+		Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.17"))
+		Expect(metav1.IsControlledBy(deployment, webstore)).To(BeTrue())
+
+		service := &corev1.Service{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "webstore-svc", Namespace: namespace.Name}, service)
+		}, timeout, interval).Should(Succeed())
+
+		Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "webstore"}))
+		Expect(service.Spec.Ports).To(HaveLen(1))
+		Expect(service.Spec.Ports[0].TargetPort.IntValue()).To(Equal(containerPort))
+		Expect(metav1.IsControlledBy(service, webstore)).To(BeTrue())
+
+		ingress := &networkingv1beta1.Ingress{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: ingressName, Namespace: namespace.Name}, ingress)
+		}, timeout, interval).Should(Succeed())
+
+		Expect(ingress.Spec.Rules).To(HaveLen(1))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName).To(Equal("webstore-svc"))
+		Expect(metav1.IsControlledBy(ingress, webstore)).To(BeTrue())
+	})
+
+	It("reports the readiness of the child resources as conditions", func() {
+		// envtest does not run the deployment controller, so the deployment never becomes ready
+		Eventually(func() *metav1.Condition {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: webstore.Name, Namespace: namespace.Name}, webstore)).To(Succeed())
+
+			return meta.FindStatusCondition(webstore.Status.Conditions, demov1alpha1.ConditionTypeDeploymentReady)
+		}, timeout, interval).ShouldNot(BeNil())
+
+		Expect(meta.IsStatusConditionFalse(webstore.Status.Conditions, demov1alpha1.ConditionTypeDeploymentReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(webstore.Status.Conditions, demov1alpha1.ConditionTypeServiceReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(webstore.Status.Conditions, demov1alpha1.ConditionTypeIngressReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(webstore.Status.Conditions, demov1alpha1.ConditionTypeReady)).To(BeTrue())
+		Expect(webstore.Status.ObservedGeneration).To(Equal(webstore.Generation))
+	})
+
+	It("replaces the service when the service name changes", func() {
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "webstore-svc", Namespace: namespace.Name}, &corev1.Service{})
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: webstore.Name, Namespace: namespace.Name}, webstore); err != nil {
+				return err
+			}
+
+			webstore.Spec.ServiceName = "webstore-renamed-svc"
+
+			return k8sClient.Update(ctx, webstore)
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "webstore-renamed-svc", Namespace: namespace.Name}, &corev1.Service{})
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "webstore-svc", Namespace: namespace.Name}, &corev1.Service{})
+
+			return apierrors.IsNotFound(err)
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("updates the deployment when the spec changes", func() {
+		deploymentKey := types.NamespacedName{Name: deploymentName, Namespace: namespace.Name}
+
+		Eventually(func() error {
+			return k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{})
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: webstore.Name, Namespace: namespace.Name}, webstore); err != nil {
+				return err
+			}
+
+			replicas := int32(3)
+			webstore.Spec.WebStoreReplicas = &replicas
+			webstore.Spec.WebstoreImage = "nginx:1.21"
+
+			return k8sClient.Update(ctx, webstore)
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() bool {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+				return false
+			}
+
+			return *deployment.Spec.Replicas == 3 &&
+				deployment.Spec.Template.Spec.Containers[0].Image == "nginx:1.21"
+		}, timeout, interval).Should(BeTrue())
+	})
+})
@@ -0,0 +1,244 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+const (
+	// ServiceMonitorKind is the kind of the ServiceMonitor resource.
+	ServiceMonitorKind = "ServiceMonitor"
+
+	// PrometheusRuleKind is the kind of the PrometheusRule resource.
+	PrometheusRuleKind = "PrometheusRule"
+
+	// MonitoringGroup is the API group of the Prometheus operator resources.
+	MonitoringGroup = "monitoring.coreos.com"
+
+	// ExporterContainerName is the name of the nginx exporter container of the web store pods.
+	ExporterContainerName = "nginx-exporter"
+
+	// MetricsPortName is the name of the port which the nginx exporter serves metrics on.
+	MetricsPortName = "metrics"
+
+	// MetricsPort is the port which the nginx exporter serves metrics on.
+	MetricsPort = 9113
+
+	// StubStatusPort is the port which nginx serves its status on to the nginx exporter.  It only
+	// listens on the loopback interface, so the status is not reachable from outside of the pod.
+	StubStatusPort = 8081
+)
+
+// CreateServiceMonitorWebstoreMonitor creates the webstore-monitor ServiceMonitor resource.  The
+// service monitor is only persisted when monitoring is enabled and the Prometheus operator is
+// installed.
+func CreateServiceMonitorWebstoreMonitor(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	monitoring := parent.Spec.Monitoring
+	if !monitoring.IsEnabled() {
+		monitoring = &appsv1alpha1.WebStoreMonitoring{}
+	}
+
+	var resourceObj = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": MonitoringGroup + "/v1",
+			"kind":       ServiceMonitorKind,
+			"metadata": map[string]interface{}{
+				"name": servingName(parent, ServiceMonitorSuffix),
+			},
+			"spec": map[string]interface{}{
+				// Selects the web store Service, which exposes the metrics port of the exporter
+				"selector": map[string]interface{}{
+					"matchLabels": servedLabels(parent),
+				},
+				"namespaceSelector": map[string]interface{}{
+					"matchNames": []interface{}{
+						parent.Namespace,
+					},
+				},
+				"endpoints": []interface{}{
+					map[string]interface{}{
+						"port": MetricsPortName,
+						"path": "/metrics",
+						// Defines the scrape interval, controlled by monitoring.interval
+						"interval": prometheusDuration(monitoring.Interval.Duration),
+					},
+				},
+			},
+		},
+	}
+
+	resourceObj.SetNamespace(parent.Namespace)
+	resourceObj.SetLabels(monitoring.Labels)
+
+	return resourceObj, nil
+}
+
+// CreatePrometheusRuleWebstoreRules creates the webstore-rules PrometheusRule resource.  The
+// prometheus rule is only persisted when alerts are enabled and the Prometheus operator is
+// installed.
+func CreatePrometheusRuleWebstoreRules(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	monitoring := parent.Spec.Monitoring
+	if !monitoring.AlertsAreEnabled() {
+		monitoring = &appsv1alpha1.WebStoreMonitoring{Alerts: &appsv1alpha1.WebStoreAlerts{}}
+	}
+
+	alerts := monitoring.Alerts
+
+	// the metrics of the web store are labelled with the namespace and service of the scrape target
+	selector := fmt.Sprintf(`namespace=%q,service=%q`, parent.Namespace, ServiceName(parent))
+
+	labels := map[string]interface{}{
+		// Defines the severity of the alerts, controlled by monitoring.alerts.severity
+		"severity": alerts.Severity,
+	}
+
+	var resourceObj = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": MonitoringGroup + "/v1",
+			"kind":       PrometheusRuleKind,
+			"metadata": map[string]interface{}{
+				"name": servingName(parent, PrometheusRuleSuffix),
+			},
+			"spec": map[string]interface{}{
+				"groups": []interface{}{
+					map[string]interface{}{
+						"name": fmt.Sprintf("webstore.%s.%s", parent.Namespace, parent.Name),
+						"rules": []interface{}{
+							map[string]interface{}{
+								"alert": "WebStoreDown",
+								"expr":  fmt.Sprintf(`absent(up{%s} == 1)`, selector),
+								// Defines how long the condition must hold, controlled by monitoring.alerts.for
+								"for":    prometheusDuration(alerts.For.Duration),
+								"labels": labels,
+								"annotations": map[string]interface{}{
+									"summary": fmt.Sprintf("Web store %s/%s is down", parent.Namespace, parent.Name),
+									"description": "None of the pods of the web store are serving metrics, " +
+										"or the web store is not being scraped.",
+								},
+							},
+							map[string]interface{}{
+								"alert":  "WebStoreNginxDown",
+								"expr":   fmt.Sprintf(`nginx_up{%s} == 0`, selector),
+								"for":    prometheusDuration(alerts.For.Duration),
+								"labels": labels,
+								"annotations": map[string]interface{}{
+									"summary": fmt.Sprintf("Web store %s/%s has a pod which is not serving", parent.Namespace, parent.Name),
+									"description": "The nginx exporter of pod {{ $labels.pod }} is unable to reach nginx, " +
+										"so the pod is not serving the web store.",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resourceObj.SetNamespace(parent.Namespace)
+	resourceObj.SetLabels(monitoring.Labels)
+
+	return resourceObj, nil
+}
+
+// exporterContainers returns the nginx exporter container which runs alongside the web store
+// container when monitoring is enabled.  The exporter is added to each deployment from the spec
+// rather than the revision, as it does not change how the web store serves traffic.
+func exporterContainers(parent *appsv1alpha1.WebStore) []interface{} {
+	monitoring := parent.Spec.Monitoring
+	if !monitoring.IsEnabled() {
+		return nil
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"name": ExporterContainerName,
+			// Defines the exporter image, controlled by monitoring.exporterImage
+			"image": monitoring.ExporterImage,
+			"args": []interface{}{
+				fmt.Sprintf("-nginx.scrape-uri=http://127.0.0.1:%d/stub_status", StubStatusPort),
+			},
+			"ports": []interface{}{
+				map[string]interface{}{
+					"name":          MetricsPortName,
+					"containerPort": MetricsPort,
+				},
+			},
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{
+					"cpu":    "10m",
+					"memory": "16Mi",
+				},
+				"limits": map[string]interface{}{
+					"cpu":    "50m",
+					"memory": "32Mi",
+				},
+			},
+			// the exporter complies with the restricted pod security standard, as the web store does
+			"securityContext": map[string]interface{}{
+				"runAsNonRoot":             true,
+				"runAsUser":                65534,
+				"allowPrivilegeEscalation": false,
+				"readOnlyRootFilesystem":   true,
+				"capabilities": map[string]interface{}{
+					"drop": []interface{}{"ALL"},
+				},
+				"seccompProfile": map[string]interface{}{
+					"type": "RuntimeDefault",
+				},
+			},
+		},
+	}
+}
+
+// servicePorts returns the ports of the web store Service.  The ports are named, as a service
+// with more than one port requires each of its ports to be named.
+func servicePorts(parent *appsv1alpha1.WebStore) []interface{} {
+	ports := []interface{}{
+		map[string]interface{}{
+			"name":       "http",
+			"protocol":   "TCP",
+			"port":       80,
+			"targetPort": ContainerPort,
+		},
+	}
+
+	if parent.Spec.Monitoring.IsEnabled() {
+		ports = append(ports, map[string]interface{}{
+			"name":       MetricsPortName,
+			"protocol":   "TCP",
+			"port":       MetricsPort,
+			"targetPort": MetricsPort,
+		})
+	}
+
+	return ports
+}
+
+// prometheusDuration formats a duration for Prometheus, which does not accept fractional units.
+// Durations are validated to be whole seconds.
+func prometheusDuration(duration time.Duration) string {
+	return fmt.Sprintf("%ds", int64(duration/time.Second))
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+const (
+	// NameLabel is the label which identifies the pods of web stores with the instance naming.
+	NameLabel = "app.kubernetes.io/name"
+
+	// InstanceLabel is the label which identifies the pods of a single web store with the instance
+	// naming.
+	InstanceLabel = "app.kubernetes.io/instance"
+)
+
+// Below are the suffixes of the child resource names.  A child resource is named by its suffix,
+// prefixed with the name of the web store for the instance naming, or with webstore for the legacy
+// naming.
+const (
+	DeploymentSuffix     = "deploy"
+	NginxConfigMapSuffix = "nginx-conf"
+	ServiceSuffix        = "svc"
+	IngressSuffix        = "ing"
+	NetworkPolicySuffix  = "netpol"
+	CertificateSuffix    = "tls"
+	ServiceMonitorSuffix = "monitor"
+	PrometheusRuleSuffix = "rules"
+)
+
+// ChildName returns the name of a child resource of a web store for a naming.
+func ChildName(parent *appsv1alpha1.WebStore, naming appsv1alpha1.WebStoreNaming, suffix string) string {
+	if naming == appsv1alpha1.NamingLegacy {
+		return appsv1alpha1.LegacyNamePrefix + "-" + suffix
+	}
+
+	return parent.Name + "-" + suffix
+}
+
+// workloadName returns the name of a child resource which runs the pods of the web store.  The
+// workload resources of a new naming are created alongside the workload resources which serve the
+// web store, so they are named by the desired naming.
+func workloadName(parent *appsv1alpha1.WebStore, suffix string) string {
+	return ChildName(parent, parent.Naming(), suffix)
+}
+
+// servingName returns the name of a child resource which routes traffic to the pods of the web
+// store.  The serving resources only move to a new naming once its workload resources are ready,
+// so they are named by the naming which serves the web store.
+func servingName(parent *appsv1alpha1.WebStore, suffix string) string {
+	return ChildName(parent, parent.ServedNaming(), suffix)
+}
+
+// ServiceName returns the name of the web store Service.
+func ServiceName(parent *appsv1alpha1.WebStore) string {
+	if parent.Spec.ServiceName != "" {
+		return parent.Spec.ServiceName
+	}
+
+	return servingName(parent, ServiceSuffix)
+}
+
+// DeploymentName returns the name of the webstore-deploy Deployment, or of the Deployment of a
+// slot of a blue/green or canary rollout.
+func DeploymentName(parent *appsv1alpha1.WebStore, slot string) string {
+	if slot == "" {
+		return workloadName(parent, DeploymentSuffix)
+	}
+
+	return workloadName(parent, DeploymentSuffix+"-"+slot)
+}
+
+// NginxConfigMapName returns the name of the ConfigMap which holds the nginx configuration of the
+// webstore-deploy Deployment, or of the Deployment of a slot.
+func NginxConfigMapName(parent *appsv1alpha1.WebStore, slot string) string {
+	if slot == "" {
+		return workloadName(parent, NginxConfigMapSuffix)
+	}
+
+	return workloadName(parent, NginxConfigMapSuffix+"-"+slot)
+}
+
+// podLabels returns the labels which select the pods of the web store for a naming.  The labels of
+// the other naming are explicitly null so that they are removed from the selector of the Service
+// when it moves to a new naming.
+func podLabels(parent *appsv1alpha1.WebStore, naming appsv1alpha1.WebStoreNaming) map[string]interface{} {
+	if naming == appsv1alpha1.NamingLegacy {
+		return map[string]interface{}{
+			AppLabel:      AppName,
+			NameLabel:     nil,
+			InstanceLabel: nil,
+		}
+	}
+
+	return map[string]interface{}{
+		AppLabel:      nil,
+		NameLabel:     AppName,
+		InstanceLabel: parent.Name,
+	}
+}
+
+// workloadLabels returns the labels of the pods of the web store for the desired naming, along
+// with any additional labels.  Workload resources are created for each naming, so the labels of
+// the other naming are omitted rather than null.
+func workloadLabels(parent *appsv1alpha1.WebStore, additional map[string]interface{}) map[string]interface{} {
+	labels := setLabels(podLabels(parent, parent.Naming()))
+
+	for key, value := range additional {
+		labels[key] = value
+	}
+
+	return labels
+}
+
+// servedLabels returns the labels of the pods of the naming which serves the web store.
+func servedLabels(parent *appsv1alpha1.WebStore) map[string]interface{} {
+	return setLabels(podLabels(parent, parent.ServedNaming()))
+}
+
+// setLabels returns the labels which are not explicitly null.
+func setLabels(labels map[string]interface{}) map[string]interface{} {
+	set := map[string]interface{}{}
+
+	for key, value := range labels {
+		if value != nil {
+			set[key] = value
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+const (
+	// NetworkPolicyKind is the kind of the NetworkPolicy resource.
+	NetworkPolicyKind = "NetworkPolicy"
+
+	// namespaceNameLabel is the label which the API server sets on each namespace to its name.
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// CreateNetworkPolicyWebstoreNetpol creates the webstore-netpol NetworkPolicy resource.  The
+// network policy is only persisted when it is enabled.
+func CreateNetworkPolicyWebstoreNetpol(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	policy := parent.Spec.NetworkPolicy
+
+	from := []interface{}{
+		// Allows traffic from the ingress controller, controlled by networkPolicy.ingressControllerNamespace
+		map[string]interface{}{
+			"namespaceSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					namespaceNameLabel: policy.IngressControllerNamespace,
+				},
+			},
+		},
+		// Allows traffic from the pods in the namespace of the web store
+		map[string]interface{}{
+			"podSelector": map[string]interface{}{},
+		},
+	}
+
+	// Allows traffic from additional peers, controlled by networkPolicy.additionalPeers
+	for i := range policy.AdditionalPeers {
+		peer, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&policy.AdditionalPeers[i])
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert network policy peer; %v", err)
+		}
+
+		from = append(from, peer)
+	}
+
+	// egress is explicitly null when it is not restricted, so that its rules are removed
+	var egress interface{}
+
+	policyTypes := []interface{}{"Ingress"}
+
+	if len(policy.Egress) > 0 {
+		rules := make([]interface{}, len(policy.Egress))
+
+		for i := range policy.Egress {
+			rule, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&policy.Egress[i])
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert network policy egress rule; %v", err)
+			}
+
+			rules[i] = rule
+		}
+
+		egress = rules
+		policyTypes = append(policyTypes, "Egress")
+	}
+
+	ingress := []interface{}{
+		map[string]interface{}{
+			"from": from,
+			"ports": []interface{}{
+				map[string]interface{}{
+					"protocol": "TCP",
+					"port":     ContainerPort,
+				},
+			},
+		},
+	}
+
+	// Allows Prometheus to scrape the nginx exporter, controlled by monitoring.prometheusNamespace
+	if monitoring := parent.Spec.Monitoring; monitoring.IsEnabled() {
+		ingress = append(ingress, map[string]interface{}{
+			"from": []interface{}{
+				map[string]interface{}{
+					"namespaceSelector": map[string]interface{}{
+						"matchLabels": map[string]interface{}{
+							namespaceNameLabel: monitoring.PrometheusNamespace,
+						},
+					},
+				},
+			},
+			"ports": []interface{}{
+				map[string]interface{}{
+					"protocol": "TCP",
+					"port":     MetricsPort,
+				},
+			},
+		})
+	}
+
+	var resourceObj = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       NetworkPolicyKind,
+			"metadata": map[string]interface{}{
+				"name": workloadName(parent, NetworkPolicySuffix),
+			},
+			"spec": map[string]interface{}{
+				// Selects the pods of the deployments, which are also selected by the service
+				"podSelector": map[string]interface{}{
+					"matchLabels": workloadLabels(parent, nil),
+				},
+				"policyTypes": policyTypes,
+				"ingress":     ingress,
+				// Defines the egress rules, controlled by networkPolicy.egress
+				"egress": egress,
+			},
+		},
+	}
+
+	resourceObj.SetNamespace(parent.Namespace)
+
+	return resourceObj, nil
+}
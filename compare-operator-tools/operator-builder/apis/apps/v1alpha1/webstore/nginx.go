@@ -0,0 +1,197 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+const (
+	// NginxConfigKey is the key of the nginx configuration in the nginx ConfigMaps.
+	NginxConfigKey = "nginx.conf"
+
+	// ConfigChecksumAnnotation is the pod template annotation which records the checksum of the
+	// nginx configuration, so that a change to the configuration restarts the pods.
+	ConfigChecksumAnnotation = "apps.acme.com/config-checksum"
+)
+
+// nginxConfigHeader is the start of each nginx configuration.  The pid and temporary files are
+// written to /tmp so that nginx runs as the non-root user of the default security context.
+const nginxConfigHeader = `pid /tmp/nginx.pid;
+worker_processes auto;
+error_log /dev/stderr warn;
+
+events {
+    worker_connections 1024;
+}
+
+http {
+    include /etc/nginx/mime.types;
+    default_type application/octet-stream;
+    access_log /dev/stdout;
+    sendfile on;
+
+    client_body_temp_path /tmp/client_temp;
+    proxy_temp_path /tmp/proxy_temp;
+    fastcgi_temp_path /tmp/fastcgi_temp;
+    uwsgi_temp_path /tmp/uwsgi_temp;
+    scgi_temp_path /tmp/scgi_temp;
+`
+
+// defaultServerBlock is the server block which serves the static content of the image when no
+// server blocks are defined.
+const defaultServerBlock = `location / {
+    root /usr/share/nginx/html;
+    index index.html;
+}`
+
+// stubStatusServerBlock is the server block which serves the status of nginx to the nginx exporter.
+const stubStatusServerBlock = `
+    server {
+        listen 127.0.0.1:%d;
+
+        location = /stub_status {
+            stub_status;
+        }
+    }
+`
+
+// CreateConfigMapWebstoreNginxConf creates the webstore-nginx-conf ConfigMap resource.
+func CreateConfigMapWebstoreNginxConf(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	revision, err := parent.Spec.Revision()
+	if err != nil {
+		return nil, err
+	}
+
+	return createNginxConfigMap(parent, NginxConfigMapName(parent, ""), revision)
+}
+
+// CreateConfigMapWebstoreNginxConfBlue creates the webstore-nginx-conf-blue ConfigMap resource.
+func CreateConfigMapWebstoreNginxConfBlue(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	return createSlotNginxConfigMap(parent, appsv1alpha1.RolloutSlotBlue)
+}
+
+// CreateConfigMapWebstoreNginxConfGreen creates the webstore-nginx-conf-green ConfigMap resource.
+func CreateConfigMapWebstoreNginxConfGreen(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	return createSlotNginxConfigMap(parent, appsv1alpha1.RolloutSlotGreen)
+}
+
+// createSlotNginxConfigMap creates the ConfigMap resource which holds the nginx configuration of
+// the revision served by a slot.
+func createSlotNginxConfigMap(
+	parent *appsv1alpha1.WebStore, slot string) (metav1.Object, error) {
+	revision, err := parent.SlotRevision(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	return createNginxConfigMap(parent, NginxConfigMapName(parent, slot), revision)
+}
+
+// createNginxConfigMap creates a ConfigMap resource which holds the nginx configuration of a
+// revision.
+func createNginxConfigMap(
+	parent *appsv1alpha1.WebStore, name string, revision appsv1alpha1.WebStoreRevision) (metav1.Object, error) {
+	var resourceObj = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"data": map[string]interface{}{
+				// Defines the nginx configuration, controlled by nginx and monitoring
+				NginxConfigKey: renderNginxConfig(revision.Nginx, parent.Spec.Monitoring.IsEnabled()),
+			},
+		},
+	}
+
+	resourceObj.SetNamespace(parent.Namespace)
+
+	return resourceObj, nil
+}
+
+// renderNginxConfig renders the nginx configuration.  Each server block listens on the web store
+// container port, which the web store Service targets.  A monitored web store also serves its
+// status to the nginx exporter on the loopback interface.
+func renderNginxConfig(nginx appsv1alpha1.WebStoreNginx, monitored bool) string {
+	var config strings.Builder
+
+	config.WriteString(nginxConfigHeader)
+
+	for _, upstream := range nginx.Upstreams {
+		fmt.Fprintf(&config, "\n    upstream %s {\n", upstream.Name)
+
+		for _, server := range upstream.Servers {
+			fmt.Fprintf(&config, "        server %s;\n", server)
+		}
+
+		config.WriteString("    }\n")
+	}
+
+	if nginx.Snippet != "" {
+		fmt.Fprintf(&config, "\n%s\n", indent(nginx.Snippet, 1))
+	}
+
+	serverBlocks := nginx.ServerBlocks
+	if len(serverBlocks) == 0 {
+		serverBlocks = []string{defaultServerBlock}
+	}
+
+	for _, serverBlock := range serverBlocks {
+		fmt.Fprintf(&config, "\n    server {\n        listen %d;\n\n%s\n    }\n", ContainerPort, indent(serverBlock, 2))
+	}
+
+	if monitored {
+		fmt.Fprintf(&config, stubStatusServerBlock, StubStatusPort)
+	}
+
+	config.WriteString("}\n")
+
+	return config.String()
+}
+
+// indent indents each non-empty line of the content by a number of levels.
+func indent(content string, levels int) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = strings.Repeat("    ", levels) + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// configChecksum returns the checksum of the nginx configuration of a revision.
+func configChecksum(parent *appsv1alpha1.WebStore, revision appsv1alpha1.WebStoreRevision) string {
+	sum := sha256.Sum256([]byte(renderNginxConfig(revision.Nginx, parent.Spec.Monitoring.IsEnabled())))
+
+	return hex.EncodeToString(sum[:])
+}
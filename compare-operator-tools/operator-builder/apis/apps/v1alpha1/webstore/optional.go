@@ -0,0 +1,144 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+// OptionalResource is a child resource which is only persisted when it is enabled for the parent.
+// An optional resource which has been disabled is removed from the cluster.
+type OptionalResource struct {
+	schema.GroupVersionKind
+
+	Name    func(*appsv1alpha1.WebStore) string
+	Enabled func(*appsv1alpha1.WebStore) bool
+
+	// SkipWhenUnavailable skips the resource rather than failing reconciliation when its API is
+	// not installed in the cluster.
+	SkipWhenUnavailable bool
+}
+
+// OptionalResources are the child resources which are only persisted when they are enabled.
+var OptionalResources = []OptionalResource{
+	{
+		GroupVersionKind: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: CertificateKind},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return servingName(parent, CertificateSuffix)
+		},
+		Enabled: func(parent *appsv1alpha1.WebStore) bool {
+			return parent.Spec.TLS != nil && parent.Spec.TLS.Mode == appsv1alpha1.TLSModeCertManager
+		},
+	},
+	{
+		GroupVersionKind: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: NetworkPolicyKind},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return workloadName(parent, NetworkPolicySuffix)
+		},
+		Enabled: func(parent *appsv1alpha1.WebStore) bool {
+			return parent.Spec.NetworkPolicy.IsEnabled()
+		},
+	},
+	{
+		GroupVersionKind: schema.GroupVersionKind{Group: MonitoringGroup, Version: "v1", Kind: ServiceMonitorKind},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return servingName(parent, ServiceMonitorSuffix)
+		},
+		Enabled: func(parent *appsv1alpha1.WebStore) bool {
+			return parent.Spec.Monitoring.IsEnabled()
+		},
+		SkipWhenUnavailable: true,
+	},
+	{
+		GroupVersionKind: schema.GroupVersionKind{Group: MonitoringGroup, Version: "v1", Kind: PrometheusRuleKind},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return servingName(parent, PrometheusRuleSuffix)
+		},
+		Enabled: func(parent *appsv1alpha1.WebStore) bool {
+			return parent.Spec.Monitoring.AlertsAreEnabled()
+		},
+		SkipWhenUnavailable: true,
+	},
+	{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return NginxConfigMapName(parent, appsv1alpha1.RolloutSlotBlue)
+		},
+		Enabled: slotsAreEnabled,
+	},
+	{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return NginxConfigMapName(parent, appsv1alpha1.RolloutSlotGreen)
+		},
+		Enabled: slotsAreEnabled,
+	},
+	{
+		GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return DeploymentName(parent, appsv1alpha1.RolloutSlotBlue)
+		},
+		Enabled: slotsAreEnabled,
+	},
+	{
+		GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Name: func(parent *appsv1alpha1.WebStore) string {
+			return DeploymentName(parent, appsv1alpha1.RolloutSlotGreen)
+		},
+		Enabled: slotsAreEnabled,
+	},
+}
+
+// slotsAreEnabled determines if the child resources of the blue and green slots are enabled, which
+// are only needed when a new revision is rolled out alongside the current revision.
+func slotsAreEnabled(parent *appsv1alpha1.WebStore) bool {
+	return parent.Spec.Rollout.Strategy.IsStaged()
+}
+
+// GetOptionalResource returns the optional resource which matches a child resource of the parent, or
+// false if the child resource is not optional.  Optional resources are matched by their kind and
+// name, as they may share their kind with child resources which are always enabled.
+func GetOptionalResource(parent *appsv1alpha1.WebStore, object metav1.Object) (OptionalResource, bool) {
+	clientObject, ok := object.(client.Object)
+	if !ok {
+		return OptionalResource{}, false
+	}
+
+	for _, optional := range OptionalResources {
+		if clientObject.GetObjectKind().GroupVersionKind().GroupKind() == optional.GroupKind() &&
+			clientObject.GetName() == optional.Name(parent) {
+			return optional, true
+		}
+	}
+
+	return OptionalResource{}, false
+}
+
+// IsEnabled determines if a child resource is enabled for the parent.  Child resources which are
+// not optional are always enabled.
+func IsEnabled(parent *appsv1alpha1.WebStore, object metav1.Object) bool {
+	optional, found := GetOptionalResource(parent, object)
+	if !found {
+		return true
+	}
+
+	return optional.Enabled(parent)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+// podTemplateFields returns the fields of a revision which are passed through to the pod spec and
+// to the web store container, in their unstructured representation.  Fields which are unset are
+// absent from the returned maps, so that they render as explicitly null and are removed from the
+// pods when they are unset on the web store.
+func podTemplateFields(
+	revision appsv1alpha1.WebStoreRevision) (pod, container map[string]interface{}, err error) {
+	template := revision.WebStorePodTemplate
+
+	if pod, err = runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.PodSpec{
+		ImagePullSecrets: template.ImagePullSecrets,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("unable to convert pod spec of revision [%s]; %v", revision.Name, err)
+	}
+
+	if container, err = runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.Container{
+		Resources:       template.Resources,
+		LivenessProbe:   template.LivenessProbe,
+		ReadinessProbe:  template.ReadinessProbe,
+		Env:             template.Env,
+		EnvFrom:         template.EnvFrom,
+		SecurityContext: template.SecurityContext,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("unable to convert container of revision [%s]; %v", revision.Name, err)
+	}
+
+	// an empty resource requirement is not omitted by the converter
+	if resources, ok := container["resources"].(map[string]interface{}); ok && len(resources) == 0 {
+		delete(container, "resources")
+	}
+
+	return pod, container, nil
+}
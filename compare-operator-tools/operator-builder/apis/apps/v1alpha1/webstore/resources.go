@@ -22,14 +22,41 @@ import (
 	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
 )
 
+const (
+	// AppLabel is the label which selects the pods of the web store.  The deployments, the Service
+	// and the NetworkPolicy all select the pods by the label, so it must not differ between them.
+	AppLabel = "app"
+
+	// AppName is the value of the AppLabel on the pods of the web store.
+	AppName = "webstore"
+
+	// SlotLabel is the label which identifies the pods of a slot of a blue/green or canary rollout.
+	SlotLabel = "apps.acme.com/slot"
+
+	// RevisionAnnotation is the annotation which records the revision that a slot deployment serves.
+	RevisionAnnotation = "apps.acme.com/revision"
+
+	// ContainerPort is the port which the web store container listens on.
+	ContainerPort = 8080
+)
+
 // CreateFuncs is an array of functions that are called to create the child resources for the controller
 // in memory during the reconciliation loop prior to persisting the changes or updates to the Kubernetes
 // database.
 var CreateFuncs = []func(
 	*appsv1alpha1.WebStore) (metav1.Object, error){
+	CreateConfigMapWebstoreNginxConf,
+	CreateConfigMapWebstoreNginxConfBlue,
+	CreateConfigMapWebstoreNginxConfGreen,
 	CreateDeploymentWebstoreDeploy,
+	CreateDeploymentWebstoreDeployBlue,
+	CreateDeploymentWebstoreDeployGreen,
+	CreateCertificateWebstoreTLS,
 	CreateIngressWebstoreIng,
+	CreateNetworkPolicyWebstoreNetpol,
+	CreatePrometheusRuleWebstoreRules,
 	CreateServiceParentSpecServiceName,
+	CreateServiceMonitorWebstoreMonitor,
 }
 
 // InitFuncs is an array of functions that are called prior to starting the controller manager.  This is
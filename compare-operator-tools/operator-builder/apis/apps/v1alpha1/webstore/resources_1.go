@@ -26,37 +26,84 @@ import (
 // CreateDeploymentWebstoreDeploy creates the webstore-deploy Deployment resource.
 func CreateDeploymentWebstoreDeploy(
 	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	revision, err := parent.Spec.Revision()
+	if err != nil {
+		return nil, err
+	}
+
+	pod, container, err := podTemplateFields(revision)
+	if err != nil {
+		return nil, err
+	}
+
 	var resourceObj = &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "apps/v1",
 			"kind":       "Deployment",
 			"metadata": map[string]interface{}{
-				"name": "webstore-deploy",
+				"name": DeploymentName(parent, ""),
 			},
 			"spec": map[string]interface{}{
-				"replicas": parent.Spec.WebStoreReplicas,
+				// Defines the number of replicas, which run from the slot deployments for staged rollouts
+				"replicas": rollingUpdateReplicas(parent),
 				"selector": map[string]interface{}{
-					"matchLabels": map[string]interface{}{
-						"app": "webstore",
+					// Selects the pods of the web store, controlled by naming, excluding the pods of the slots
+					"matchLabels": workloadLabels(parent, nil),
+					"matchExpressions": []interface{}{
+						map[string]interface{}{
+							"key":      SlotLabel,
+							"operator": "DoesNotExist",
+						},
 					},
 				},
 				"template": map[string]interface{}{
 					"metadata": map[string]interface{}{
-						"labels": map[string]interface{}{
-							"app": "webstore",
+						"labels": workloadLabels(parent, nil),
+						"annotations": map[string]interface{}{
+							// Restarts the pods when the nginx configuration changes
+							ConfigChecksumAnnotation: configChecksum(parent, revision),
 						},
 					},
 					"spec": map[string]interface{}{
-						"containers": []interface{}{
+						// Defines the containers, along with the nginx exporter controlled by monitoring
+						"containers": append([]interface{}{
 							map[string]interface{}{
 								"name": "webstore-container",
 								// Defines the web store image, controlled by webstoreImage
 								"image": parent.Spec.WebstoreImage,
 								"ports": []interface{}{
 									map[string]interface{}{
-										"containerPort": 8080,
+										"containerPort": ContainerPort,
+									},
+								},
+								"volumeMounts": []interface{}{
+									map[string]interface{}{
+										"name":      "nginx-conf",
+										"mountPath": "/etc/nginx/nginx.conf",
+										"subPath":   NginxConfigKey,
+										"readOnly":  true,
 									},
 								},
+								// Defines the compute resources, controlled by resources
+								"resources": container["resources"],
+								// Defines the probes, controlled by livenessProbe and readinessProbe
+								"livenessProbe":  container["livenessProbe"],
+								"readinessProbe": container["readinessProbe"],
+								// Defines the environment, controlled by env and envFrom
+								"env":     container["env"],
+								"envFrom": container["envFrom"],
+								// Defines the security context, controlled by securityContext
+								"securityContext": container["securityContext"],
+							},
+						}, exporterContainers(parent)...),
+						// Defines the image pull secrets, controlled by imagePullSecrets
+						"imagePullSecrets": pod["imagePullSecrets"],
+						"volumes": []interface{}{
+							map[string]interface{}{
+								"name": "nginx-conf",
+								"configMap": map[string]interface{}{
+									"name": NginxConfigMapName(parent, ""),
+								},
 							},
 						},
 					},
@@ -78,21 +125,23 @@ func CreateIngressWebstoreIng(
 			"apiVersion": "networking.k8s.io/v1beta1",
 			"kind":       "Ingress",
 			"metadata": map[string]interface{}{
-				"name": "webstore-ing",
+				"name": servingName(parent, IngressSuffix),
 				"annotations": map[string]interface{}{
 					"nginx.ingress.kubernetes.io/rewrite-target": "/",
 				},
 			},
 			"spec": map[string]interface{}{
+				// Defines the certificate which the ingress serves, controlled by tls
+				"tls": ingressTLS(parent),
 				"rules": []interface{}{
 					map[string]interface{}{
-						"host": "app.acme.com",
+						"host": IngressHost,
 						"http": map[string]interface{}{
 							"paths": []interface{}{
 								map[string]interface{}{
 									"path": "/",
 									"backend": map[string]interface{}{
-										"serviceName": "webstorep-svc",
+										"serviceName": ServiceName(parent),
 										"servicePort": 80,
 									},
 								},
@@ -109,7 +158,8 @@ func CreateIngressWebstoreIng(
 	return resourceObj, nil
 }
 
-// CreateServiceParentSpecServiceName creates the parent.Spec.ServiceName Service resource.
+// CreateServiceParentSpecServiceName creates the parent.Spec.ServiceName Service resource.  The
+// name of the service is derived from the naming of the child resources when it is not set.
 func CreateServiceParentSpecServiceName(
 	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
 	var resourceObj = &unstructured.Unstructured{
@@ -117,19 +167,15 @@ func CreateServiceParentSpecServiceName(
 			"kind":       "Service",
 			"apiVersion": "v1",
 			"metadata": map[string]interface{}{
-				"name": parent.Spec.ServiceName,
+				"name": ServiceName(parent),
+				// Labels the service so that it is selected by the ServiceMonitor, controlled by naming
+				"labels": podLabels(parent, parent.ServedNaming()),
 			},
 			"spec": map[string]interface{}{
-				"selector": map[string]interface{}{
-					"app": "webstore",
-				},
-				"ports": []interface{}{
-					map[string]interface{}{
-						"protocol":   "TCP",
-						"port":       80,
-						"targetPort": 8080,
-					},
-				},
+				// Defines the pods which receive traffic, controlled by rollout.strategy
+				"selector": serviceSelector(parent),
+				// Defines the ports, along with the metrics port controlled by monitoring
+				"ports": servicePorts(parent),
 			},
 		},
 	}
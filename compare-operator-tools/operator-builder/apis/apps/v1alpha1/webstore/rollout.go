@@ -0,0 +1,161 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+// CreateDeploymentWebstoreDeployBlue creates the webstore-deploy-blue Deployment resource.
+func CreateDeploymentWebstoreDeployBlue(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	return createSlotDeployment(parent, appsv1alpha1.RolloutSlotBlue)
+}
+
+// CreateDeploymentWebstoreDeployGreen creates the webstore-deploy-green Deployment resource.
+func CreateDeploymentWebstoreDeployGreen(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	return createSlotDeployment(parent, appsv1alpha1.RolloutSlotGreen)
+}
+
+// createSlotDeployment creates the Deployment resource for a slot of a blue/green or canary
+// rollout.  The slots are only persisted for those strategies, in which case both slots exist and
+// a slot which is not in use is scaled to zero replicas.
+func createSlotDeployment(
+	parent *appsv1alpha1.WebStore, slot string) (metav1.Object, error) {
+	revision, err := parent.SlotRevision(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := parent.SlotReplicas(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, container, err := podTemplateFields(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	var resourceObj = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name": DeploymentName(parent, slot),
+				"annotations": map[string]interface{}{
+					RevisionAnnotation: revision.Name,
+				},
+			},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+				"selector": map[string]interface{}{
+					// Selects the pods of the slot, controlled by naming
+					"matchLabels": workloadLabels(parent, map[string]interface{}{SlotLabel: slot}),
+				},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": workloadLabels(parent, map[string]interface{}{SlotLabel: slot}),
+						"annotations": map[string]interface{}{
+							// Restarts the pods when the nginx configuration of the revision changes
+							ConfigChecksumAnnotation: configChecksum(parent, revision),
+						},
+					},
+					"spec": map[string]interface{}{
+						// Defines the containers, along with the nginx exporter controlled by monitoring
+						"containers": append([]interface{}{
+							map[string]interface{}{
+								"name": "webstore-container",
+								// Defines the web store image of the revision served by the slot
+								"image": revision.WebstoreImage,
+								"ports": []interface{}{
+									map[string]interface{}{
+										"containerPort": ContainerPort,
+									},
+								},
+								"volumeMounts": []interface{}{
+									map[string]interface{}{
+										"name":      "nginx-conf",
+										"mountPath": "/etc/nginx/nginx.conf",
+										"subPath":   NginxConfigKey,
+										"readOnly":  true,
+									},
+								},
+								// Defines the compute resources, controlled by resources
+								"resources": container["resources"],
+								// Defines the probes, controlled by livenessProbe and readinessProbe
+								"livenessProbe":  container["livenessProbe"],
+								"readinessProbe": container["readinessProbe"],
+								// Defines the environment, controlled by env and envFrom
+								"env":     container["env"],
+								"envFrom": container["envFrom"],
+								// Defines the security context, controlled by securityContext
+								"securityContext": container["securityContext"],
+							},
+						}, exporterContainers(parent)...),
+						// Defines the image pull secrets, controlled by imagePullSecrets
+						"imagePullSecrets": pod["imagePullSecrets"],
+						"volumes": []interface{}{
+							map[string]interface{}{
+								"name": "nginx-conf",
+								"configMap": map[string]interface{}{
+									"name": NginxConfigMapName(parent, slot),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resourceObj.SetNamespace(parent.Namespace)
+
+	return resourceObj, nil
+}
+
+// rollingUpdateReplicas returns the number of replicas for the webstore-deploy Deployment, which
+// only runs pods while the web store is served in place.
+func rollingUpdateReplicas(parent *appsv1alpha1.WebStore) int {
+	if !parent.ServesInPlace() {
+		return 0
+	}
+
+	return parent.Spec.WebStoreReplicas
+}
+
+// serviceSelector returns the selector of the web store Service.  A blue/green rollout only sends
+// traffic to the active slot, while a canary rollout splits traffic by the replicas of each slot.
+// The slot label is explicitly null for other strategies so that it is removed from the selector
+// when switching away from a blue/green rollout.  It is also null while switching to a blue/green
+// rollout, so that the webstore-deploy Deployment serves until the active slot has taken over.
+// The service selects the pods of the naming which serves the web store, so that traffic only
+// moves to a new naming once its pods are ready.
+func serviceSelector(parent *appsv1alpha1.WebStore) map[string]interface{} {
+	selector := podLabels(parent, parent.ServedNaming())
+	selector[SlotLabel] = nil
+
+	if parent.Spec.Rollout.Strategy == appsv1alpha1.RolloutStrategyBlueGreen && !parent.ServesInPlace() {
+		selector[SlotLabel] = parent.ActiveSlot()
+	}
+
+	return selector
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webstore
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+const (
+	// IngressHost is the host which the web store Ingress serves, and which its certificate is
+	// issued for.
+	IngressHost = "app.acme.com"
+
+	// CertificateKind is the kind of the cert-manager Certificate resource.
+	CertificateKind = "Certificate"
+)
+
+// CreateCertificateWebstoreTLS creates the webstore-tls Certificate resource.  The certificate is
+// only persisted for the CertManager TLS mode.
+func CreateCertificateWebstoreTLS(
+	parent *appsv1alpha1.WebStore) (metav1.Object, error) {
+	tls := parent.Spec.TLS
+	if tls == nil {
+		tls = &appsv1alpha1.WebStoreTLS{Mode: appsv1alpha1.TLSModeCertManager}
+	}
+
+	var resourceObj = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       CertificateKind,
+			"metadata": map[string]interface{}{
+				"name": servingName(parent, CertificateSuffix),
+			},
+			"spec": map[string]interface{}{
+				// Defines the secret which cert-manager writes the certificate to, controlled by tls.secretName
+				"secretName": tls.SecretName,
+				"dnsNames": []interface{}{
+					IngressHost,
+				},
+				// Defines the issuer of the certificate, controlled by tls.issuer
+				"issuerRef": map[string]interface{}{
+					"group": "cert-manager.io",
+					"kind":  tls.Issuer.Kind,
+					"name":  tls.Issuer.Name,
+				},
+				// Defines the validity of the certificate, controlled by tls.duration and tls.renewBefore
+				"duration":    tls.Duration.Duration.String(),
+				"renewBefore": tls.RenewBefore.Duration.String(),
+			},
+		},
+	}
+
+	resourceObj.SetNamespace(parent.Namespace)
+
+	return resourceObj, nil
+}
+
+// ingressTLS returns the TLS configuration of the web store Ingress.  The configuration is
+// explicitly null when TLS is not enabled so that it is removed when TLS is disabled.
+func ingressTLS(parent *appsv1alpha1.WebStore) interface{} {
+	if parent.Spec.TLS == nil {
+		return nil
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"hosts": []interface{}{
+				IngressHost,
+			},
+			"secretName": parent.Spec.TLS.SecretName,
+		},
+	}
+}
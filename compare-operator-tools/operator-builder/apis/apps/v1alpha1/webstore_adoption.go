@@ -0,0 +1,88 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// AdoptionPolicy defines whether a web store adopts a child resource which already exists in the
+// cluster but is not controlled by the web store.
+// +kubebuilder:validation:Enum=Never;IfUnowned;Force
+type AdoptionPolicy string
+
+const (
+	// AdoptionPolicyNever refuses to adopt a child resource which is not controlled by the web store.
+	AdoptionPolicyNever AdoptionPolicy = "Never"
+
+	// AdoptionPolicyIfUnowned adopts a child resource which is not controlled by any other owner.
+	AdoptionPolicyIfUnowned AdoptionPolicy = "IfUnowned"
+
+	// AdoptionPolicyForce adopts a child resource even when it is controlled by another owner, which
+	// is replaced as the controller of the resource.  A child resource is never adopted from another
+	// web store.
+	AdoptionPolicyForce AdoptionPolicy = "Force"
+)
+
+// WebStoreAdoption defines how the web store adopts child resources which already exist in the
+// cluster.
+type WebStoreAdoption struct {
+	// +kubebuilder:default="IfUnowned"
+	// +kubebuilder:validation:Optional
+	// Defines the adoption policy of each child resource which is not listed in resources.
+	Policy AdoptionPolicy `json:"policy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the adoption policy of individual child resources, which takes precedence over the
+	// adoption policy of the web store.
+	Resources []WebStoreResourceAdoption `json:"resources,omitempty"`
+}
+
+// WebStoreResourceAdoption defines the adoption policy of a child resource.
+type WebStoreResourceAdoption struct {
+	// +kubebuilder:validation:Required
+	// Defines the kind of the child resource, e.g. Deployment.
+	Kind string `json:"kind"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the name of the child resource.  The adoption policy applies to each child resource
+	// of the kind when unset.
+	Name string `json:"name,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// Defines the adoption policy of the child resource.
+	Policy AdoptionPolicy `json:"policy"`
+}
+
+// AdoptionPolicy returns the adoption policy of a child resource.  A policy for the named child
+// resource takes precedence over a policy for each child resource of its kind, which in turn takes
+// precedence over the adoption policy of the web store.
+func (component *WebStore) AdoptionPolicy(kind, name string) AdoptionPolicy {
+	policy := component.Spec.Adoption.Policy
+
+	matchedName := false
+
+	for _, resource := range component.Spec.Adoption.Resources {
+		if resource.Kind != kind || (resource.Name != "" && resource.Name != name) {
+			continue
+		}
+
+		if resource.Name != "" {
+			policy, matchedName = resource.Policy, true
+		} else if !matchedName {
+			policy = resource.Policy
+		}
+	}
+
+	return policy
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebStoreMonitoring defines how the web store is monitored by the Prometheus operator.  An nginx
+// exporter runs alongside each web store pod, and a ServiceMonitor scrapes its metrics.
+type WebStoreMonitoring struct {
+	// +kubebuilder:default="30s"
+	// +kubebuilder:validation:Optional
+	// Defines the interval at which the metrics of the web store are scraped.
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the labels of the ServiceMonitor and PrometheusRule, which a Prometheus instance
+	// selects them by.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +kubebuilder:default="nginx/nginx-prometheus-exporter:0.10.0"
+	// +kubebuilder:validation:Optional
+	// Defines the image of the nginx exporter which runs alongside each web store pod.
+	ExporterImage string `json:"exporterImage,omitempty"`
+
+	// +kubebuilder:default="monitoring"
+	// +kubebuilder:validation:Optional
+	// Defines the namespace of Prometheus, which the NetworkPolicy allows to scrape the metrics.
+	PrometheusNamespace string `json:"prometheusNamespace,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the alerts of the web store.  A PrometheusRule is only generated when it is set.
+	Alerts *WebStoreAlerts `json:"alerts,omitempty"`
+}
+
+// WebStoreAlerts defines the alerts which are generated for a web store.
+type WebStoreAlerts struct {
+	// +kubebuilder:default="5m"
+	// +kubebuilder:validation:Optional
+	// Defines how long a condition must hold before an alert fires.
+	For metav1.Duration `json:"for,omitempty"`
+
+	// +kubebuilder:default="warning"
+	// +kubebuilder:validation:Optional
+	// Defines the severity label of the alerts.
+	Severity string `json:"severity,omitempty"`
+}
+
+// IsEnabled determines if the web store is monitored.
+func (monitoring *WebStoreMonitoring) IsEnabled() bool {
+	return monitoring != nil
+}
+
+// AlertsAreEnabled determines if alerts are generated for the web store.
+func (monitoring *WebStoreMonitoring) AlertsAreEnabled() bool {
+	return monitoring != nil && monitoring.Alerts != nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// WebStoreNaming defines how the child resources of a web store are named and labelled.
+// +kubebuilder:validation:Enum=Instance;Legacy
+type WebStoreNaming string
+
+const (
+	// NamingInstance prefixes the names of the child resources with the name of the web store, and
+	// labels the pods with the app.kubernetes.io/instance label, so that any number of web stores
+	// may run in a namespace.
+	NamingInstance WebStoreNaming = "Instance"
+
+	// NamingLegacy uses the fixed child resource names and the app: webstore label which web
+	// stores were created with before the instance naming existed.  Only a single web store may run
+	// in a namespace.
+	NamingLegacy WebStoreNaming = "Legacy"
+)
+
+// LegacyNamePrefix is the prefix of the child resource names of the legacy naming.
+const LegacyNamePrefix = "webstore"
+
+// Naming returns the naming which the child resources of the web store are desired to have.
+func (component *WebStore) Naming() WebStoreNaming {
+	// the child resource names of a web store named webstore are the same for either naming, so it
+	// keeps the naming that it is served by, as the selectors of its deployments can not change
+	if component.Name == LegacyNamePrefix {
+		if served := component.recordedNaming(); served != "" {
+			return served
+		}
+	}
+
+	if component.Spec.Naming != "" {
+		return component.Spec.Naming
+	}
+
+	// web stores which were created before the naming existed are only migrated on request
+	if served := component.recordedNaming(); served != "" {
+		return served
+	}
+
+	return NamingInstance
+}
+
+// ServedNaming returns the naming of the child resources which serve the web store.  It differs
+// from the desired naming while the child resources are migrated, until the child resources of the
+// desired naming are ready.
+func (component *WebStore) ServedNaming() WebStoreNaming {
+	if served := component.recordedNaming(); served != "" {
+		return served
+	}
+
+	return component.Naming()
+}
+
+// IsMigrating determines if the child resources of the web store are being migrated to a new
+// naming.
+func (component *WebStore) IsMigrating() bool {
+	return component.ServedNaming() != component.Naming()
+}
+
+// recordedNaming returns the naming which has been recorded on the status, or an empty naming if
+// no child resources have been persisted.
+func (component *WebStore) recordedNaming() WebStoreNaming {
+	if component.Status.Naming != "" || len(component.Status.Resources) == 0 {
+		return component.Status.Naming
+	}
+
+	// web stores which were reconciled before the naming was recorded are served by legacy names
+	return NamingLegacy
+}
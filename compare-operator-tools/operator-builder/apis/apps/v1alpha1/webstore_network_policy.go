@@ -0,0 +1,49 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// WebStoreNetworkPolicy defines the NetworkPolicy which restricts the traffic of the web store
+// pods.  By default, ingress is only allowed from the ingress controller namespace and from pods
+// in the namespace of the web store.
+type WebStoreNetworkPolicy struct {
+	// +kubebuilder:default=true
+	// +kubebuilder:validation:Optional
+	// Defines whether the traffic of the web store pods is restricted.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// +kubebuilder:default="ingress-nginx"
+	// +kubebuilder:validation:Optional
+	// Defines the namespace of the ingress controller which routes traffic to the web store.
+	IngressControllerNamespace string `json:"ingressControllerNamespace,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines additional peers which are allowed to send traffic to the web store pods.
+	AdditionalPeers []networkingv1.NetworkPolicyPeer `json:"additionalPeers,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the egress rules of the web store pods.  Egress is not restricted when unset.
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+// IsEnabled determines if the traffic of the web store pods is restricted.
+func (policy *WebStoreNetworkPolicy) IsEnabled() bool {
+	return policy.Enabled == nil || *policy.Enabled
+}
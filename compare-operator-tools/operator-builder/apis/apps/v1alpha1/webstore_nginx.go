@@ -0,0 +1,45 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// WebStoreNginx defines the nginx configuration of a web store.  The configuration is rendered into
+// the nginx.conf of the web store, which always listens on the web store container port.
+type WebStoreNginx struct {
+	// +kubebuilder:validation:Optional
+	// Defines the upstream blocks which the server blocks may proxy to.
+	Upstreams []WebStoreNginxUpstream `json:"upstreams,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the contents of the server blocks, excluding the listen directive.  A server block
+	// which serves the static content of the image is used when no server blocks are defined.
+	ServerBlocks []string `json:"serverBlocks,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines raw nginx.conf directives which are added to the http block.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// WebStoreNginxUpstream defines an nginx upstream block.
+type WebStoreNginxUpstream struct {
+	// +kubebuilder:validation:MinLength=1
+	// Defines the name of the upstream, which server blocks reference with proxy_pass.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinItems=1
+	// Defines the addresses of the servers of the upstream, e.g. backend.team-a.svc:8080.
+	Servers []string `json:"servers"`
+}
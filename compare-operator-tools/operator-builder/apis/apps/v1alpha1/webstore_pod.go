@@ -0,0 +1,57 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WebStorePodTemplate defines the fields of a web store which are passed through to the pods of
+// the web store.
+type WebStorePodTemplate struct {
+	// +kubebuilder:default={requests: {cpu: "100m", memory: "128Mi"}, limits: {cpu: "500m", memory: "256Mi"}}
+	// +kubebuilder:validation:Optional
+	// Defines the compute resources of the web store container.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// +kubebuilder:default={httpGet: {path: "/", port: 8080}}
+	// +kubebuilder:validation:Optional
+	// Defines the probe which restarts the web store container when it fails.
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// +kubebuilder:default={httpGet: {path: "/", port: 8080}}
+	// +kubebuilder:validation:Optional
+	// Defines the probe which removes the web store container from service when it fails.
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the environment variables of the web store container.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the sources of environment variables of the web store container.
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// +kubebuilder:default={allowPrivilegeEscalation: false, capabilities: {drop: {"ALL"}}, runAsNonRoot: true, runAsUser: 101, seccompProfile: {type: "RuntimeDefault"}}
+	// +kubebuilder:validation:Optional
+	// Defines the security context of the web store container.
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the secrets which are used to pull the web store image.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
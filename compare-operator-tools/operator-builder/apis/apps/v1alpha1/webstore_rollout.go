@@ -0,0 +1,288 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// WebStoreRolloutStrategy defines how a new revision of a web store is rolled out.
+// +kubebuilder:validation:Enum=RollingUpdate;BlueGreen;Canary
+type WebStoreRolloutStrategy string
+
+const (
+	// RolloutStrategyRollingUpdate updates the web store deployment in place.
+	RolloutStrategyRollingUpdate WebStoreRolloutStrategy = "RollingUpdate"
+
+	// RolloutStrategyBlueGreen runs a new revision alongside the current revision and switches the
+	// service to the new revision once it is ready.
+	RolloutStrategyBlueGreen WebStoreRolloutStrategy = "BlueGreen"
+
+	// RolloutStrategyCanary shifts a weighted share of the replicas to a new revision until it is
+	// promoted.
+	RolloutStrategyCanary WebStoreRolloutStrategy = "Canary"
+)
+
+// WebStoreRolloutPhase defines the phase of the rollout of a web store revision.
+type WebStoreRolloutPhase string
+
+const (
+	RolloutPhaseProgressing WebStoreRolloutPhase = "Progressing"
+	RolloutPhasePaused      WebStoreRolloutPhase = "Paused"
+	RolloutPhasePromoted    WebStoreRolloutPhase = "Promoted"
+	RolloutPhaseAborted     WebStoreRolloutPhase = "Aborted"
+)
+
+// Below are the slots which the deployments of a blue/green or canary rollout run in.  The active
+// slot serves the current revision while the other slot serves a new revision.
+const (
+	RolloutSlotBlue  = "blue"
+	RolloutSlotGreen = "green"
+)
+
+// Below are the bounds of the canary weight.
+const (
+	MinCanaryWeight = 1
+	MaxCanaryWeight = 99
+)
+
+// WebStoreRollout defines how a new revision of a web store is rolled out.
+type WebStoreRollout struct {
+	// +kubebuilder:default="RollingUpdate"
+	// +kubebuilder:validation:Optional
+	// Defines the strategy which is used to roll out a new revision.
+	Strategy WebStoreRolloutStrategy `json:"strategy,omitempty"`
+
+	// +kubebuilder:default=20
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// Defines the percentage of replicas which run a new revision during a canary rollout.
+	CanaryWeight int `json:"canaryWeight,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines whether a new revision is held back from promotion once it is ready.  Only applies
+	// to the BlueGreen and Canary strategies.
+	Paused bool `json:"paused,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines whether a new revision which has not yet been promoted is scaled down, leaving the
+	// current revision in place.  Only applies to the BlueGreen and Canary strategies.
+	Abort bool `json:"abort,omitempty"`
+}
+
+// WebStoreRevision defines the fields of a web store which are rendered into its pods.
+type WebStoreRevision struct {
+	// Name defines the name of the revision, which is a hash of the remaining revision fields.
+	Name string `json:"name"`
+
+	// WebstoreImage defines the web store image of the revision.
+	WebstoreImage string `json:"webstoreImage"`
+
+	// Nginx defines the nginx configuration of the revision.
+	Nginx WebStoreNginx `json:"nginx,omitempty"`
+
+	// Defines the fields of the revision which are passed through to the pods of the web store.
+	WebStorePodTemplate `json:",inline"`
+}
+
+// WebStoreRolloutStatus defines the observed state of the rollout of a web store.
+type WebStoreRolloutStatus struct {
+	// Phase defines the phase of the rollout of the desired revision.
+	Phase WebStoreRolloutPhase `json:"phase,omitempty"`
+
+	// ActiveSlot defines the slot which serves the current revision.
+	ActiveSlot string `json:"activeSlot,omitempty"`
+
+	// CurrentRevision defines the revision which has most recently been promoted.
+	CurrentRevision *WebStoreRevision `json:"currentRevision,omitempty"`
+
+	// PreviousRevision defines the revision which was current prior to the most recent promotion.
+	PreviousRevision *WebStoreRevision `json:"previousRevision,omitempty"`
+
+	// Strategy defines the strategy which the current revision was rolled out with.
+	Strategy WebStoreRolloutStrategy `json:"strategy,omitempty"`
+}
+
+// Revision returns the revision which is desired by the spec.
+func (spec *WebStoreSpec) Revision() (WebStoreRevision, error) {
+	revision := WebStoreRevision{
+		WebstoreImage:       spec.WebstoreImage,
+		Nginx:               *spec.Nginx.DeepCopy(),
+		WebStorePodTemplate: *spec.WebStorePodTemplate.DeepCopy(),
+	}
+
+	// the revision is named by the hash of its content, which excludes the name
+	content, err := json.Marshal(revision)
+	if err != nil {
+		return WebStoreRevision{}, fmt.Errorf("unable to marshal revision; %w", err)
+	}
+
+	sum := sha256.Sum256(content)
+	revision.Name = hex.EncodeToString(sum[:])[:10]
+
+	return revision, nil
+}
+
+// IsStaged determines if a rollout strategy runs a new revision alongside the current revision.
+func (strategy WebStoreRolloutStrategy) IsStaged() bool {
+	return strategy == RolloutStrategyBlueGreen || strategy == RolloutStrategyCanary
+}
+
+// ServesInPlace determines if the current revision is served by the webstore-deploy Deployment,
+// which is the case for the RollingUpdate strategy.  A web store which switches to a staged
+// strategy keeps being served in place until the active slot is ready and the switch has been
+// promoted, as the pods of the slot do not exist until then.
+func (component *WebStore) ServesInPlace() bool {
+	if !component.Spec.Rollout.Strategy.IsStaged() {
+		return true
+	}
+
+	status := component.Status.Rollout
+
+	return status.CurrentRevision != nil && !status.Strategy.IsStaged()
+}
+
+// ActiveSlot returns the slot which serves the current revision.
+func (component *WebStore) ActiveSlot() string {
+	if component.Status.Rollout.ActiveSlot == "" {
+		return RolloutSlotBlue
+	}
+
+	return component.Status.Rollout.ActiveSlot
+}
+
+// CandidateSlot returns the slot which serves a new revision.
+func (component *WebStore) CandidateSlot() string {
+	if component.ActiveSlot() == RolloutSlotBlue {
+		return RolloutSlotGreen
+	}
+
+	return RolloutSlotBlue
+}
+
+// ActiveRevision returns the revision which is served from the active slot.  The revision which
+// is desired by the spec is active until a revision has been promoted.
+func (component *WebStore) ActiveRevision() (WebStoreRevision, error) {
+	if component.Status.Rollout.CurrentRevision == nil {
+		return component.Spec.Revision()
+	}
+
+	return *component.Status.Rollout.CurrentRevision, nil
+}
+
+// CandidateRevision returns the revision which is desired by the spec when it differs from the
+// current revision, or nil if there is no new revision to roll out.
+func (component *WebStore) CandidateRevision() (*WebStoreRevision, error) {
+	desired, err := component.Spec.Revision()
+	if err != nil {
+		return nil, err
+	}
+
+	if current := component.Status.Rollout.CurrentRevision; current == nil || current.Name == desired.Name {
+		return nil, nil
+	}
+
+	return &desired, nil
+}
+
+// SlotRevision returns the revision which is rendered into the deployment of a slot.  A slot
+// which is not in use serves the active revision so that it is ready to be scaled up.  While a web
+// store switches to a staged strategy, the slots serve the revision desired by the spec, as the
+// webstore-deploy Deployment does.
+func (component *WebStore) SlotRevision(slot string) (WebStoreRevision, error) {
+	if component.ServesInPlace() {
+		return component.Spec.Revision()
+	}
+
+	if slot != component.ActiveSlot() {
+		candidate, err := component.CandidateRevision()
+		if err != nil {
+			return WebStoreRevision{}, err
+		}
+
+		if candidate != nil {
+			return *candidate, nil
+		}
+	}
+
+	return component.ActiveRevision()
+}
+
+// SlotReplicas returns the number of replicas for the deployment of a slot.
+func (component *WebStore) SlotReplicas(slot string) (int, error) {
+	rollout := component.Spec.Rollout
+	if !rollout.Strategy.IsStaged() {
+		return 0, nil
+	}
+
+	candidate, err := component.CandidateRevision()
+	if err != nil {
+		return 0, err
+	}
+
+	replicas := component.Spec.WebStoreReplicas
+	active := slot == component.ActiveSlot()
+
+	// only the active slot is scaled up while a web store switches to a staged strategy, so that it
+	// takes over from the webstore-deploy Deployment once it is ready
+	if candidate == nil || rollout.Abort || component.ServesInPlace() {
+		if active {
+			return replicas, nil
+		}
+
+		return 0, nil
+	}
+
+	if rollout.Strategy == RolloutStrategyBlueGreen {
+		return replicas, nil
+	}
+
+	canaryReplicas := component.CanaryReplicas()
+	if active {
+		return replicas - canaryReplicas, nil
+	}
+
+	return canaryReplicas, nil
+}
+
+// CanaryReplicas returns the number of replicas which run a new revision during a canary rollout.
+// At least one replica runs the new revision, unless the web store has no replicas, and at least
+// one replica keeps running the current revision, unless the web store has a single replica.
+func (component *WebStore) CanaryReplicas() int {
+	replicas := component.Spec.WebStoreReplicas
+	if replicas == 0 {
+		return 0
+	}
+
+	// round up so that small web stores still run the new revision
+	canaryReplicas := (replicas*component.Spec.Rollout.CanaryWeight + 99) / 100
+
+	switch {
+	case canaryReplicas < 1:
+		return 1
+	case replicas > 1 && canaryReplicas > replicas-1:
+		return replicas - 1
+	case canaryReplicas > replicas:
+		return replicas
+	}
+
+	return canaryReplicas
+}
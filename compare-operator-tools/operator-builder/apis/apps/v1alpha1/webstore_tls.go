@@ -0,0 +1,90 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebStoreTLSMode defines how the certificate of the web store Ingress is provisioned.
+// +kubebuilder:validation:Enum=Secret;SelfSigned;CertManager
+type WebStoreTLSMode string
+
+const (
+	// TLSModeSecret serves the certificate of an existing secret, which is managed outside of the
+	// web store.
+	TLSModeSecret WebStoreTLSMode = "Secret"
+
+	// TLSModeSelfSigned serves a certificate which is signed by a certificate authority that the
+	// controller generates.  The certificate is rotated before it expires.
+	TLSModeSelfSigned WebStoreTLSMode = "SelfSigned"
+
+	// TLSModeCertManager serves a certificate which is issued by cert-manager.
+	TLSModeCertManager WebStoreTLSMode = "CertManager"
+)
+
+// WebStoreTLS defines how the certificate of the web store Ingress is provisioned.
+type WebStoreTLS struct {
+	// +kubebuilder:validation:Required
+	// Defines how the certificate is provisioned.
+	Mode WebStoreTLSMode `json:"mode"`
+
+	// +kubebuilder:default="webstore-tls"
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	// Defines the name of the secret which holds the certificate.  The secret must already exist
+	// for the Secret mode, and is written by the controller or cert-manager otherwise.
+	SecretName string `json:"secretName,omitempty"`
+
+	// +kubebuilder:default={kind: "Issuer"}
+	// +kubebuilder:validation:Optional
+	// Defines the cert-manager issuer of the certificate.  Only applies to the CertManager mode.
+	Issuer WebStoreTLSIssuer `json:"issuer,omitempty"`
+
+	// +kubebuilder:default="2160h"
+	// +kubebuilder:validation:Optional
+	// Defines how long an issued certificate is valid for.  Does not apply to the Secret mode.
+	Duration metav1.Duration `json:"duration,omitempty"`
+
+	// +kubebuilder:default="720h"
+	// +kubebuilder:validation:Optional
+	// Defines how long before its expiry an issued certificate is renewed.  Does not apply to the
+	// Secret mode.
+	RenewBefore metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// WebStoreTLSIssuer defines a cert-manager issuer.
+type WebStoreTLSIssuer struct {
+	// +kubebuilder:validation:Optional
+	// Defines the name of the issuer.
+	Name string `json:"name,omitempty"`
+
+	// +kubebuilder:default="Issuer"
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// Defines the kind of the issuer.
+	Kind string `json:"kind,omitempty"`
+}
+
+// WebStoreTLSStatus defines the observed state of the certificate of a web store.
+type WebStoreTLSStatus struct {
+	// SecretName defines the name of the secret which holds the certificate.
+	SecretName string `json:"secretName,omitempty"`
+
+	// NotAfter defines the time at which the certificate expires.
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+}
@@ -38,13 +38,68 @@ type WebStoreSpec struct {
 	// Defines the web store image
 	WebstoreImage string `json:"webstoreImage"`
 
-	// +kubebuilder:default="webstore-svc"
 	// +kubebuilder:validation:Optional
-	ServiceName string `json:"serviceName"`
+	// +kubebuilder:validation:MaxLength=63
+	// Defines the name of the web store Service.  The name is derived from the naming of the child
+	// resources when unset.
+	ServiceName string `json:"serviceName,omitempty"`
 
 	// +kubebuilder:default=2
 	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
 	WebStoreReplicas int `json:"webStoreReplicas"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the collection which this web store belongs to.  A web store is its own collection
+	// unless a collection is referenced.
+	Collection common.CollectionReference `json:"collection,omitempty"`
+
+	// +kubebuilder:default={strategy: "RollingUpdate"}
+	// +kubebuilder:validation:Optional
+	// Defines how a new revision of the web store is rolled out.
+	Rollout WebStoreRollout `json:"rollout,omitempty"`
+
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// Defines the number of previously reconciled revisions of the spec which are retained to allow
+	// a rollback.
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines the nginx configuration of the web store.
+	Nginx WebStoreNginx `json:"nginx,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines how the certificate of the web store Ingress is provisioned.  The Ingress does not
+	// terminate TLS when unset.
+	TLS *WebStoreTLS `json:"tls,omitempty"`
+
+	// +kubebuilder:default={enabled: true, ingressControllerNamespace: "ingress-nginx"}
+	// +kubebuilder:validation:Optional
+	// Defines the NetworkPolicy which restricts the traffic of the web store pods.
+	NetworkPolicy WebStoreNetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines how the web store is monitored.  The web store is not monitored when unset.
+	Monitoring *WebStoreMonitoring `json:"monitoring,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Defines how the child resources of the web store are named and labelled.  Child resources
+	// are migrated to a new naming once the child resources of the new naming are ready.  When
+	// unset, new web stores use the Instance naming, while web stores which are already served by
+	// the Legacy naming keep it until the Instance naming is explicitly requested.
+	Naming WebStoreNaming `json:"naming,omitempty"`
+
+	// +kubebuilder:default={policy: "IfUnowned"}
+	// +kubebuilder:validation:Optional
+	// Defines how the web store adopts child resources which already exist in the cluster but are
+	// not controlled by the web store.
+	Adoption WebStoreAdoption `json:"adoption,omitempty"`
+
+	// Defines the fields which are passed through to the pods of the web store.
+	WebStorePodTemplate `json:",inline"`
 }
 
 // WebStoreStatus defines the observed state of WebStore.
@@ -56,6 +111,19 @@ type WebStoreStatus struct {
 	DependenciesSatisfied bool                    `json:"dependenciesSatisfied,omitempty"`
 	Conditions            []common.PhaseCondition `json:"conditions,omitempty"`
 	Resources             []common.Resource       `json:"resources,omitempty"`
+	Rollout               WebStoreRolloutStatus   `json:"rollout,omitempty"`
+
+	// Revision is the number of the revision which holds the last successfully reconciled spec.
+	Revision int64 `json:"revision,omitempty"`
+
+	// TLS is the observed state of the certificate of the web store Ingress.
+	TLS *WebStoreTLSStatus `json:"tls,omitempty"`
+
+	// Naming is the naming of the child resources which serve the web store.
+	Naming WebStoreNaming `json:"naming,omitempty"`
+
+	// Degraded is set when the web store can not be reconciled until its spec changes.
+	Degraded *common.DegradedCondition `json:"degraded,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -105,18 +173,53 @@ func (component WebStore) GetPhaseConditions() []common.PhaseCondition {
 	return component.Status.Conditions
 }
 
-// SetPhaseCondition sets the phase conditions for a component.
+// SetPhaseCondition sets the phase conditions for a component.  The last modified time of a
+// condition is only moved forward when the condition changes, so that setting an unchanged
+// condition leaves the status unchanged.
 func (component *WebStore) SetPhaseCondition(condition common.PhaseCondition) {
 	if found := condition.GetPhaseConditionIndex(component); found >= 0 {
+		existing := component.Status.Conditions[found]
+
+		if condition.LastModified == "" && existing.State == condition.State && existing.Message == condition.Message {
+			condition.LastModified = existing.LastModified
+		}
+
 		if condition.LastModified == "" {
 			condition.LastModified = time.Now().UTC().String()
 		}
 		component.Status.Conditions[found] = condition
 	} else {
+		if condition.LastModified == "" {
+			condition.LastModified = time.Now().UTC().String()
+		}
 		component.Status.Conditions = append(component.Status.Conditions, condition)
 	}
 }
 
+// GetDegradedCondition returns the degraded condition for a component.
+func (component WebStore) GetDegradedCondition() *common.DegradedCondition {
+	return component.Status.Degraded
+}
+
+// SetDegradedCondition sets the degraded condition for a component.  The condition is cleared when
+// it is nil.
+func (component *WebStore) SetDegradedCondition(condition *common.DegradedCondition) {
+	if condition != nil {
+		condition.ObservedGeneration = component.Generation
+
+		if existing := component.Status.Degraded; condition.LastModified == "" && existing != nil &&
+			existing.Phase == condition.Phase && existing.Message == condition.Message {
+			condition.LastModified = existing.LastModified
+		}
+
+		if condition.LastModified == "" {
+			condition.LastModified = time.Now().UTC().String()
+		}
+	}
+
+	component.Status.Degraded = condition
+}
+
 // GetResources returns the resources for a component.
 func (component WebStore) GetResources() []common.Resource {
 	return component.Status.Resources
@@ -124,17 +227,53 @@ func (component WebStore) GetResources() []common.Resource {
 
 // SetResources sets the phase conditions for a component.
 func (component *WebStore) SetResource(resource common.Resource) {
+	// record the naming when the first child resource is persisted, so that web stores which were
+	// reconciled before the naming was recorded may be told apart
+	if len(component.Status.Resources) == 0 && component.Status.Naming == "" {
+		component.Status.Naming = component.Naming()
+	}
 
 	if found := resource.GetResourceIndex(component); found >= 0 {
+		existing := component.Status.Resources[found].ResourceCondition
+
+		if resource.ResourceCondition.LastModified == "" {
+			// the last modified time is only moved forward when the condition changes
+			compared := existing
+			compared.LastModified = ""
+
+			if compared == resource.ResourceCondition {
+				resource.ResourceCondition.LastModified = existing.LastModified
+			}
+		}
+
 		if resource.ResourceCondition.LastModified == "" {
 			resource.ResourceCondition.LastModified = time.Now().UTC().String()
 		}
 		component.Status.Resources[found] = resource
 	} else {
+		if resource.ResourceCondition.LastModified == "" {
+			resource.ResourceCondition.LastModified = time.Now().UTC().String()
+		}
 		component.Status.Resources = append(component.Status.Resources, resource)
 	}
 }
 
+// GetCollectionReference returns the reference to the collection for a component.  A WebStore is a
+// collection itself, so it references itself unless a collection is explicitly referenced.
+func (component *WebStore) GetCollectionReference() common.CollectionReference {
+	reference := *component.Spec.Collection.DeepCopy()
+
+	if reference.IsEmpty() {
+		reference.Name = component.Name
+	}
+
+	if reference.Namespace == "" {
+		reference.Namespace = component.Namespace
+	}
+
+	return reference
+}
+
 // GetDependencies returns the dependencies for a component.
 func (*WebStore) GetDependencies() []common.Component {
 	return []common.Component{}
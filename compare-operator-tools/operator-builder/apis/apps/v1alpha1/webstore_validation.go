@@ -0,0 +1,445 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// imageReferenceRegexp matches a container image reference as defined by the distribution
+// reference grammar, e.g. registry.example.com:5000/org/nginx:1.17@sha256:<digest>.
+var imageReferenceRegexp = regexp.MustCompile(
+	`^(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+		`[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*` +
+		`(?::[\w][\w.-]{0,127})?` +
+		`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`,
+)
+
+// nginxNameRegexp matches the name of an nginx upstream.
+var nginxNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// nginxServerRegexp matches the address of a server of an nginx upstream, including its parameters,
+// e.g. backend.team-a.svc:8080 weight=2.
+var nginxServerRegexp = regexp.MustCompile(`^[^;{}\s]+(?: [^;{}\s]+)*$`)
+
+// Validate performs the semantic validation of a WebStore which cannot be expressed in the OpenAPI
+// schema of the custom resource definition.  It expects that defaults have already been applied.
+func (component *WebStore) Validate() field.ErrorList {
+	var allErrs field.ErrorList
+
+	metaPath := field.NewPath("metadata")
+
+	for _, msg := range validation.IsDNS1123Subdomain(component.Name) {
+		allErrs = append(allErrs, field.Invalid(metaPath.Child("name"), component.Name, msg))
+	}
+
+	if component.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(component.Namespace) {
+			allErrs = append(allErrs, field.Invalid(metaPath.Child("namespace"), component.Namespace, msg))
+		}
+	}
+
+	allErrs = append(allErrs, component.validateNaming(metaPath, field.NewPath("spec"))...)
+
+	return append(allErrs, component.Spec.validate(field.NewPath("spec"))...)
+}
+
+// validateNaming performs the semantic validation of the naming of the child resources of a
+// WebStore.  The instance naming labels the pods with the name of the web store and derives the
+// name of the Service from it, so the name must be usable as both.
+func (component *WebStore) validateNaming(metaPath, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch component.Spec.Naming {
+	case "", NamingInstance, NamingLegacy:
+	default:
+		allErrs = append(allErrs, field.NotSupported(
+			specPath.Child("naming"),
+			component.Spec.Naming,
+			[]string{string(NamingInstance), string(NamingLegacy)},
+		))
+	}
+
+	if component.Naming() != NamingInstance {
+		return allErrs
+	}
+
+	for _, msg := range validation.IsValidLabelValue(component.Name) {
+		allErrs = append(allErrs, field.Invalid(metaPath.Child("name"), component.Name,
+			"must be a valid label value for the Instance naming; "+msg))
+	}
+
+	if component.Spec.ServiceName == "" {
+		serviceName := component.Name + "-svc"
+
+		for _, msg := range validation.IsDNS1035Label(serviceName) {
+			allErrs = append(allErrs, field.Invalid(metaPath.Child("name"), component.Name,
+				fmt.Sprintf("must derive a valid service name for the Instance naming, %s; %s", serviceName, msg)))
+		}
+	}
+
+	return allErrs
+}
+
+// validate performs the semantic validation of a WebStoreSpec.
+func (spec *WebStoreSpec) validate(specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if !imageReferenceRegexp.MatchString(spec.WebstoreImage) {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("webstoreImage"),
+			spec.WebstoreImage,
+			"must be a valid image reference, e.g. registry.example.com/nginx:1.17",
+		))
+	}
+
+	// service names are restricted to DNS-1035 labels by the API server
+	if spec.ServiceName != "" {
+		for _, msg := range validation.IsDNS1035Label(spec.ServiceName) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("serviceName"), spec.ServiceName, msg))
+		}
+	}
+
+	if spec.RevisionHistoryLimit != nil && *spec.RevisionHistoryLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("revisionHistoryLimit"),
+			*spec.RevisionHistoryLimit,
+			"must be greater than or equal to 0",
+		))
+	}
+
+	allErrs = append(allErrs, validateCollectionReference(spec, specPath.Child("collection"))...)
+	allErrs = append(allErrs, validatePodTemplate(spec, specPath)...)
+	allErrs = append(allErrs, validateNginx(spec, specPath.Child("nginx"))...)
+	allErrs = append(allErrs, validateTLS(spec, specPath.Child("tls"))...)
+	allErrs = append(allErrs, validateMonitoring(spec, specPath.Child("monitoring"))...)
+	allErrs = append(allErrs, validateAdoption(spec, specPath.Child("adoption"))...)
+
+	for _, msg := range validation.IsDNS1123Label(spec.NetworkPolicy.IngressControllerNamespace) {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("networkPolicy", "ingressControllerNamespace"),
+			spec.NetworkPolicy.IngressControllerNamespace,
+			msg,
+		))
+	}
+
+	return append(allErrs, validateRollout(spec, specPath.Child("rollout"))...)
+}
+
+// validatePodTemplate performs the semantic validation of the fields of a WebStoreSpec which are
+// passed through to the pods of the web store.
+func validatePodTemplate(spec *WebStoreSpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	resources := spec.Resources
+
+	for name, request := range resources.Requests {
+		limit, found := resources.Limits[name]
+		if found && request.Cmp(limit) > 0 {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("resources", "requests").Key(string(name)),
+				request.String(),
+				fmt.Sprintf("must be less than or equal to %s limit of %s", name, limit.String()),
+			))
+		}
+	}
+
+	for i, env := range spec.Env {
+		for _, msg := range validation.IsEnvVarName(env.Name) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("env").Index(i).Child("name"), env.Name, msg))
+		}
+	}
+
+	for i, secret := range spec.ImagePullSecrets {
+		for _, msg := range validation.IsDNS1123Subdomain(secret.Name) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("imagePullSecrets").Index(i).Child("name"), secret.Name, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// validateRollout performs the semantic validation of the rollout of a WebStoreSpec.
+func validateRollout(spec *WebStoreSpec, rolloutPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	rollout := spec.Rollout
+
+	switch rollout.Strategy {
+	case RolloutStrategyRollingUpdate, RolloutStrategyBlueGreen, RolloutStrategyCanary:
+	default:
+		allErrs = append(allErrs, field.NotSupported(
+			rolloutPath.Child("strategy"),
+			rollout.Strategy,
+			[]string{
+				string(RolloutStrategyRollingUpdate),
+				string(RolloutStrategyBlueGreen),
+				string(RolloutStrategyCanary),
+			},
+		))
+	}
+
+	if rollout.CanaryWeight < MinCanaryWeight || rollout.CanaryWeight > MaxCanaryWeight {
+		allErrs = append(allErrs, field.Invalid(
+			rolloutPath.Child("canaryWeight"),
+			rollout.CanaryWeight,
+			fmt.Sprintf("must be between %d and %d", MinCanaryWeight, MaxCanaryWeight),
+		))
+	}
+
+	return allErrs
+}
+
+// validateCollectionReference performs the semantic validation of the collection reference of a
+// WebStoreSpec.
+func validateCollectionReference(spec *WebStoreSpec, collectionPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	reference := spec.Collection
+
+	if reference.Name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(reference.Name) {
+			allErrs = append(allErrs, field.Invalid(collectionPath.Child("name"), reference.Name, msg))
+		}
+	}
+
+	if reference.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(reference.Namespace) {
+			allErrs = append(allErrs, field.Invalid(collectionPath.Child("namespace"), reference.Namespace, msg))
+		}
+	}
+
+	if reference.Selector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(
+			reference.Selector,
+			collectionPath.Child("selector"),
+		)...)
+	}
+
+	return allErrs
+}
+
+// validateNginx performs the semantic validation of the nginx configuration of a WebStoreSpec.  The
+// contents of server blocks and snippets are only checked for balanced braces, as they would
+// otherwise escape the block which they are rendered into.
+func validateNginx(spec *WebStoreSpec, nginxPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := map[string]bool{}
+
+	for i, upstream := range spec.Nginx.Upstreams {
+		upstreamPath := nginxPath.Child("upstreams").Index(i)
+
+		switch {
+		case !nginxNameRegexp.MatchString(upstream.Name):
+			allErrs = append(allErrs, field.Invalid(upstreamPath.Child("name"), upstream.Name,
+				"must consist of alphanumeric characters, '_', '.' or '-'"))
+		case names[upstream.Name]:
+			allErrs = append(allErrs, field.Duplicate(upstreamPath.Child("name"), upstream.Name))
+		}
+
+		names[upstream.Name] = true
+
+		if len(upstream.Servers) == 0 {
+			allErrs = append(allErrs, field.Required(upstreamPath.Child("servers"), "must define at least one server"))
+		}
+
+		for j, server := range upstream.Servers {
+			if !nginxServerRegexp.MatchString(server) {
+				allErrs = append(allErrs, field.Invalid(upstreamPath.Child("servers").Index(j), server,
+					"must be a server address with optional parameters, e.g. backend.team-a.svc:8080 weight=2"))
+			}
+		}
+	}
+
+	for i, serverBlock := range spec.Nginx.ServerBlocks {
+		if !bracesAreBalanced(serverBlock) {
+			allErrs = append(allErrs, field.Invalid(nginxPath.Child("serverBlocks").Index(i), serverBlock,
+				"must have balanced braces"))
+		}
+	}
+
+	if !bracesAreBalanced(spec.Nginx.Snippet) {
+		allErrs = append(allErrs, field.Invalid(nginxPath.Child("snippet"), spec.Nginx.Snippet, "must have balanced braces"))
+	}
+
+	return allErrs
+}
+
+// bracesAreBalanced determines if each opening brace of nginx configuration content is closed.
+func bracesAreBalanced(content string) bool {
+	depth := 0
+
+	for _, char := range content {
+		switch char {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
+	}
+
+	return depth == 0
+}
+
+// validateTLS performs the semantic validation of the TLS configuration of a WebStoreSpec.
+func validateTLS(spec *WebStoreSpec, tlsPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	tls := spec.TLS
+	if tls == nil {
+		return allErrs
+	}
+
+	switch tls.Mode {
+	case TLSModeSecret, TLSModeSelfSigned, TLSModeCertManager:
+	default:
+		allErrs = append(allErrs, field.NotSupported(
+			tlsPath.Child("mode"),
+			tls.Mode,
+			[]string{string(TLSModeSecret), string(TLSModeSelfSigned), string(TLSModeCertManager)},
+		))
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(tls.SecretName) {
+		allErrs = append(allErrs, field.Invalid(tlsPath.Child("secretName"), tls.SecretName, msg))
+	}
+
+	if tls.Mode == TLSModeCertManager {
+		if tls.Issuer.Name == "" {
+			allErrs = append(allErrs, field.Required(tlsPath.Child("issuer", "name"), "must be set for the CertManager mode"))
+		}
+
+		if tls.Issuer.Kind != "Issuer" && tls.Issuer.Kind != "ClusterIssuer" {
+			allErrs = append(allErrs, field.NotSupported(
+				tlsPath.Child("issuer", "kind"),
+				tls.Issuer.Kind,
+				[]string{"Issuer", "ClusterIssuer"},
+			))
+		}
+	}
+
+	if tls.RenewBefore.Duration <= 0 || tls.RenewBefore.Duration >= tls.Duration.Duration {
+		allErrs = append(allErrs, field.Invalid(
+			tlsPath.Child("renewBefore"),
+			tls.RenewBefore.Duration.String(),
+			fmt.Sprintf("must be greater than 0 and less than the duration of %s", tls.Duration.Duration),
+		))
+	}
+
+	return allErrs
+}
+
+// validateMonitoring performs the semantic validation of the monitoring configuration of a
+// WebStoreSpec.  Durations are restricted to whole seconds, as Prometheus does not accept
+// fractional durations.
+func validateMonitoring(spec *WebStoreSpec, monitoringPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	monitoring := spec.Monitoring
+	if monitoring == nil {
+		return allErrs
+	}
+
+	if interval := monitoring.Interval.Duration; interval <= 0 || interval%time.Second != 0 {
+		allErrs = append(allErrs, field.Invalid(
+			monitoringPath.Child("interval"),
+			interval.String(),
+			"must be a whole number of seconds greater than 0",
+		))
+	}
+
+	allErrs = append(allErrs, metav1validation.ValidateLabels(monitoring.Labels, monitoringPath.Child("labels"))...)
+
+	if !imageReferenceRegexp.MatchString(monitoring.ExporterImage) {
+		allErrs = append(allErrs, field.Invalid(
+			monitoringPath.Child("exporterImage"),
+			monitoring.ExporterImage,
+			"must be a valid image reference, e.g. nginx/nginx-prometheus-exporter:0.10.0",
+		))
+	}
+
+	for _, msg := range validation.IsDNS1123Label(monitoring.PrometheusNamespace) {
+		allErrs = append(allErrs, field.Invalid(monitoringPath.Child("prometheusNamespace"), monitoring.PrometheusNamespace, msg))
+	}
+
+	if alerts := monitoring.Alerts; alerts != nil {
+		if alertsFor := alerts.For.Duration; alertsFor < 0 || alertsFor%time.Second != 0 {
+			allErrs = append(allErrs, field.Invalid(
+				monitoringPath.Child("alerts", "for"),
+				alertsFor.String(),
+				"must be a whole number of seconds",
+			))
+		}
+
+		for _, msg := range validation.IsValidLabelValue(alerts.Severity) {
+			allErrs = append(allErrs, field.Invalid(monitoringPath.Child("alerts", "severity"), alerts.Severity, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// validateAdoption performs the semantic validation of the adoption of a WebStoreSpec.
+func validateAdoption(spec *WebStoreSpec, adoptionPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, validateAdoptionPolicy(spec.Adoption.Policy, adoptionPath.Child("policy"))...)
+
+	seen := map[string]bool{}
+
+	for i, resource := range spec.Adoption.Resources {
+		resourcePath := adoptionPath.Child("resources").Index(i)
+
+		if resource.Kind == "" {
+			allErrs = append(allErrs, field.Required(resourcePath.Child("kind"), "must specify the kind of the child resource"))
+		}
+
+		key := resource.Kind + "/" + resource.Name
+		if seen[key] {
+			allErrs = append(allErrs, field.Duplicate(resourcePath, key))
+		}
+
+		seen[key] = true
+
+		allErrs = append(allErrs, validateAdoptionPolicy(resource.Policy, resourcePath.Child("policy"))...)
+	}
+
+	return allErrs
+}
+
+// validateAdoptionPolicy performs the semantic validation of an adoption policy.
+func validateAdoptionPolicy(policy AdoptionPolicy, policyPath *field.Path) field.ErrorList {
+	switch policy {
+	case AdoptionPolicyNever, AdoptionPolicyIfUnowned, AdoptionPolicyForce:
+		return nil
+	default:
+		return field.ErrorList{field.NotSupported(
+			policyPath,
+			policy,
+			[]string{string(AdoptionPolicyNever), string(AdoptionPolicyIfUnowned), string(AdoptionPolicyForce)},
+		)}
+	}
+}
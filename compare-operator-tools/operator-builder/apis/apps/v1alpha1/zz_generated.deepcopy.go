@@ -22,6 +22,8 @@ package v1alpha1
 
 import (
 	"github.com/scottd018/demos/apis/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -30,7 +32,7 @@ func (in *WebStore) DeepCopyInto(out *WebStore) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -52,6 +54,42 @@ func (in *WebStore) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreAdoption) DeepCopyInto(out *WebStoreAdoption) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]WebStoreResourceAdoption, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreAdoption.
+func (in *WebStoreAdoption) DeepCopy() *WebStoreAdoption {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreAdoption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreAlerts) DeepCopyInto(out *WebStoreAlerts) {
+	*out = *in
+	out.For = in.For
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreAlerts.
+func (in *WebStoreAlerts) DeepCopy() *WebStoreAlerts {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreAlerts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebStoreList) DeepCopyInto(out *WebStoreList) {
 	*out = *in
@@ -84,9 +122,261 @@ func (in *WebStoreList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreMonitoring) DeepCopyInto(out *WebStoreMonitoring) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = new(WebStoreAlerts)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreMonitoring.
+func (in *WebStoreMonitoring) DeepCopy() *WebStoreMonitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreMonitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreNetworkPolicy) DeepCopyInto(out *WebStoreNetworkPolicy) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AdditionalPeers != nil {
+		in, out := &in.AdditionalPeers, &out.AdditionalPeers
+		*out = make([]v1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]v1.NetworkPolicyEgressRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreNetworkPolicy.
+func (in *WebStoreNetworkPolicy) DeepCopy() *WebStoreNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreNginx) DeepCopyInto(out *WebStoreNginx) {
+	*out = *in
+	if in.Upstreams != nil {
+		in, out := &in.Upstreams, &out.Upstreams
+		*out = make([]WebStoreNginxUpstream, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ServerBlocks != nil {
+		in, out := &in.ServerBlocks, &out.ServerBlocks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreNginx.
+func (in *WebStoreNginx) DeepCopy() *WebStoreNginx {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreNginx)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreNginxUpstream) DeepCopyInto(out *WebStoreNginxUpstream) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreNginxUpstream.
+func (in *WebStoreNginxUpstream) DeepCopy() *WebStoreNginxUpstream {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreNginxUpstream)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStorePodTemplate) DeepCopyInto(out *WebStorePodTemplate) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]corev1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStorePodTemplate.
+func (in *WebStorePodTemplate) DeepCopy() *WebStorePodTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStorePodTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreResourceAdoption) DeepCopyInto(out *WebStoreResourceAdoption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreResourceAdoption.
+func (in *WebStoreResourceAdoption) DeepCopy() *WebStoreResourceAdoption {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreResourceAdoption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreRevision) DeepCopyInto(out *WebStoreRevision) {
+	*out = *in
+	in.Nginx.DeepCopyInto(&out.Nginx)
+	in.WebStorePodTemplate.DeepCopyInto(&out.WebStorePodTemplate)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreRevision.
+func (in *WebStoreRevision) DeepCopy() *WebStoreRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreRollout) DeepCopyInto(out *WebStoreRollout) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreRollout.
+func (in *WebStoreRollout) DeepCopy() *WebStoreRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreRolloutStatus) DeepCopyInto(out *WebStoreRolloutStatus) {
+	*out = *in
+	if in.CurrentRevision != nil {
+		in, out := &in.CurrentRevision, &out.CurrentRevision
+		*out = new(WebStoreRevision)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreviousRevision != nil {
+		in, out := &in.PreviousRevision, &out.PreviousRevision
+		*out = new(WebStoreRevision)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreRolloutStatus.
+func (in *WebStoreRolloutStatus) DeepCopy() *WebStoreRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebStoreSpec) DeepCopyInto(out *WebStoreSpec) {
 	*out = *in
+	in.Collection.DeepCopyInto(&out.Collection)
+	out.Rollout = in.Rollout
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	in.Nginx.DeepCopyInto(&out.Nginx)
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(WebStoreTLS)
+		**out = **in
+	}
+	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(WebStoreMonitoring)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Adoption.DeepCopyInto(&out.Adoption)
+	in.WebStorePodTemplate.DeepCopyInto(&out.WebStorePodTemplate)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreSpec.
@@ -112,6 +402,17 @@ func (in *WebStoreStatus) DeepCopyInto(out *WebStoreStatus) {
 		*out = make([]common.Resource, len(*in))
 		copy(*out, *in)
 	}
+	in.Rollout.DeepCopyInto(&out.Rollout)
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(WebStoreTLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Degraded != nil {
+		in, out := &in.Degraded, &out.Degraded
+		*out = new(common.DegradedCondition)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreStatus.
@@ -123,3 +424,55 @@ func (in *WebStoreStatus) DeepCopy() *WebStoreStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreTLS) DeepCopyInto(out *WebStoreTLS) {
+	*out = *in
+	out.Issuer = in.Issuer
+	out.Duration = in.Duration
+	out.RenewBefore = in.RenewBefore
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreTLS.
+func (in *WebStoreTLS) DeepCopy() *WebStoreTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreTLSIssuer) DeepCopyInto(out *WebStoreTLSIssuer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreTLSIssuer.
+func (in *WebStoreTLSIssuer) DeepCopy() *WebStoreTLSIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreTLSIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebStoreTLSStatus) DeepCopyInto(out *WebStoreTLSStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebStoreTLSStatus.
+func (in *WebStoreTLSStatus) DeepCopy() *WebStoreTLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebStoreTLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
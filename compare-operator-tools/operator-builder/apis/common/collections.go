@@ -0,0 +1,45 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// +kubebuilder:object:generate=true
+package common
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CollectionReference defines the reference to the collection that a component belongs to.  The
+// collection is referenced either by name or by a label selector which must match exactly one
+// collection.
+type CollectionReference struct {
+	// +kubebuilder:validation:Optional
+	// Name defines the name of the collection.
+	Name string `json:"name,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Namespace defines the namespace of the collection.  Defaults to the namespace of the component.
+	Namespace string `json:"namespace,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Selector defines a label selector which must match exactly one collection.  The selector is
+	// ignored when a name is set.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// IsEmpty determines if a collection reference does not reference any collection.
+func (reference *CollectionReference) IsEmpty() bool {
+	return reference.Name == "" && reference.Selector == nil
+}
@@ -31,15 +31,18 @@ import (
 
 type Component interface {
 	GetComponentGVK() schema.GroupVersionKind
+	GetCollectionReference() CollectionReference
 	GetDependencies() []Component
 	GetDependencyStatus() bool
 	GetReadyStatus() bool
 	GetPhaseConditions() []PhaseCondition
+	GetDegradedCondition() *DegradedCondition
 	GetResources() []Resource
 
 	SetReadyStatus(bool)
 	SetDependencyStatus(bool)
 	SetPhaseCondition(PhaseCondition)
+	SetDegradedCondition(*DegradedCondition)
 	SetResource(Resource)
 }
 
@@ -57,6 +60,7 @@ type ComponentReconciler interface {
 
 	// component and child resource methods
 	CreateOrUpdate(metav1.Object) error
+	SetResources() error
 	UpdateStatus() error
 
 	// methods from the underlying client package
@@ -55,6 +55,33 @@ type ResourceCondition struct {
 
 	// Message defines a helpful message from the resource phase.
 	Message string `json:"message,omitempty"`
+
+	// DesiredStateHash defines the hash of the desired state of this resource when it was last persisted.
+	DesiredStateHash string `json:"desiredStateHash,omitempty"`
+
+	// ObservedGeneration defines the generation of this resource when it was last persisted.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Adopted defines whether this object existed in the cluster before it was adopted by the
+	// parent object.
+	Adopted bool `json:"adopted,omitempty"`
+}
+
+// DegradedCondition describes a permanent error which prevents the reconciliation of the parent
+// object until it changes.
+type DegradedCondition struct {
+	// Phase defines the phase which failed with the permanent error.
+	Phase string `json:"phase"`
+
+	// Message defines the message of the permanent error.
+	Message string `json:"message"`
+
+	// LastModified defines the time in which this condition was updated.
+	LastModified string `json:"lastModified,omitempty"`
+
+	// ObservedGeneration defines the generation of the parent object which failed with the
+	// permanent error.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // GetPhaseConditionIndex returns the index of a matching phase condition.  Any integer which is 0
@@ -0,0 +1,314 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/resources"
+)
+
+// serverPopulatedFields are the fields which are set by the API server and are ignored when
+// displaying the differences between resources.
+var serverPopulatedFields = [][]string{
+	{"status"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "uid"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "generation"},
+	{"metadata", "selfLink"},
+}
+
+type diffCommand struct {
+	*cobra.Command
+	workloadManifest string
+	exportDirectory  string
+	namespace        string
+	exitCode         bool
+}
+
+// newDiffCommand creates a new instance of the diff subcommand.
+func (c *WebstorectlCommand) newDiffCommand() {
+	d := &diffCommand{}
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Diff the child resources of a workload's custom resource against the cluster",
+		Long: "Diff the child resources of a workload's custom resource against the objects in the cluster, " +
+			"or against a directory of exported manifests, showing what the controller would change",
+		RunE: d.diff,
+	}
+
+	diffCmd.Flags().StringVarP(
+		&d.workloadManifest,
+		"workload-manifest",
+		"w",
+		"",
+		"Filepath to the workload manifest to diff child resources for.",
+	)
+	diffCmd.MarkFlagRequired("workload-manifest")
+
+	diffCmd.Flags().StringVarP(
+		&d.exportDirectory,
+		"export-directory",
+		"d",
+		"",
+		"Directory of exported manifests to diff against instead of the cluster.",
+	)
+
+	diffCmd.Flags().StringVarP(
+		&d.namespace,
+		"namespace",
+		"n",
+		"default",
+		"Namespace of the workload when it is not set in the workload manifest.",
+	)
+
+	diffCmd.Flags().BoolVar(
+		&d.exitCode,
+		"exit-code",
+		false,
+		"Exit with a non-zero status when differences are found.",
+	)
+
+	c.AddCommand(diffCmd)
+}
+
+// diff diffs the child resources of a workload's custom resource against their actual state.
+func (d *diffCommand) diff(cmd *cobra.Command, args []string) error {
+	changed, err := d.run(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if changed && d.exitCode {
+		cmd.SilenceUsage = true
+
+		return fmt.Errorf("differences found for workload manifest %s", d.workloadManifest)
+	}
+
+	return nil
+}
+
+// run writes the differences between the rendered and the actual child resources of the workload
+// to the output, and returns whether any differences were found.
+func (d *diffCommand) run(output io.Writer) (bool, error) {
+	workload, err := readWorkload(d.workloadManifest)
+	if err != nil {
+		return false, err
+	}
+
+	if workload.Namespace == "" {
+		workload.Namespace = d.namespace
+	}
+
+	// construct a reconciler which reads the actual state from the requested source
+	source := "live"
+
+	var objects []client.Object
+	if d.exportDirectory != "" {
+		source = "exported"
+
+		if objects, err = readManifests(d.exportDirectory); err != nil {
+			return false, err
+		}
+	}
+
+	r := newOfflineReconciler(workload, objects...)
+
+	if d.exportDirectory == "" {
+		config, err := ctrl.GetConfig()
+		if err != nil {
+			return false, fmt.Errorf("failed to load kubeconfig, %w", err)
+		}
+
+		if r.Client, err = client.New(config, client.Options{Scheme: r.Scheme}); err != nil {
+			return false, fmt.Errorf("failed to create client, %w", err)
+		}
+	}
+
+	// the child resources are rendered from the status as well as the spec, such as the active slot
+	// of a rollout and the naming of a migration, so the status of the actual workload is used
+	if err := copyStatus(r); err != nil {
+		return false, err
+	}
+
+	if err := r.SetResources(); err != nil {
+		return false, err
+	}
+
+	var changed bool
+
+	for _, resource := range r.GetResources() {
+		resourceDiff, err := diffResource(resource.(*resources.Resource), source)
+		if err != nil {
+			return false, err
+		}
+
+		if resourceDiff == "" {
+			continue
+		}
+
+		changed = true
+
+		if _, err := io.WriteString(output, resourceDiff); err != nil {
+			return false, fmt.Errorf("failed to write output, %w", err)
+		}
+	}
+
+	return changed, nil
+}
+
+// copyStatus copies the status of the actual workload onto the workload of a reconcile.  The status
+// is left as is when the workload does not exist yet.
+func copyStatus(r *appscontrollers.WebStoreRequest) error {
+	actual := &appsv1alpha1.WebStore{}
+	if err := r.Get(r.Context, client.ObjectKeyFromObject(r.Component), actual); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("unable to get workload %s, %w", r.Component.Name, err)
+	}
+
+	r.Component.Status = *actual.Status.DeepCopy()
+
+	return nil
+}
+
+// diffResource returns a unified diff between the actual state of a resource and the state it
+// would have after the controller updates it.  An empty string is returned when the controller
+// would not update the resource.
+func diffResource(desired *resources.Resource, source string) (string, error) {
+	r := desired.GetReconciler()
+
+	actualObject := &unstructured.Unstructured{}
+	actualObject.SetGroupVersionKind(desired.Object.GetObjectKind().GroupVersionKind())
+
+	var before, after string
+
+	if err := r.Get(r.GetContext(), client.ObjectKeyFromObject(desired.Object), actualObject); err != nil {
+		if !apierrs.IsNotFound(err) {
+			return "", fmt.Errorf("unable to get resource %s, %w", resourceID(desired), err)
+		}
+
+		// the resource would be created as is
+		if after, err = toCleanYAML(desired.Object); err != nil {
+			return "", err
+		}
+	} else {
+		// use the same semantics as the controller to determine if an update is needed
+		needsUpdate, err := resources.NeedsUpdate(*desired, *resources.NewResourceFromClient(actualObject, r))
+		if err != nil || !needsUpdate {
+			return "", err
+		}
+
+		if before, after, err = mergedYAML(desired.Object, actualObject); err != nil {
+			return "", err
+		}
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: source + "/" + resourceID(desired),
+		ToFile:   "rendered/" + resourceID(desired),
+		Context:  3,
+	})
+}
+
+// mergedYAML returns the yaml of the actual object, and of the actual object after the desired
+// object has been merged into it, in the same way that the controller patches the resource.
+func mergedYAML(desired, actual client.Object) (string, string, error) {
+	desiredJSON, err := json.Marshal(desired)
+	if err != nil {
+		return "", "", err
+	}
+
+	actualJSON, err := json.Marshal(actual)
+	if err != nil {
+		return "", "", err
+	}
+
+	mergedJSON, err := jsonpatch.MergePatch(actualJSON, desiredJSON)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to merge resource %s, %w", actual.GetName(), err)
+	}
+
+	merged := &unstructured.Unstructured{}
+	if err := merged.UnmarshalJSON(mergedJSON); err != nil {
+		return "", "", err
+	}
+
+	before, err := toCleanYAML(actual)
+	if err != nil {
+		return "", "", err
+	}
+
+	after, err := toCleanYAML(merged)
+	if err != nil {
+		return "", "", err
+	}
+
+	return before, after, nil
+}
+
+// toCleanYAML returns the yaml of an object without its server-populated fields.
+func toCleanYAML(object client.Object) (string, error) {
+	objectJSON, err := json.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+
+	cleaned := &unstructured.Unstructured{}
+	if err := cleaned.UnmarshalJSON(objectJSON); err != nil {
+		return "", err
+	}
+
+	for _, fields := range serverPopulatedFields {
+		unstructured.RemoveNestedField(cleaned.Object, fields...)
+	}
+
+	resources.RemoveNullFields(cleaned.Object)
+
+	cleanedYAML, err := yaml.Marshal(cleaned.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return string(cleanedYAML), nil
+}
+
+// resourceID returns a path-like identifier for a resource for use in diff headers.
+func resourceID(resource *resources.Resource) string {
+	return strings.Join([]string{resource.Kind, resource.Namespace, resource.Name}, "/")
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+const diffWorkloadManifest = `apiVersion: apps.acme.com/v1alpha1
+kind: WebStore
+metadata:
+  name: webstore-sample
+  namespace: team-a
+spec:
+  webstoreImage: nginx:1.17
+  webStoreReplicas: 2
+  rollout:
+    strategy: BlueGreen
+`
+
+// writeManifest writes the objects to a multi-document manifest in the directory.
+func writeManifest(t *testing.T, filename string, objects ...interface{}) {
+	var manifest bytes.Buffer
+
+	for _, object := range objects {
+		content, err := yaml.Marshal(object)
+		if err != nil {
+			t.Fatalf("unable to marshal object; %v", err)
+		}
+
+		manifest.WriteString("---\n")
+		manifest.Write(content)
+	}
+
+	if err := ioutil.WriteFile(filename, manifest.Bytes(), 0o600); err != nil {
+		t.Fatalf("unable to write manifest; %v", err)
+	}
+}
+
+// greenActiveFixture returns the workload of the manifest as it exists in the cluster once a
+// revision has been promoted to the green slot.
+func greenActiveFixture(t *testing.T, workloadManifest string) *appsv1alpha1.WebStore {
+	workload, err := readWorkload(workloadManifest)
+	if err != nil {
+		t.Fatalf("readWorkload() error = %v", err)
+	}
+
+	revision, err := workload.Spec.Revision()
+	if err != nil {
+		t.Fatalf("Revision() error = %v", err)
+	}
+
+	workload.Status.Rollout = appsv1alpha1.WebStoreRolloutStatus{
+		Phase:           appsv1alpha1.RolloutPhasePromoted,
+		ActiveSlot:      appsv1alpha1.RolloutSlotGreen,
+		CurrentRevision: &revision,
+	}
+
+	return workload
+}
+
+func TestDiffUsesActualStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		exportStore bool
+		wantChanged bool
+	}{
+		{
+			name:        "green active store has no differences",
+			exportStore: true,
+			wantChanged: false,
+		},
+		{
+			name:        "store which does not exist is rendered from the blue slot",
+			exportStore: false,
+			wantChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := t.TempDir()
+			workloadManifest := filepath.Join(directory, "workload.yaml")
+
+			if err := ioutil.WriteFile(workloadManifest, []byte(diffWorkloadManifest), 0o600); err != nil {
+				t.Fatalf("unable to write workload manifest; %v", err)
+			}
+
+			// the child resources in the cluster are those of the green active store
+			actual := greenActiveFixture(t, workloadManifest)
+
+			children, err := generateResources(actual.DeepCopy())
+			if err != nil {
+				t.Fatalf("generateResources() error = %v", err)
+			}
+
+			exported := []interface{}{}
+			for _, child := range children {
+				exported = append(exported, child)
+			}
+
+			if tt.exportStore {
+				exported = append(exported, actual)
+			}
+
+			exportDirectory := filepath.Join(directory, "export")
+			if err := os.Mkdir(exportDirectory, 0o700); err != nil {
+				t.Fatalf("unable to create export directory; %v", err)
+			}
+
+			writeManifest(t, filepath.Join(exportDirectory, "export.yaml"), exported...)
+
+			d := &diffCommand{workloadManifest: workloadManifest, exportDirectory: exportDirectory, namespace: "default"}
+
+			var output bytes.Buffer
+
+			changed, err := d.run(&output)
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if changed != tt.wantChanged {
+				t.Errorf("run() changed = %v, want %v; differences:\n%s", changed, tt.wantChanged, output.String())
+			}
+		})
+	}
+}
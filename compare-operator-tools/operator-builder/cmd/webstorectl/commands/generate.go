@@ -18,18 +18,10 @@ package commands
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
-	"sigs.k8s.io/yaml"
-
-	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
-	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
 )
 
 type generateCommand struct {
@@ -61,30 +53,14 @@ func (c *WebstorectlCommand) newGenerateCommand() {
 
 // generate creates child resource manifests from a workload's custom resource.
 func (g *generateCommand) generate(cmd *cobra.Command, args []string) error {
-
-	filename, _ := filepath.Abs(g.workloadManifest)
-
-	yamlFile, err := ioutil.ReadFile(filename)
+	workload, err := readWorkload(g.workloadManifest)
 	if err != nil {
-		return fmt.Errorf("failed to open file %s, %w", filename, err)
+		return err
 	}
 
-	var workload appsv1alpha1.WebStore
-
-	err = yaml.Unmarshal(yamlFile, &workload)
+	resourceObjects, err := generateResources(workload)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal yaml %s into workload, %w", filename, err)
-	}
-
-	resourceObjects := make([]metav1.Object, len(webstore.CreateFuncs))
-
-	for i, f := range webstore.CreateFuncs {
-		resource, err := f(&workload)
-		if err != nil {
-			return err
-		}
-
-		resourceObjects[i] = resource
+		return err
 	}
 
 	e := json.NewYAMLSerializer(json.DefaultMetaFactory, nil, nil)
@@ -96,7 +72,7 @@ func (g *generateCommand) generate(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to write output, %w", err)
 		}
 
-		if err := e.Encode(o.(runtime.Object), os.Stdout); err != nil {
+		if err := e.Encode(o, os.Stdout); err != nil {
 			return fmt.Errorf("failed to write output, %w", err)
 		}
 	}
@@ -17,38 +17,320 @@ limitations under the License.
 package commands
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/internal/crd"
 )
 
-const defaultManifest = `apiVersion: apps.acme.com/v1alpha1
-kind: WebStore
-metadata:
-  name: webstore-sample
-spec:
-  webstoreImage: "nginx:1.17"
-  serviceName: "webstore-svc"
-  webStoreReplicas: 2
-`
+const defaultWorkloadName = "webstore-sample"
+
+type initCommand struct {
+	*cobra.Command
+	name        string
+	namespace   string
+	image       string
+	replicas    int
+	serviceName string
+	interactive bool
+}
 
 // newInitCommand creates a new instance of the init subcommand.
 func (c *WebstorectlCommand) newInitCommand() {
+	i := &initCommand{}
 	initCmd := &cobra.Command{
 		Use:   "init",
 		Short: "Write a sample custom resource manifest for a workload to standard out",
 		Long:  "Write a sample custom resource manifest for a workload to standard out",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			outputStream := os.Stdout
+		RunE:  i.init,
+	}
+
+	initCmd.Flags().StringVar(&i.name, "name", defaultWorkloadName, "Name of the workload.")
+	initCmd.Flags().StringVar(&i.namespace, "namespace", "", "Namespace of the workload.")
+	initCmd.Flags().StringVar(&i.image, "image", "", "Image of the web store, defaulted by the custom resource definition when unset.")
+	initCmd.Flags().IntVar(&i.replicas, "replicas", 0, "Replicas of the web store, defaulted by the custom resource definition when unset.")
+	initCmd.Flags().StringVar(&i.serviceName, "service-name", "", "Name of the web store service, derived from the workload name when unset.")
+	initCmd.Flags().BoolVarP(&i.interactive, "interactive", "i", false, "Prompt for each of the scalar workload fields, writing only the fields which are answered.")
+
+	c.AddCommand(initCmd)
+}
+
+// init writes a sample custom resource manifest for a workload to standard out.
+func (i *initCommand) init(cmd *cobra.Command, args []string) error {
+	// only the flags which are set are written to the spec, so that the remaining fields are
+	// defaulted and explicit zero values from the flags are kept
+	spec := map[string]interface{}{}
+
+	if cmd.Flags().Changed("image") {
+		spec["webstoreImage"] = i.image
+	}
+
+	if cmd.Flags().Changed("replicas") {
+		spec["webStoreReplicas"] = int64(i.replicas)
+	}
+
+	if i.serviceName != "" {
+		spec["serviceName"] = i.serviceName
+	}
+
+	metadata := map[string]interface{}{"name": i.name}
+	if i.namespace != "" {
+		metadata["namespace"] = i.namespace
+	}
+
+	content := map[string]interface{}{
+		"apiVersion": appsv1alpha1.GroupVersion.String(),
+		"kind":       (&appsv1alpha1.WebStore{}).GetComponentGVK().Kind,
+		"metadata":   metadata,
+		"spec":       spec,
+	}
+
+	cmd.SilenceUsage = true
+
+	if i.interactive {
+		if err := prompt(os.Stdin, os.Stderr, content); err != nil {
+			return err
+		}
+	}
+
+	// the manifest is validated as it is admitted by the API server, with the defaults applied, but
+	// is written without them
+	workload, err := decodeWorkload(runtime.DeepCopyJSON(content))
+	if err != nil {
+		return err
+	}
+
+	defaulted, err := toManifest(workload)
+	if err != nil {
+		return err
+	}
+
+	if err := validateManifest(defaulted, workload); err != nil {
+		return err
+	}
+
+	manifest, err := yaml.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workload, %w", err)
+	}
+
+	if _, err := os.Stdout.Write(manifest); err != nil {
+		return fmt.Errorf("failed to write to stdout, %w", err)
+	}
+
+	return nil
+}
+
+// prompt prompts for the name and namespace of a workload and for each of the scalar fields on its
+// spec, showing the defaulted values.  Fields are discovered from the spec type so that new fields
+// are prompted for without changes to this command.  Only the fields which are answered are written
+// to the content of the workload manifest, and the fields which are not scalar, such as rollout and
+// tls, are listed as skipped so that they may be set in the manifest instead.
+func prompt(input io.Reader, output io.Writer, content map[string]interface{}) error {
+	workload, err := decodeWorkload(runtime.DeepCopyJSON(content))
+	if err != nil {
+		return err
+	}
+
+	metadata, _ := content["metadata"].(map[string]interface{})
+	spec, _ := content["spec"].(map[string]interface{})
+
+	if metadata == nil || spec == nil {
+		return fmt.Errorf("failed to prompt for workload, manifest content has no metadata or spec")
+	}
+
+	skipped := skippedFields(reflect.TypeOf(workload.Spec))
+	if len(skipped) > 0 {
+		if _, err := fmt.Fprintf(output, "skipping fields which are not scalar, set them in the manifest instead: %s\n",
+			strings.Join(skipped, ", ")); err != nil {
+			return fmt.Errorf("failed to write prompt, %w", err)
+		}
+	}
+
+	reader := bufio.NewReader(input)
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{name: "name", value: workload.Name},
+		{name: "namespace", value: workload.Namespace},
+	} {
+		answer, err := promptString(reader, output, field.name, field.value)
+		if err != nil {
+			return err
+		}
+
+		if answer != "" {
+			metadata[field.name] = answer
+		}
+	}
+
+	value := reflect.ValueOf(workload.Spec)
+
+	for i := 0; i < value.NumField(); i++ {
+		fieldName := jsonName(value.Type().Field(i))
+		if fieldName == "" {
+			continue
+		}
 
-			if _, err := outputStream.WriteString(defaultManifest); err != nil {
-				return fmt.Errorf("failed to write to stdout, %w", err)
+		field := value.Field(i)
+
+		var defaultValue string
+
+		switch field.Kind() {
+		case reflect.String:
+			defaultValue = field.String()
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			defaultValue = strconv.FormatInt(field.Int(), 10)
+		case reflect.Bool:
+			defaultValue = strconv.FormatBool(field.Bool())
+		default:
+			continue
+		}
+
+		answer, err := promptString(reader, output, fieldName, defaultValue)
+		if err != nil {
+			return err
+		}
+
+		if answer == "" {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			parsed, err := strconv.ParseInt(answer, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %s for %s, %w", answer, fieldName, err)
+			}
+
+			spec[fieldName] = parsed
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(answer)
+			if err != nil {
+				return fmt.Errorf("invalid value %s for %s, %w", answer, fieldName, err)
 			}
 
-			return nil
-		},
+			spec[fieldName] = parsed
+		default:
+			spec[fieldName] = answer
+		}
 	}
 
-	c.AddCommand(initCmd)
+	return nil
+}
+
+// skippedFields returns the json names of the fields of a struct type which are not prompted for,
+// including the fields of inlined structs.
+func skippedFields(structType reflect.Type) []string {
+	skipped := []string{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			skipped = append(skipped, skippedFields(field.Type)...)
+
+			continue
+		}
+
+		fieldName := jsonName(field)
+		if fieldName == "" {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Int, reflect.Int32, reflect.Int64, reflect.Bool:
+		default:
+			skipped = append(skipped, fieldName)
+		}
+	}
+
+	return skipped
+}
+
+// jsonName returns the json name of a struct field, or an empty string if the field is inlined or
+// not serialized.
+func jsonName(field reflect.StructField) string {
+	fieldName := strings.Split(field.Tag.Get("json"), ",")[0]
+	if fieldName == "-" {
+		return ""
+	}
+
+	return fieldName
+}
+
+// promptString prompts for a single value, showing its default value, and returns an empty string
+// when no answer is given.
+func promptString(reader *bufio.Reader, output io.Writer, fieldName, defaultValue string) (string, error) {
+	if _, err := fmt.Fprintf(output, "%s [%s]: ", fieldName, defaultValue); err != nil {
+		return "", fmt.Errorf("failed to write prompt, %w", err)
+	}
+
+	answer, err := reader.ReadString('\n')
+	if err != nil && !(err == io.EOF && answer != "") {
+		return "", fmt.Errorf("failed to read answer for %s, %w", fieldName, err)
+	}
+
+	return strings.TrimSpace(answer), nil
+}
+
+// toManifest returns the yaml manifest for a workload without the fields which are managed by the
+// API server.
+func toManifest(workload *appsv1alpha1.WebStore) ([]byte, error) {
+	manifestJSON, err := yaml.Marshal(workload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workload, %w", err)
+	}
+
+	var manifest map[string]interface{}
+	if err := yaml.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workload, %w", err)
+	}
+
+	delete(manifest, "status")
+
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+
+	return yaml.Marshal(manifest)
+}
+
+// validateManifest validates a workload manifest against the schema of the custom resource
+// definition which is compiled in, and the workload against its semantic validation.
+func validateManifest(manifest []byte, workload *appsv1alpha1.WebStore) error {
+	jsonManifest, err := yaml.YAMLToJSON(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to convert yaml to json, %w", err)
+	}
+
+	// use the apimachinery json package so that integers are decoded as they are by the API server
+	var content map[string]interface{}
+	if err := utiljson.Unmarshal(jsonManifest, &content); err != nil {
+		return fmt.Errorf("failed to unmarshal json, %w", err)
+	}
+
+	errs, err := crd.Validate(content)
+	if err != nil {
+		return fmt.Errorf("failed to validate against the schema, %w", err)
+	}
+
+	if errs = append(errs, workload.Validate()...); len(errs) > 0 {
+		return fmt.Errorf("invalid workload, %w", errs.ToAggregate())
+	}
+
+	return nil
 }
@@ -0,0 +1,101 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrompt(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         map[string]interface{}
+		answers      []string
+		wantMetadata map[string]interface{}
+		wantSpec     map[string]interface{}
+		wantErr      bool
+	}{
+		{
+			name:         "unanswered fields are not written",
+			spec:         map[string]interface{}{},
+			wantMetadata: map[string]interface{}{"name": defaultWorkloadName},
+			wantSpec:     map[string]interface{}{},
+		},
+		{
+			name:         "answered fields are written",
+			spec:         map[string]interface{}{},
+			answers:      []string{"", "web", "nginx:1.19", "", "5"},
+			wantMetadata: map[string]interface{}{"name": defaultWorkloadName, "namespace": "web"},
+			wantSpec:     map[string]interface{}{"webstoreImage": "nginx:1.19", "webStoreReplicas": int64(5)},
+		},
+		{
+			name:         "fields which are set by flags are kept",
+			spec:         map[string]interface{}{"serviceName": "storefront"},
+			wantMetadata: map[string]interface{}{"name": defaultWorkloadName},
+			wantSpec:     map[string]interface{}{"serviceName": "storefront"},
+		},
+		{
+			name:    "invalid integer answer is rejected",
+			spec:    map[string]interface{}{},
+			answers: []string{"", "", "", "", "many"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := map[string]interface{}{"name": defaultWorkloadName}
+			content := map[string]interface{}{
+				"apiVersion": "apps.acme.com/v1alpha1",
+				"kind":       "WebStore",
+				"metadata":   metadata,
+				"spec":       tt.spec,
+			}
+
+			// unanswered prompts read an empty line, and the input is padded for the remaining fields
+			input := strings.Join(tt.answers, "\n") + strings.Repeat("\n", 20)
+
+			var output bytes.Buffer
+
+			err := prompt(strings.NewReader(input), &output, content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prompt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(metadata, tt.wantMetadata) {
+				t.Errorf("metadata = %v, want %v", metadata, tt.wantMetadata)
+			}
+
+			if !reflect.DeepEqual(tt.spec, tt.wantSpec) {
+				t.Errorf("spec = %v, want %v", tt.spec, tt.wantSpec)
+			}
+
+			for _, skipped := range []string{"rollout", "tls", "networkPolicy", "monitoring", "adoption", "resources"} {
+				if !strings.Contains(output.String(), skipped) {
+					t.Errorf("prompt() output does not list skipped field %s\n%s", skipped, output.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/internal/history"
+)
+
+type rollbackCommand struct {
+	*cobra.Command
+	namespace  string
+	toRevision int64
+}
+
+// newRollbackCommand creates a new instance of the rollback subcommand.
+func (c *WebstorectlCommand) newRollbackCommand() {
+	r := &rollbackCommand{}
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback NAME",
+		Short: "Roll back a workload's custom resource to a previous revision of its spec",
+		Long: "Roll back a workload's custom resource to a previous revision of its spec, " +
+			"restoring the spec which the controller recorded when the revision was reconciled",
+		Args: cobra.ExactArgs(1),
+		RunE: r.rollback,
+	}
+
+	rollbackCmd.Flags().StringVarP(
+		&r.namespace,
+		"namespace",
+		"n",
+		"default",
+		"Namespace of the workload.",
+	)
+
+	rollbackCmd.Flags().Int64Var(
+		&r.toRevision,
+		"to-revision",
+		0,
+		"Revision to roll back to.  Defaults to the revision before the current revision.",
+	)
+
+	c.AddCommand(rollbackCmd)
+}
+
+// rollback restores the spec of a workload's custom resource from a previous revision.
+func (r *rollbackCommand) rollback(cmd *cobra.Command, args []string) error {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig, %w", err)
+	}
+
+	c, err := client.New(config, client.Options{Scheme: newScheme()})
+	if err != nil {
+		return fmt.Errorf("failed to create client, %w", err)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+	key := client.ObjectKey{Name: args[0], Namespace: r.namespace}
+
+	workload := &appsv1alpha1.WebStore{}
+	if err := c.Get(ctx, key, workload); err != nil {
+		return fmt.Errorf("failed to get workload %s, %w", key, err)
+	}
+
+	revisions, err := history.ListRevisions(ctx, c, workload)
+	if err != nil {
+		return fmt.Errorf("failed to list revisions of workload %s, %w", key, err)
+	}
+
+	revision, err := r.findRevision(workload, revisions)
+	if err != nil {
+		return err
+	}
+
+	if revision.Revision == workload.Status.Revision {
+		fmt.Fprintf(os.Stdout, "workload %s is already at revision %d\n", key, revision.Revision)
+
+		return nil
+	}
+
+	spec, err := history.SpecFromRevision(revision)
+	if err != nil {
+		return fmt.Errorf("failed to read revision %d of workload %s, %w", revision.Revision, key, err)
+	}
+
+	// the revision history limit is not recorded in a revision, so the current limit is retained
+	spec.RevisionHistoryLimit = workload.Spec.RevisionHistoryLimit
+	workload.Spec = *spec
+
+	if err := c.Update(ctx, workload); err != nil {
+		return fmt.Errorf("failed to roll back workload %s, %w", key, err)
+	}
+
+	fmt.Fprintf(os.Stdout, "workload %s rolled back to revision %d\n", key, revision.Revision)
+
+	return nil
+}
+
+// findRevision returns the revision to roll back to.  The revision before the current revision
+// is returned when no revision was requested.
+func (r *rollbackCommand) findRevision(
+	workload *appsv1alpha1.WebStore,
+	revisions []appsv1.ControllerRevision,
+) (*appsv1.ControllerRevision, error) {
+	if r.toRevision < 0 {
+		return nil, fmt.Errorf("invalid revision %d, revisions must be greater than 0", r.toRevision)
+	}
+
+	var previous *appsv1.ControllerRevision
+
+	for i := range revisions {
+		if r.toRevision != 0 && revisions[i].Revision == r.toRevision {
+			return &revisions[i], nil
+		}
+
+		if revisions[i].Revision < workload.Status.Revision {
+			previous = &revisions[i]
+		}
+	}
+
+	if r.toRevision != 0 {
+		return nil, fmt.Errorf("revision %d of workload %s/%s not found", r.toRevision, workload.Namespace, workload.Name)
+	}
+
+	if previous == nil {
+		return nil, fmt.Errorf("no previous revision of workload %s/%s found", workload.Namespace, workload.Name)
+	}
+
+	return previous, nil
+}
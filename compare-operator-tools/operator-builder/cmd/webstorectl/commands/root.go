@@ -32,6 +32,9 @@ func NewWebstorectlCommand() *WebstorectlCommand {
 			Use:   "webstorectl",
 			Short: "Manage webstore stuff like a boss",
 			Long:  "Manage webstore stuff like a boss",
+
+			// errors are printed by Run so that they are not printed twice
+			SilenceErrors: true,
 		},
 	}
 
@@ -50,5 +53,9 @@ func (c *WebstorectlCommand) Run() {
 func (c *WebstorectlCommand) addSubCommands() {
 	c.newInitCommand()
 	c.newGenerateCommand()
+	c.newValidateCommand()
+	c.newDiffCommand()
+	c.newStatusCommand()
+	c.newRollbackCommand()
 	//+kubebuilder:scaffold:operator-builder:subcommands
 }
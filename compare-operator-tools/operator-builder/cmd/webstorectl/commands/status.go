@@ -0,0 +1,358 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/utils"
+	"github.com/scottd018/demos/internal/pipelines"
+)
+
+// blockingMarker is the marker which highlights the first blocking phase in the phase table.
+const blockingMarker = "*"
+
+// watchBackoff is the backoff with which a watch is re-established when the server closes it
+// without any progress.
+var watchBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    10,
+	Cap:      time.Minute,
+}
+
+type statusCommand struct {
+	*cobra.Command
+	namespace string
+	watch     bool
+	backoff   wait.Backoff
+}
+
+// newStatusCommand creates a new instance of the status subcommand.
+func (c *WebstorectlCommand) newStatusCommand() {
+	s := &statusCommand{backoff: watchBackoff}
+	statusCmd := &cobra.Command{
+		Use:   "status NAME",
+		Short: "Display the phase and resource conditions of a workload's custom resource",
+		Long: "Display the phase and resource conditions of a workload's custom resource, " +
+			"highlighting the first phase which is blocking reconciliation",
+		Args: cobra.ExactArgs(1),
+		RunE: s.status,
+	}
+
+	statusCmd.Flags().StringVarP(
+		&s.namespace,
+		"namespace",
+		"n",
+		"default",
+		"Namespace of the workload.",
+	)
+
+	statusCmd.Flags().BoolVar(
+		&s.watch,
+		"watch",
+		false,
+		"Watch the workload and display its status each time it changes.",
+	)
+
+	c.AddCommand(statusCmd)
+}
+
+// status displays the phase and resource conditions of a workload's custom resource.
+func (s *statusCommand) status(cmd *cobra.Command, args []string) error {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig, %w", err)
+	}
+
+	c, err := client.NewWithWatch(config, client.Options{Scheme: newScheme()})
+	if err != nil {
+		return fmt.Errorf("failed to create client, %w", err)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+	key := client.ObjectKey{Name: args[0], Namespace: s.namespace}
+
+	workload := &appsv1alpha1.WebStore{}
+	if err := c.Get(ctx, key, workload); err != nil {
+		return fmt.Errorf("failed to get workload %s, %w", key, err)
+	}
+
+	if err := writeStatus(os.Stdout, workload); err != nil {
+		return err
+	}
+
+	if !s.watch {
+		return nil
+	}
+
+	return s.watchStatus(ctx, c, os.Stdout, workload)
+}
+
+// watchStatus displays the status of a workload each time it changes until it is deleted or the
+// command is interrupted.  The server closes a watch after a timeout, so the watch is re-established
+// from the last observed resource version until the command is interrupted.  A watch which is closed
+// without any progress is re-established with backoff, so that a server which keeps closing the
+// watch is not flooded with requests.
+func (s *statusCommand) watchStatus(
+	ctx context.Context,
+	c client.WithWatch,
+	output io.Writer,
+	workload *appsv1alpha1.WebStore,
+) error {
+	key := client.ObjectKeyFromObject(workload)
+	resourceVersion := workload.ResourceVersion
+	backoff := s.backoff
+
+	for ctx.Err() == nil {
+		watcher, err := c.Watch(
+			ctx,
+			&appsv1alpha1.WebStoreList{},
+			client.InNamespace(key.Namespace),
+			client.MatchingFields{"metadata.name": key.Name},
+			&client.ListOptions{Raw: &metav1.ListOptions{
+				ResourceVersion:     resourceVersion,
+				AllowWatchBookmarks: true,
+			}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to watch workload %s, %w", key, err)
+		}
+
+		next, deleted, err := watchEvents(ctx, watcher, output, key, resourceVersion)
+
+		watcher.Stop()
+
+		if err != nil || deleted {
+			return err
+		}
+
+		progressed := next != "" && next != resourceVersion
+		resourceVersion = next
+
+		if progressed {
+			backoff = s.backoff
+
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff.Step()):
+		}
+	}
+
+	return nil
+}
+
+// watchEvents displays the status of a workload for each event of a watch until the watch is closed.
+// It returns the last observed resource version, from which the watch is re-established, and
+// whether the workload was deleted.
+func watchEvents(
+	ctx context.Context,
+	watcher watch.Interface,
+	output io.Writer,
+	key client.ObjectKey,
+	resourceVersion string,
+) (string, bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, false, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion, false, nil
+			}
+
+			switch event.Type {
+			case watch.Deleted:
+				fmt.Fprintf(output, "\nworkload %s was deleted\n", key)
+
+				return resourceVersion, true, nil
+			case watch.Bookmark:
+				if workload, ok := event.Object.(*appsv1alpha1.WebStore); ok {
+					resourceVersion = workload.ResourceVersion
+				}
+			case watch.Added, watch.Modified:
+				workload, ok := event.Object.(*appsv1alpha1.WebStore)
+				if !ok {
+					continue
+				}
+
+				resourceVersion = workload.ResourceVersion
+
+				if _, err := io.WriteString(output, "\n"); err != nil {
+					return resourceVersion, false, fmt.Errorf("failed to write output, %w", err)
+				}
+
+				if err := writeStatus(output, workload); err != nil {
+					return resourceVersion, false, err
+				}
+			case watch.Error:
+				// the resource version has been compacted away, so the watch is re-established from
+				// the current state of the workload, which is displayed once again
+				err := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return "", false, nil
+				}
+
+				return resourceVersion, false, fmt.Errorf("failed to watch workload %s, %w", key, err)
+			}
+		}
+	}
+}
+
+// writeStatus writes the phase and resource condition tables for a workload.
+func writeStatus(output io.Writer, workload *appsv1alpha1.WebStore) error {
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "NAME:\t%s\n", workload.Name)
+	fmt.Fprintf(w, "NAMESPACE:\t%s\n", workload.Namespace)
+	fmt.Fprintf(w, "CREATED:\t%t\n", workload.Status.Created)
+	fmt.Fprintf(w, "DEPENDENCIES SATISFIED:\t%t\n", workload.Status.DependenciesSatisfied)
+	fmt.Fprintf(w, "REVISION:\t%d\n", workload.Status.Revision)
+	fmt.Fprintf(w, "NAMING:\t%s\n", describeNaming(workload))
+	fmt.Fprintf(w, "DEGRADED:\t%s\n", describeDegraded(workload.Status.Degraded))
+	fmt.Fprintf(w, "TLS:\t%s\n", describeTLS(workload.Status.TLS))
+	fmt.Fprintf(w, "ROLLOUT:\t%s %s\n", workload.Spec.Rollout.Strategy, workload.Status.Rollout.Phase)
+	fmt.Fprintf(w, "CURRENT REVISION:\t%s\n", describeRevision(workload.Status.Rollout.CurrentRevision))
+	fmt.Fprintf(w, "PREVIOUS REVISION:\t%s\n", describeRevision(workload.Status.Rollout.PreviousRevision))
+	fmt.Fprintln(w)
+
+	blocking, err := blockingPhase(workload)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\tPHASE\tSTATE\tMESSAGE\tLAST MODIFIED")
+
+	for i, condition := range workload.Status.Conditions {
+		marker := ""
+		if i == blocking {
+			marker = blockingMarker
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			marker, condition.Phase, condition.State, condition.Message, condition.LastModified)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tCREATED\tADOPTED\tLAST RESOURCE PHASE\tMESSAGE")
+
+	for _, resource := range workload.Status.Resources {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%t\t%s\t%s\n",
+			resource.Kind, resource.Namespace, resource.Name,
+			resource.Created, resource.Adopted, resource.LastResourcePhase, resource.Message)
+	}
+
+	fmt.Fprintln(w)
+
+	if blocking >= 0 {
+		condition := workload.Status.Conditions[blocking]
+		fmt.Fprintf(w, "%s blocking phase: %s (%s): %s\n",
+			blockingMarker, condition.Phase, condition.State, condition.Message)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write output, %w", err)
+	}
+
+	return nil
+}
+
+// blockingPhase returns the index of the condition of the phase which is blocking reconciliation,
+// or -1 when no phase is blocking.  Conditions are stored in the order in which the phases first
+// ran, which differs from the order in which they execute once the update phases run or phases
+// are added to the pipeline, so the first phase which is not complete is found in the order of the
+// pipeline of the workload.
+func blockingPhase(workload *appsv1alpha1.WebStore) (int, error) {
+	pipeline, err := pipelines.WebStorePipeline(pipelines.DefaultWebStoreOptions())
+	if err != nil {
+		return -1, fmt.Errorf("failed to get pipeline, %w", err)
+	}
+
+	pipelinePhases, err := utils.Phases(workload, pipeline)
+	if err != nil {
+		return -1, fmt.Errorf("failed to get pipeline phases, %w", err)
+	}
+
+	for _, phase := range pipelinePhases {
+		for i, condition := range workload.Status.Conditions {
+			if condition.Phase == phase.Name && condition.State != common.PhaseStateComplete {
+				return i, nil
+			}
+		}
+	}
+
+	return -1, nil
+}
+
+// describeTLS describes the certificate of a workload for display.
+func describeTLS(tls *appsv1alpha1.WebStoreTLSStatus) string {
+	if tls == nil {
+		return "<none>"
+	}
+
+	if tls.NotAfter == nil {
+		return tls.SecretName
+	}
+
+	return fmt.Sprintf("%s (expires %s)", tls.SecretName, tls.NotAfter.UTC().Format(time.RFC3339))
+}
+
+// describeNaming describes the naming of the child resources of a workload for display.
+func describeNaming(workload *appsv1alpha1.WebStore) string {
+	if workload.IsMigrating() {
+		return fmt.Sprintf("%s (migrating to %s)", workload.ServedNaming(), workload.Naming())
+	}
+
+	return string(workload.ServedNaming())
+}
+
+// describeDegraded describes the degraded condition of a workload for display.
+func describeDegraded(degraded *common.DegradedCondition) string {
+	if degraded == nil {
+		return "false"
+	}
+
+	return fmt.Sprintf("true (%s: %s)", degraded.Phase, degraded.Message)
+}
+
+// describeRevision describes a revision of a workload for display.
+func describeRevision(revision *appsv1alpha1.WebStoreRevision) string {
+	if revision == nil {
+		return "<none>"
+	}
+
+	return fmt.Sprintf("%s (%s)", revision.Name, revision.WebstoreImage)
+}
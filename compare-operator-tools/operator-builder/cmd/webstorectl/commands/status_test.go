@@ -0,0 +1,239 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+)
+
+// watchClient is a fake client which serves a closed watch with the provided events for each watch
+// which is established, and records the resource version which each watch is established from.
+type watchClient struct {
+	client.WithWatch
+
+	events           [][]watch.Event
+	resourceVersions []string
+}
+
+// Watch returns a watch which serves the next events of the fake client and is closed by the server
+// once they have been served.
+func (c *watchClient) Watch(
+	ctx context.Context,
+	list client.ObjectList,
+	opts ...client.ListOption,
+) (watch.Interface, error) {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	c.resourceVersions = append(c.resourceVersions, listOpts.AsListOptions().ResourceVersion)
+
+	watcher := watch.NewFakeWithChanSize(len(c.events[0]), false)
+
+	for _, event := range c.events[0] {
+		watcher.Action(event.Type, event.Object)
+	}
+
+	watcher.Stop()
+	c.events = c.events[1:]
+
+	return watcher, nil
+}
+
+// watchedFixture returns the watched workload at a resource version.
+func watchedFixture(resourceVersion string) *appsv1alpha1.WebStore {
+	return &appsv1alpha1.WebStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample", Namespace: "default", ResourceVersion: resourceVersion},
+	}
+}
+
+func TestWatchStatus(t *testing.T) {
+	expired := &metav1.Status{Status: metav1.StatusFailure, Code: http.StatusGone, Reason: metav1.StatusReasonExpired}
+
+	tests := []struct {
+		name                 string
+		events               [][]watch.Event
+		wantResourceVersions []string
+		wantStatuses         int
+	}{
+		{
+			name: "closed watch is re-established from the last resource version",
+			events: [][]watch.Event{
+				{{Type: watch.Modified, Object: watchedFixture("2")}},
+				{{Type: watch.Bookmark, Object: watchedFixture("3")}},
+				{{Type: watch.Modified, Object: watchedFixture("4")}, {Type: watch.Deleted, Object: watchedFixture("5")}},
+			},
+			wantResourceVersions: []string{"1", "2", "3"},
+			wantStatuses:         2,
+		},
+		{
+			name: "expired watch is re-established from the current state",
+			events: [][]watch.Event{
+				{{Type: watch.Error, Object: expired}},
+				{{Type: watch.Added, Object: watchedFixture("6")}, {Type: watch.Deleted, Object: watchedFixture("7")}},
+			},
+			wantResourceVersions: []string{"1", ""},
+			wantStatuses:         1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &watchClient{events: tt.events}
+
+			var output bytes.Buffer
+
+			if err := (&statusCommand{}).watchStatus(context.Background(), c, &output, watchedFixture("1")); err != nil {
+				t.Fatalf("watchStatus() error = %v", err)
+			}
+
+			if strings.Join(c.resourceVersions, ",") != strings.Join(tt.wantResourceVersions, ",") {
+				t.Errorf("watched resource versions = %q, want %q", c.resourceVersions, tt.wantResourceVersions)
+			}
+
+			if statuses := strings.Count(output.String(), "NAME:"); statuses != tt.wantStatuses {
+				t.Errorf("displayed statuses = %d, want %d", statuses, tt.wantStatuses)
+			}
+
+			if !strings.Contains(output.String(), "was deleted") {
+				t.Errorf("output = %q, want the deletion displayed", output.String())
+			}
+		})
+	}
+}
+
+func TestWatchStatusBacksOff(t *testing.T) {
+	backoff := wait.Backoff{Duration: 20 * time.Millisecond, Factor: 2, Steps: 10}
+
+	// the server closes the first watches without any events
+	c := &watchClient{events: [][]watch.Event{
+		{},
+		{},
+		{{Type: watch.Modified, Object: watchedFixture("2")}},
+		{},
+		{{Type: watch.Deleted, Object: watchedFixture("3")}},
+	}}
+
+	s := &statusCommand{backoff: backoff}
+	start := time.Now()
+
+	if err := s.watchStatus(context.Background(), c, &bytes.Buffer{}, watchedFixture("1")); err != nil {
+		t.Fatalf("watchStatus() error = %v", err)
+	}
+
+	// the backoff grows while the watch makes no progress, and is reset once it does
+	if elapsed, want := time.Since(start), 20*time.Millisecond+40*time.Millisecond+20*time.Millisecond; elapsed < want {
+		t.Errorf("watchStatus() returned after %s, want a backoff of at least %s", elapsed, want)
+	}
+
+	if want := []string{"1", "1", "1", "2", "2"}; strings.Join(c.resourceVersions, ",") != strings.Join(want, ",") {
+		t.Errorf("watched resource versions = %q, want %q", c.resourceVersions, want)
+	}
+}
+
+func TestWatchStatusStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := &watchClient{events: [][]watch.Event{{}}}
+
+	if err := (&statusCommand{}).watchStatus(ctx, c, &bytes.Buffer{}, watchedFixture("1")); err != nil {
+		t.Fatalf("watchStatus() error = %v", err)
+	}
+
+	if len(c.resourceVersions) != 0 {
+		t.Errorf("watched resource versions = %q, want no watch once cancelled", c.resourceVersions)
+	}
+}
+
+func TestWriteStatusBlockingPhase(t *testing.T) {
+	condition := func(phase string, state common.PhaseState) common.PhaseCondition {
+		return common.PhaseCondition{Phase: phase, State: state, Message: phase + " message"}
+	}
+
+	tests := []struct {
+		name         string
+		created      bool
+		conditions   []common.PhaseCondition
+		wantBlocking string
+	}{
+		{
+			name: "phase which was added to the pipeline blocks before later phases",
+			conditions: []common.PhaseCondition{
+				condition("DependencyPhase", common.PhaseStateComplete),
+				condition("CreateResourcesPhase", common.PhaseStateComplete),
+				condition("CheckReadyPhase", common.PhaseStatePending),
+				condition("PreFlightPhase", common.PhaseStateFailed),
+			},
+			wantBlocking: "PreFlightPhase",
+		},
+		{
+			name:    "dependency phase does not block once the update phases run",
+			created: true,
+			conditions: []common.PhaseCondition{
+				condition("DependencyPhase", common.PhaseStatePending),
+				condition("CreateResourcesPhase", common.PhaseStateComplete),
+				condition("CheckReadyPhase", common.PhaseStatePending),
+			},
+			wantBlocking: "CheckReadyPhase",
+		},
+		{
+			name: "complete phases do not block",
+			conditions: []common.PhaseCondition{
+				condition("DependencyPhase", common.PhaseStateComplete),
+				condition("CompletePhase", common.PhaseStateComplete),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workload := watchedFixture("1")
+			workload.Status.Created = tt.created
+			workload.Status.Conditions = tt.conditions
+
+			var output bytes.Buffer
+
+			if err := writeStatus(&output, workload); err != nil {
+				t.Fatalf("writeStatus() error = %v", err)
+			}
+
+			blocking := ""
+
+			for _, line := range strings.Split(output.String(), "\n") {
+				if strings.HasPrefix(line, blockingMarker+" blocking phase: ") {
+					blocking = strings.Fields(strings.TrimPrefix(line, blockingMarker+" blocking phase: "))[0]
+				}
+			}
+
+			if blocking != tt.wantBlocking {
+				t.Errorf("blocking phase = %q, want %q\n%s", blocking, tt.wantBlocking, output.String())
+			}
+		})
+	}
+}
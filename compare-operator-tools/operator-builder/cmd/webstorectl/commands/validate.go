@@ -0,0 +1,331 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	"github.com/scottd018/demos/internal/crd"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// Below are the checks which are performed by the validate subcommand.  The input check reports the
+// errors which prevent the remaining checks from running.
+const (
+	checkInput    = "input"
+	checkSchema   = "schema"
+	checkSemantic = "semantic"
+	checkGenerate = "generate"
+)
+
+type validateCommand struct {
+	*cobra.Command
+	workloadManifest string
+	crdManifest      string
+	output           string
+}
+
+// validationError is a single failed check for a workload manifest.
+type validationError struct {
+	Check   string `json:"check"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// validationResult is the machine-readable result of validating a workload manifest.
+type validationResult struct {
+	Manifest string            `json:"manifest"`
+	Valid    bool              `json:"valid"`
+	Errors   []validationError `json:"errors,omitempty"`
+}
+
+// newValidateCommand creates a new instance of the validate subcommand.
+func (c *WebstorectlCommand) newValidateCommand() {
+	v := &validateCommand{}
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate a workload's custom resource manifest",
+		Long: "Validate a workload's custom resource manifest against the custom resource definition schema, " +
+			"check its semantic rules and dry-run the generation of its child resources",
+		RunE: v.validate,
+	}
+
+	validateCmd.Flags().StringVarP(
+		&v.workloadManifest,
+		"workload-manifest",
+		"w",
+		"",
+		"Filepath to the workload manifest to validate.",
+	)
+	validateCmd.MarkFlagRequired("workload-manifest")
+
+	validateCmd.Flags().StringVarP(
+		&v.crdManifest,
+		"crd-manifest",
+		"c",
+		"",
+		"Filepath to the custom resource definition manifest which contains the OpenAPI schema.  "+
+			"The custom resource definition which is compiled in is used when unset.",
+	)
+
+	validateCmd.Flags().StringVarP(
+		&v.output,
+		"output",
+		"o",
+		outputText,
+		"Output format of the validation result; one of text or json.",
+	)
+
+	c.AddCommand(validateCmd)
+}
+
+// validate validates a workload's custom resource manifest.
+func (v *validateCommand) validate(cmd *cobra.Command, args []string) error {
+	if v.output != outputText && v.output != outputJSON {
+		return fmt.Errorf("invalid output format %s; must be one of %s or %s", v.output, outputText, outputJSON)
+	}
+
+	// from this point on, failures are reported in the result rather than as usage errors
+	cmd.SilenceUsage = true
+
+	return v.report(os.Stdout)
+}
+
+// report writes the result of validating the workload manifest to the output.  An error is returned
+// when the workload manifest is invalid or could not be validated.
+func (v *validateCommand) report(output io.Writer) error {
+	result, err := v.run()
+	if err != nil {
+		if v.output != outputJSON {
+			return err
+		}
+
+		// errors which prevent the checks from running are written in the result as well, so that
+		// the json output may always be parsed
+		result = &validationResult{Manifest: v.workloadManifest}
+		result.addError(checkInput, "", err.Error())
+
+		if writeErr := result.complete().write(output, v.output); writeErr != nil {
+			return writeErr
+		}
+
+		return err
+	}
+
+	if err := result.write(output, v.output); err != nil {
+		return err
+	}
+
+	if !result.Valid {
+		return fmt.Errorf("workload manifest %s is invalid", v.workloadManifest)
+	}
+
+	return nil
+}
+
+// run executes each of the checks against the workload manifest and returns the result.
+func (v *validateCommand) run() (*validationResult, error) {
+	result := &validationResult{Manifest: v.workloadManifest}
+
+	if err := v.validateSchema(result); err != nil {
+		return nil, err
+	}
+
+	// stop if the manifest does not decode into a workload as the remaining checks require it
+	workload, err := readWorkload(v.workloadManifest)
+	if err != nil {
+		result.addError(checkSchema, "", err.Error())
+
+		return result.complete(), nil
+	}
+
+	result.addFieldErrors(checkSemantic, workload.Validate())
+
+	// dry-run each create function individually so that failures can be attributed
+	for _, f := range webstore.CreateFuncs {
+		if _, err := f(workload.DeepCopy()); err != nil {
+			result.addError(checkGenerate, "", fmt.Sprintf("%s: %v", getFuncName(f), err))
+		}
+	}
+
+	// dry-run the full pipeline, including the mutate functions
+	if _, err := generateResources(workload.DeepCopy()); err != nil {
+		result.addError(checkGenerate, "", err.Error())
+	}
+
+	return result.complete(), nil
+}
+
+// validateSchema validates the workload manifest against the OpenAPI schema of the custom resource
+// definition once the defaults of the schema have been applied, which is how the API server would
+// validate it on admission.  Only errors which prevent the check from running at all are returned.
+func (v *validateCommand) validateSchema(result *validationResult) error {
+	schema, err := loadSchema(v.crdManifest)
+	if err != nil {
+		return err
+	}
+
+	filename, _ := filepath.Abs(v.workloadManifest)
+
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s, %w", filename, err)
+	}
+
+	jsonFile, err := yaml.YAMLToJSON(yamlFile)
+	if err != nil {
+		result.addError(checkSchema, "", fmt.Sprintf("failed to convert yaml to json, %v", err))
+
+		return nil
+	}
+
+	// use the apimachinery json package so that integers are decoded as they are by the API server
+	var object map[string]interface{}
+	if err := utiljson.Unmarshal(jsonFile, &object); err != nil {
+		result.addError(checkSchema, "", fmt.Sprintf("failed to unmarshal json, %v", err))
+
+		return nil
+	}
+
+	schema.Default(object)
+
+	errs, err := schema.Validate(object)
+	if err != nil {
+		return fmt.Errorf("failed to validate against the schema, %w", err)
+	}
+
+	result.addFieldErrors(checkSchema, errs)
+
+	// the API server prunes unknown fields silently; report them here instead so typos are caught
+	if err := yaml.UnmarshalStrict(yamlFile, &appsv1alpha1.WebStore{}); err != nil {
+		result.addError(checkSchema, "", err.Error())
+	}
+
+	return nil
+}
+
+// loadSchema returns the schema of the workload version of a custom resource definition manifest,
+// or of the custom resource definition which is compiled in when no manifest is provided.
+func loadSchema(crdManifest string) (*crd.Schema, error) {
+	if crdManifest == "" {
+		return crd.WebStoreSchema()
+	}
+
+	filename, _ := filepath.Abs(crdManifest)
+
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s, %w", filename, err)
+	}
+
+	var definition extensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(yamlFile, &definition); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal yaml %s into custom resource definition, %w", filename, err)
+	}
+
+	schema, err := crd.NewSchema(&definition, appsv1alpha1.GroupVersion.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema from %s, %w", filename, err)
+	}
+
+	return schema, nil
+}
+
+// getFuncName returns the short name of a create function for use in messages.
+func getFuncName(f interface{}) string {
+	funcName := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+
+	return funcName[strings.LastIndex(funcName, ".")+1:]
+}
+
+// addError adds a validation error to the result.
+func (result *validationResult) addError(check, fieldPath, message string) {
+	result.Errors = append(result.Errors, validationError{
+		Check:   check,
+		Field:   fieldPath,
+		Message: message,
+	})
+}
+
+// addFieldErrors adds a list of field errors to the result.
+func (result *validationResult) addFieldErrors(check string, errs field.ErrorList) {
+	for _, err := range errs {
+		result.addError(check, err.Field, err.ErrorBody())
+	}
+}
+
+// complete marks the result as valid when no errors were found and returns it.
+func (result *validationResult) complete() *validationResult {
+	result.Valid = len(result.Errors) == 0
+
+	return result
+}
+
+// write writes the result to the output stream in the requested output format.
+func (result *validationResult) write(outputStream io.Writer, output string) error {
+	if output == outputJSON {
+		encoder := json.NewEncoder(outputStream)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("failed to write output, %w", err)
+		}
+
+		return nil
+	}
+
+	if result.Valid {
+		if _, err := fmt.Fprintf(outputStream, "%s is valid\n", result.Manifest); err != nil {
+			return fmt.Errorf("failed to write output, %w", err)
+		}
+
+		return nil
+	}
+
+	for _, e := range result.Errors {
+		message := e.Message
+		if e.Field != "" {
+			message = e.Field + ": " + message
+		}
+
+		if _, err := fmt.Fprintf(outputStream, "[%s] %s\n", e.Check, message); err != nil {
+			return fmt.Errorf("failed to write output, %w", err)
+		}
+	}
+
+	return nil
+}
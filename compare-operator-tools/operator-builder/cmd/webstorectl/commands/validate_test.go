@@ -0,0 +1,103 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateReport(t *testing.T) {
+	tests := []struct {
+		name       string
+		manifest   string
+		wantValid  bool
+		wantErrors []validationError
+	}{
+		{
+			name: "valid manifest is validated against the compiled in schema",
+			manifest: `apiVersion: apps.acme.com/v1alpha1
+kind: WebStore
+metadata:
+  name: webstore-sample
+spec:
+  webStoreReplicas: 0
+`,
+			wantValid: true,
+		},
+		{
+			name: "invalid field is reported",
+			manifest: `apiVersion: apps.acme.com/v1alpha1
+kind: WebStore
+metadata:
+  name: webstore-sample
+spec:
+  webStoreReplicas: 101
+`,
+			wantErrors: []validationError{
+				{Check: checkSchema, Field: "spec.webStoreReplicas", Message: "Invalid value: 101: spec.webStoreReplicas in body should be less than or equal to 100"},
+			},
+		},
+		{
+			name: "missing manifest is reported in the result",
+			wantErrors: []validationError{
+				{Check: checkInput},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadManifest := filepath.Join(t.TempDir(), "workload.yaml")
+
+			if tt.manifest != "" {
+				if err := ioutil.WriteFile(workloadManifest, []byte(tt.manifest), 0o600); err != nil {
+					t.Fatalf("unable to write workload manifest; %v", err)
+				}
+			}
+
+			v := &validateCommand{workloadManifest: workloadManifest, output: outputJSON}
+
+			var output bytes.Buffer
+
+			err := v.report(&output)
+			if (err == nil) != tt.wantValid {
+				t.Errorf("report() error = %v, want valid %v", err, tt.wantValid)
+			}
+
+			// the output is always a json result, including for errors which prevent the checks
+			result := &validationResult{}
+			if err := json.Unmarshal(output.Bytes(), result); err != nil {
+				t.Fatalf("unable to unmarshal result %q; %v", output.String(), err)
+			}
+
+			if result.Valid != tt.wantValid || len(result.Errors) != len(tt.wantErrors) {
+				t.Fatalf("result = %+v, want valid %v with errors %+v", result, tt.wantValid, tt.wantErrors)
+			}
+
+			for i, want := range tt.wantErrors {
+				got := result.Errors[i]
+				if got.Check != want.Check || got.Field != want.Field || (want.Message != "" && got.Message != want.Message) {
+					t.Errorf("error = %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
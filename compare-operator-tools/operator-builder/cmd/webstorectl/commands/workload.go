@@ -0,0 +1,208 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/crd"
+	"github.com/scottd018/demos/internal/resources"
+)
+
+// newScheme returns a scheme which knows about the workload and its child resource types.
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+
+	return scheme
+}
+
+// readWorkload reads a workload manifest from a file and applies the API defaults to it.
+func readWorkload(workloadManifest string) (*appsv1alpha1.WebStore, error) {
+	filename, _ := filepath.Abs(workloadManifest)
+
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s, %w", filename, err)
+	}
+
+	jsonFile, err := yaml.YAMLToJSON(yamlFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert yaml %s to json, %w", filename, err)
+	}
+
+	// use the apimachinery json package so that integers are decoded as they are by the API server
+	var content map[string]interface{}
+	if err := utiljson.Unmarshal(jsonFile, &content); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal yaml %s into workload, %w", filename, err)
+	}
+
+	return decodeWorkload(content)
+}
+
+// decodeWorkload applies the API defaults to the content of a workload manifest and decodes it into
+// a workload.  The defaults are applied before decoding, as the API server does on admission, so
+// that only the fields which are absent from the manifest are defaulted.
+func decodeWorkload(content map[string]interface{}) (*appsv1alpha1.WebStore, error) {
+	if content == nil {
+		content = map[string]interface{}{}
+	}
+
+	if err := crd.Default(content); err != nil {
+		return nil, fmt.Errorf("failed to default workload, %w", err)
+	}
+
+	workload := &appsv1alpha1.WebStore{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, workload); err != nil {
+		return nil, fmt.Errorf("failed to decode workload, %w", err)
+	}
+
+	return workload, nil
+}
+
+// newOfflineReconciler returns the reconcile of a workload by a reconciler which is backed by an
+// in-memory client rather than a live cluster.  This allows the companion CLI to run the exact
+// pipeline that the controller runs without requiring access to a cluster.  Any objects passed in
+// are used to seed the in-memory client.
+func newOfflineReconciler(workload *appsv1alpha1.WebStore, objects ...client.Object) *appscontrollers.WebStoreRequest {
+	scheme := newScheme()
+
+	reconciler := &appscontrollers.WebStoreReconciler{
+		Name:   "WebStore",
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Log:    ctrl.Log.WithName("webstorectl"),
+		Scheme: scheme,
+	}
+
+	return reconciler.NewRequest(context.Background(), workload)
+}
+
+// generateResources renders the child resources for a workload by running them through the same
+// construct and mutate pipeline that the controller uses.
+func generateResources(workload *appsv1alpha1.WebStore) ([]client.Object, error) {
+	r := newOfflineReconciler(workload)
+
+	if err := r.SetResources(); err != nil {
+		return nil, err
+	}
+
+	resourceObjects := make([]client.Object, len(r.GetResources()))
+	for i, resource := range r.GetResources() {
+		resourceObjects[i] = resource.GetObject()
+
+		// generated resources are created as is, so they must not contain null fields
+		if object, ok := resourceObjects[i].(*unstructured.Unstructured); ok {
+			resources.RemoveNullFields(object.Object)
+		}
+	}
+
+	return resourceObjects, nil
+}
+
+// readManifests reads all of the objects from the yaml or json manifests in a directory.
+func readManifests(directory string) ([]client.Object, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s, %w", directory, err)
+	}
+
+	objects := []client.Object{}
+
+	for _, file := range files {
+		switch filepath.Ext(file.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		filename := filepath.Join(directory, file.Name())
+
+		manifest, err := os.Open(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file %s, %w", filename, err)
+		}
+
+		fileObjects, err := decodeManifest(manifest)
+		manifest.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifest %s, %w", filename, err)
+		}
+
+		objects = append(objects, fileObjects...)
+	}
+
+	return objects, nil
+}
+
+// decodeManifest decodes all of the objects from a multi-document yaml or json stream.  Lists, as
+// produced by kubectl get -o yaml, are expanded into their items.
+func decodeManifest(manifest io.Reader) ([]client.Object, error) {
+	objects := []client.Object{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(manifest, 4096)
+
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+
+			return nil, err
+		}
+
+		// skip empty documents
+		if len(object.Object) == 0 {
+			continue
+		}
+
+		if !object.IsList() {
+			objects = append(objects, object)
+
+			continue
+		}
+
+		if err := object.EachListItem(func(item runtime.Object) error {
+			objects = append(objects, item.(*unstructured.Unstructured))
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apps_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/resources"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+const adoptedDeploymentName = "webstore-sample-deploy"
+
+// adoptionFixture returns a WebStore with the adoption policy.
+func adoptionFixture(adoption appsv1alpha1.WebStoreAdoption) *appsv1alpha1.WebStore {
+	return testutil.WebStoreFixture("webstore-sample", appsv1alpha1.WebStoreSpec{
+		WebStoreReplicas: 2,
+		WebstoreImage:    "nginx:1.17",
+		Adoption:         adoption,
+	})
+}
+
+// unmanagedDeploymentFixture returns the WebStore deployment as it exists in the cluster before the
+// WebStore was created, optionally controlled by another owner.
+func unmanagedDeploymentFixture(owner *metav1.OwnerReference) *appsv1.Deployment {
+	replicas := int32(1)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: adoptedDeploymentName, Namespace: testutil.Namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "webstore-container", Image: "nginx:1.16"}},
+				},
+			},
+		},
+	}
+
+	if owner != nil {
+		deployment.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+
+	return deployment
+}
+
+// otherControllerFixture returns a controller reference to an owner which is not a WebStore.
+func otherControllerFixture() *metav1.OwnerReference {
+	controller := true
+
+	return &metav1.OwnerReference{
+		APIVersion: "apps.example.com/v1",
+		Kind:       "Shop",
+		Name:       "shop",
+		UID:        types.UID("shop-uid"),
+		Controller: &controller,
+	}
+}
+
+// newAdoptionReconciler returns a reconciler for the component whose resources have been set, and
+// which is backed by a fake client seeded with the component and the objects.
+func newAdoptionReconciler(
+	t *testing.T,
+	component *appsv1alpha1.WebStore,
+	objects ...client.Object,
+) *appscontrollers.WebStoreRequest {
+	r := testutil.NewFakeRequest(component, objects...)
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	return r
+}
+
+// getResourceCondition returns the condition of the named deployment from the status.
+func getResourceCondition(t *testing.T, component *appsv1alpha1.WebStore, name string) common.ResourceCondition {
+	for _, resource := range component.Status.Resources {
+		if resource.Kind == "Deployment" && resource.Name == name {
+			return resource.ResourceCondition
+		}
+	}
+
+	t.Fatalf("condition for deployment %s was not set", name)
+
+	return common.ResourceCondition{}
+}
+
+func TestCreateResourcesPhaseAdoption(t *testing.T) {
+	tests := []struct {
+		name        string
+		adoption    appsv1alpha1.WebStoreAdoption
+		owner       *metav1.OwnerReference
+		wantErr     string
+		wantAdopted string
+	}{
+		{
+			name:        "unowned resource is adopted by default",
+			adoption:    appsv1alpha1.WebStoreAdoption{},
+			wantAdopted: string(appsv1alpha1.AdoptionPolicyIfUnowned),
+		},
+		{
+			name:     "unowned resource is not adopted with never",
+			adoption: appsv1alpha1.WebStoreAdoption{Policy: appsv1alpha1.AdoptionPolicyNever},
+			wantErr:  "is not controlled by WebStore [webstore-sample] and the adoption policy is [Never]",
+		},
+		{
+			name:     "controlled resource is not adopted with if unowned",
+			adoption: appsv1alpha1.WebStoreAdoption{Policy: appsv1alpha1.AdoptionPolicyIfUnowned},
+			owner:    otherControllerFixture(),
+			wantErr:  "is controlled by Shop [shop] and the adoption policy is [IfUnowned]",
+		},
+		{
+			name:        "controlled resource is adopted with force",
+			adoption:    appsv1alpha1.WebStoreAdoption{Policy: appsv1alpha1.AdoptionPolicyForce},
+			owner:       otherControllerFixture(),
+			wantAdopted: string(appsv1alpha1.AdoptionPolicyForce),
+		},
+		{
+			name: "policy of the child resource takes precedence",
+			adoption: appsv1alpha1.WebStoreAdoption{
+				Policy: appsv1alpha1.AdoptionPolicyNever,
+				Resources: []appsv1alpha1.WebStoreResourceAdoption{
+					{Kind: "Deployment", Policy: appsv1alpha1.AdoptionPolicyIfUnowned},
+					{Kind: "Deployment", Name: adoptedDeploymentName, Policy: appsv1alpha1.AdoptionPolicyForce},
+				},
+			},
+			owner:       otherControllerFixture(),
+			wantAdopted: string(appsv1alpha1.AdoptionPolicyForce),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component := adoptionFixture(tt.adoption)
+			r := newAdoptionReconciler(t, component, unmanagedDeploymentFixture(tt.owner))
+
+			_, err := (&phases.CreateResourcesPhase{}).Execute(r)
+
+			condition := getResourceCondition(t, component, adoptedDeploymentName)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+
+				if condition.Created || !strings.Contains(condition.Message, tt.wantErr) {
+					t.Errorf("resource condition = %+v, want the refusal to be reported", condition)
+				}
+
+				deployment := &appsv1.Deployment{}
+				if err := r.Get(r.Context, client.ObjectKey{Name: adoptedDeploymentName, Namespace: testutil.Namespace}, deployment); err != nil {
+					t.Fatalf("unable to get deployment; %v", err)
+				}
+
+				if metav1.IsControlledBy(deployment, component) || deployment.Spec.Template.Spec.Containers[0].Image != "nginx:1.16" {
+					t.Errorf("deployment was modified although it was not adopted")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			deployment := &appsv1.Deployment{}
+			if err := r.Get(r.Context, client.ObjectKey{Name: adoptedDeploymentName, Namespace: testutil.Namespace}, deployment); err != nil {
+				t.Fatalf("unable to get deployment; %v", err)
+			}
+
+			if !metav1.IsControlledBy(deployment, component) {
+				t.Errorf("deployment owner references = %v, want it to be controlled by the web store", deployment.OwnerReferences)
+			}
+
+			if adopted := deployment.Annotations[resources.AdoptedAnnotation]; adopted != tt.wantAdopted {
+				t.Errorf("adopted annotation = %q, want %q", adopted, tt.wantAdopted)
+			}
+
+			if !condition.Created || !condition.Adopted || !strings.Contains(condition.Message, tt.wantAdopted) {
+				t.Errorf("resource condition = %+v, want an adopted resource", condition)
+			}
+
+			// the adoption is still reported once the resource is controlled by the web store
+			if err := r.SetResources(); err != nil {
+				t.Fatalf("SetResources() error = %v", err)
+			}
+
+			if _, err := (&phases.CreateResourcesPhase{}).Execute(r); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if condition := getResourceCondition(t, component, adoptedDeploymentName); !condition.Adopted {
+				t.Errorf("resource condition = %+v, want the adoption to be carried forward", condition)
+			}
+		})
+	}
+}
+
+func TestCreateResourcesPhaseCreatesWithoutAdoption(t *testing.T) {
+	component := adoptionFixture(appsv1alpha1.WebStoreAdoption{Policy: appsv1alpha1.AdoptionPolicyNever})
+	r := newAdoptionReconciler(t, component)
+
+	if proceed, err := (&phases.CreateResourcesPhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("Execute() = %v, %v", proceed, err)
+	}
+
+	// resources which the web store created are controlled by it, so they are updated regardless of
+	// the adoption policy
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	if proceed, err := (&phases.CreateResourcesPhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("Execute() = %v, %v", proceed, err)
+	}
+
+	if condition := getResourceCondition(t, component, adoptedDeploymentName); !condition.Created || condition.Adopted {
+		t.Errorf("resource condition = %+v, want a created resource which was not adopted", condition)
+	}
+}
+
+func TestReconcileRecoversFromResolvedCollision(t *testing.T) {
+	component := adoptionFixture(appsv1alpha1.WebStoreAdoption{Policy: appsv1alpha1.AdoptionPolicyIfUnowned})
+	collision := unmanagedDeploymentFixture(otherControllerFixture())
+
+	r := testutil.NewReconciler(&preFlightClient{
+		Client: testutil.NewClient(component, collision, testutil.NamespaceFixture(testutil.Namespace)),
+		mapper: newPreFlightMapper(),
+	})
+	request := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(component)}
+
+	// the deployment is controlled by another owner, which degrades the web store
+	result, err := r.Reconcile(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if result.RequeueAfter != phases.PermanentErrorRequeueAfter {
+		t.Errorf("Reconcile() result = %+v, want a requeue after %s", result, phases.PermanentErrorRequeueAfter)
+	}
+
+	persisted := &appsv1alpha1.WebStore{}
+	if err := r.Get(context.Background(), request.NamespacedName, persisted); err != nil {
+		t.Fatalf("unable to get web store; %v", err)
+	}
+
+	if persisted.GetDegradedCondition() == nil {
+		t.Fatalf("degraded condition was not set for the collision")
+	}
+
+	// the collision is removed outside of the web store, so its spec does not change and the web
+	// store recovers on the requeued request
+	if err := r.Delete(context.Background(), collision); err != nil {
+		t.Fatalf("unable to delete deployment; %v", err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	persisted = &appsv1alpha1.WebStore{}
+	if err := r.Get(context.Background(), request.NamespacedName, persisted); err != nil {
+		t.Fatalf("unable to get web store; %v", err)
+	}
+
+	if degraded := persisted.GetDegradedCondition(); degraded != nil {
+		t.Errorf("degraded condition = %+v, want it to be cleared", degraded)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(context.Background(), client.ObjectKeyFromObject(collision), deployment); err != nil {
+		t.Fatalf("unable to get deployment; %v", err)
+	}
+
+	if !metav1.IsControlledBy(deployment, persisted) {
+		t.Errorf("deployment owner references = %v, want it to be controlled by the web store", deployment.OwnerReferences)
+	}
+}
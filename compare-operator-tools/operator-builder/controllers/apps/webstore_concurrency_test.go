@@ -0,0 +1,181 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apps_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/controllers/utils"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// concurrentWebStores is the number of web stores which are reconciled at the same time.
+const concurrentWebStores = 8
+
+// concurrentFixtures returns web stores which are each reconciled by the same reconciler.
+func concurrentFixtures() []*appsv1alpha1.WebStore {
+	components := make([]*appsv1alpha1.WebStore, concurrentWebStores)
+
+	for i := range components {
+		name := fmt.Sprintf("webstore-%d", i)
+
+		components[i] = testutil.WebStoreFixture(name, appsv1alpha1.WebStoreSpec{
+			WebStoreReplicas: i + 1,
+			WebstoreImage:    "nginx:1.17",
+		})
+	}
+
+	return components
+}
+
+// newConcurrentReconciler returns a reconciler which is backed by a fake client seeded with the
+// components and their namespace.
+func newConcurrentReconciler(components []*appsv1alpha1.WebStore) *appscontrollers.WebStoreReconciler {
+	objects := []client.Object{testutil.NamespaceFixture(testutil.Namespace)}
+	for _, component := range components {
+		objects = append(objects, component)
+	}
+
+	return testutil.NewReconciler(&preFlightClient{
+		Client: testutil.NewClient(objects...),
+		mapper: newPreFlightMapper(),
+	})
+}
+
+func TestConcurrentReconciles(t *testing.T) {
+	components := concurrentFixtures()
+	r := newConcurrentReconciler(components)
+
+	// reconcile each of the web stores at the same time, twice over, as the controller never
+	// reconciles the same web store at the same time
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 2*len(components))
+
+	for round := 0; round < 2; round++ {
+		for _, component := range components {
+			wg.Add(1)
+
+			go func(key types.NamespacedName) {
+				defer wg.Done()
+
+				if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+					errs <- fmt.Errorf("Reconcile(%s) error = %w", key, err)
+				}
+			}(client.ObjectKeyFromObject(component))
+		}
+
+		wg.Wait()
+	}
+
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i, component := range components {
+		persisted := &appsv1alpha1.WebStore{}
+		if err := r.Get(context.Background(), client.ObjectKeyFromObject(component), persisted); err != nil {
+			t.Fatalf("unable to get web store; %v", err)
+		}
+
+		// the status of each web store only refers to its own child resources
+		if len(persisted.Status.Resources) == 0 {
+			t.Errorf("web store %s has no resource conditions", component.Name)
+		}
+
+		for _, resource := range persisted.Status.Resources {
+			if !strings.HasPrefix(resource.Name, component.Name+"-") {
+				t.Errorf("web store %s has a resource condition for %s %s", component.Name, resource.Kind, resource.Name)
+			}
+		}
+
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(context.Background(), client.ObjectKey{Name: component.Name + "-deploy", Namespace: testutil.Namespace}, deployment); err != nil {
+			t.Fatalf("unable to get deployment; %v", err)
+		}
+
+		if replicas := *deployment.Spec.Replicas; replicas != int32(i+1) {
+			t.Errorf("deployment %s replicas = %d, want %d", deployment.Name, replicas, i+1)
+		}
+
+		// the desired child resources which the child resource events are compared against are
+		// recorded for each web store
+		desired := r.GetDesiredResources(deployment)
+		if len(desired) == 0 {
+			t.Errorf("web store %s has no desired resources", component.Name)
+		}
+
+		for _, resource := range desired {
+			if name := resource.GetObject().GetName(); !strings.HasPrefix(name, component.Name+"-") {
+				t.Errorf("web store %s has a desired resource %s", component.Name, name)
+			}
+		}
+	}
+}
+
+func TestResourcePredicatesCompareEachWebStore(t *testing.T) {
+	components := concurrentFixtures()
+	r := newConcurrentReconciler(components)
+
+	for _, component := range components {
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(component)}); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	// the predicates are the same for each web store, so they must not hold the state of the
+	// reconcile which registered the watch
+	predicates := utils.ResourcePredicates(r)
+
+	for _, component := range components {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(context.Background(), client.ObjectKey{Name: component.Name + "-deploy", Namespace: testutil.Namespace}, deployment); err != nil {
+			t.Fatalf("unable to get deployment; %v", err)
+		}
+
+		deployment.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+
+		unchanged := deployment.DeepCopy()
+		unchanged.ResourceVersion += "0"
+
+		if predicates.Update(event.UpdateEvent{ObjectOld: deployment, ObjectNew: unchanged}) {
+			t.Errorf("unchanged deployment of web store %s is reconciled", component.Name)
+		}
+
+		drifted := unchanged.DeepCopy()
+		replicas := *drifted.Spec.Replicas + 1
+		drifted.Spec.Replicas = &replicas
+
+		if !predicates.Update(event.UpdateEvent{ObjectOld: deployment, ObjectNew: drifted}) {
+			t.Errorf("drifted deployment of web store %s is not reconciled", component.Name)
+		}
+	}
+}
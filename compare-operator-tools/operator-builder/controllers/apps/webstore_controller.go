@@ -19,13 +19,17 @@ package apps
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -37,28 +41,86 @@ import (
 	"github.com/scottd018/demos/internal/controllers/utils"
 	"github.com/scottd018/demos/internal/dependencies"
 	"github.com/scottd018/demos/internal/mutate"
+	"github.com/scottd018/demos/internal/pipelines"
 	"github.com/scottd018/demos/internal/resources"
 	"github.com/scottd018/demos/internal/wait"
 )
 
-// WebStoreReconciler reconciles a WebStore object.
+// WebStoreReconciler reconciles WebStore objects.  The reconciler is shared between the
+// reconciles of each WebStore, which may run concurrently, so it only holds the dependencies which
+// outlive a single reconcile.  The state of a single reconcile is held by a WebStoreRequest.
 type WebStoreReconciler struct {
 	client.Client
+	Cache      client.Reader
 	Name       string
 	Log        logr.Logger
 	Scheme     *runtime.Scheme
-	Context    context.Context
 	Controller controller.Controller
-	Watches    []client.Object
-	Resources  []common.ComponentResource
-	Component  *appsv1alpha1.WebStore
+	Pipeline   *phases.Pipeline
+
+	// MaxConcurrentReconciles is the maximum number of WebStores which are reconciled at the same
+	// time.  A single WebStore is reconciled at a time when unset.
+	MaxConcurrentReconciles int
+
+	// watches are the objects which are watched by the controller.  The watchLock serializes the
+	// registration of watches, which holds the watchesLock while the watches are read and updated.
+	watches     []client.Object
+	watchesLock sync.RWMutex
+	watchLock   sync.Mutex
+
+	// desiredResources are the desired child resources of each WebStore as of its last reconcile,
+	// which the child resource events are compared against.
+	desiredResources     map[types.NamespacedName][]common.ComponentResource
+	desiredResourcesLock sync.RWMutex
+
+	// accessReviews are the results of the access reviews of the pre-flight checks, which are
+	// reused between reconciles.
+	accessReviews phases.AccessReviewCache
+}
+
+// WebStoreRequest holds the state of a single reconcile of a WebStore, which is carried through the
+// phases of the pipeline.  A request is created for each reconcile, so that concurrent reconciles
+// of different WebStores do not share any state other than the reconciler.
+type WebStoreRequest struct {
+	*WebStoreReconciler
+	Context   context.Context
+	Component *appsv1alpha1.WebStore
+	Resources []common.ComponentResource
+
+	// persistedStatus is the status of the component as it was last read from or persisted to the
+	// cluster, which the status changes of a reconcile are patched against.
+	persistedStatus *appsv1alpha1.WebStoreStatus
+}
+
+// NewRequest returns the state of a new reconcile of a component, which is expected to be as it was
+// read from the cluster.
+func (r *WebStoreReconciler) NewRequest(ctx context.Context, component *appsv1alpha1.WebStore) *WebStoreRequest {
+	request := &WebStoreRequest{
+		WebStoreReconciler: r,
+		Context:            ctx,
+		Component:          component,
+	}
+
+	if component != nil {
+		request.persistedStatus = component.Status.DeepCopy()
+	}
+
+	return request
 }
 
 // +kubebuilder:rbac:groups=apps.acme.com,resources=webstores,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.acme.com,resources=webstores/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=resourcequotas,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -70,43 +132,83 @@ type WebStoreReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.7.2/pkg/reconcile
 func (r *WebStoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	r.Context = ctx
 	log := r.Log.WithValues("webstore", req.NamespacedName)
 
-	// get and store the component
-	r.Component = &appsv1alpha1.WebStore{}
-	if err := r.Get(r.Context, req.NamespacedName, r.Component); err != nil {
+	// get the component and start a request to reconcile it
+	component := &appsv1alpha1.WebStore{}
+	if err := r.Get(ctx, req.NamespacedName, component); err != nil {
 		log.V(0).Info("unable to fetch WebStore")
 
+		if errors.IsNotFound(err) {
+			r.setDesiredResources(req.NamespacedName, nil)
+		}
+
 		return ctrl.Result{}, utils.IgnoreNotFound(err)
 	}
 
+	request := r.NewRequest(ctx, component)
+
+	// the phases accumulate their status changes in memory, which are persisted in a single write
+	// once the phases exit
+	result, err := request.executePhases(log)
+
+	r.setDesiredResources(req.NamespacedName, request.Resources)
+
+	if statusErr := utils.IgnoreNotFound(request.UpdateStatus()); statusErr != nil {
+		if err != nil {
+			return result, fmt.Errorf("failed to update status; %v; %w", statusErr, err)
+		}
+
+		return result, statusErr
+	}
+
+	return result, err
+}
+
+// executePhases executes the phases of the pipeline until a phase is not ready or fails.
+func (r *WebStoreRequest) executePhases(log logr.Logger) (ctrl.Result, error) {
 	// get and store the resources
 	if err := r.SetResources(); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// get the phases of the pipeline to execute
+	pipeline, err := r.GetPipeline()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	pipelinePhases, err := utils.Phases(r.Component, pipeline)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the completed phases may ask for the request to be requeued, e.g. to renew a certificate
+	completed := phases.DefaultReconcileResult()
+
 	// execute the phases
-	for _, phase := range utils.Phases(r.Component) {
-		r.GetLogger().V(7).Info(fmt.Sprintf("enter phase: %T", phase))
+	for _, phase := range pipelinePhases {
+		r.GetLogger().V(7).Info(fmt.Sprintf("enter phase: %s", phase.Name))
 		proceed, err := phase.Execute(r)
 		result, err := phases.HandlePhaseExit(r, phase, proceed, err)
 
 		// return only if we have an error or are told not to proceed
 		if err != nil || !proceed {
-			log.V(2).Info(fmt.Sprintf("not ready; requeuing phase: %T", phase))
+			log.V(2).Info(fmt.Sprintf("not ready; requeuing phase: %s", phase.Name))
 
 			return result, err
 		}
 
-		r.GetLogger().V(5).Info(fmt.Sprintf("completed phase: %T", phase))
+		completed = phases.EarliestResult(completed, result)
+
+		r.GetLogger().V(5).Info(fmt.Sprintf("completed phase: %s", phase.Name))
 	}
 
-	return phases.DefaultReconcileResult(), nil
+	return completed, nil
 }
 
 // Construct resources runs the methods to properly construct the resources.
-func (r *WebStoreReconciler) ConstructResources() ([]metav1.Object, error) {
+func (r *WebStoreRequest) ConstructResources() ([]metav1.Object, error) {
 
 	resourceObjects := make([]metav1.Object, len(webstore.CreateFuncs))
 
@@ -123,20 +225,23 @@ func (r *WebStoreReconciler) ConstructResources() ([]metav1.Object, error) {
 	return resourceObjects, nil
 }
 
-// GetResources will return the resources associated with the reconciler.
-func (r *WebStoreReconciler) GetResources() []common.ComponentResource {
+// GetResources will return the resources associated with the request.
+func (r *WebStoreRequest) GetResources() []common.ComponentResource {
 	return r.Resources
 }
 
-// SetResources will create and return the resources in memory.
-func (r *WebStoreReconciler) SetResources() error {
+// SetResources will create and store the resources of the request in memory.  Any previously set resources are
+// replaced, as the names of the resources may change between calls.
+func (r *WebStoreRequest) SetResources() error {
 	// create resources in memory
 	baseResources, err := r.ConstructResources()
 	if err != nil {
 		return err
 	}
 
-	// loop through the in memory resources and store them on the reconciler
+	r.Resources = nil
+
+	// loop through the in memory resources and store them on the request
 	for _, base := range baseResources {
 		// run through the mutation functions to mutate the resources
 		mutatedResources, skip, err := r.Mutate(&base)
@@ -148,7 +253,17 @@ func (r *WebStoreReconciler) SetResources() error {
 		}
 
 		for _, mutated := range mutatedResources {
-			resourceObject := resources.NewResourceFromClient(mutated.(client.Object))
+			object := mutated.(client.Object)
+
+			// unstructured objects which are built in memory may contain non-json types (e.g. int)
+			// which cannot be deep copied, so normalize them through json
+			if _, ok := object.(*unstructured.Unstructured); ok {
+				if object, err = resources.NewResourceFromClient(object).ToUnstructured(); err != nil {
+					return err
+				}
+			}
+
+			resourceObject := resources.NewResourceFromClient(object)
 			resourceObject.Reconciler = r
 
 			r.SetResource(resourceObject)
@@ -159,7 +274,7 @@ func (r *WebStoreReconciler) SetResources() error {
 }
 
 // SetResource will set a resource on the objects if the relevant object does not already exist.
-func (r *WebStoreReconciler) SetResource(new common.ComponentResource) {
+func (r *WebStoreRequest) SetResource(new common.ComponentResource) {
 
 	// set and return immediately if nothing exists
 	if len(r.Resources) == 0 {
@@ -183,7 +298,7 @@ func (r *WebStoreReconciler) SetResource(new common.ComponentResource) {
 
 // CreateOrUpdate creates a resource if it does not already exist or updates a resource
 // if it does already exist.
-func (r *WebStoreReconciler) CreateOrUpdate(
+func (r *WebStoreRequest) CreateOrUpdate(
 	resource metav1.Object,
 ) error {
 	// set ownership on the underlying resource being created or updated
@@ -196,6 +311,21 @@ func (r *WebStoreReconciler) CreateOrUpdate(
 	// create a stub object to store the current resource in the cluster so that we do not affect
 	// the desired state of the resource object in memory
 	newResource := resources.NewResourceFromClient(resource.(client.Object), r)
+
+	// record the hash of the desired state on the resource so that resources which are unchanged
+	// may skip the lookup and comparison against the cluster on subsequent reconciliations
+	hash, err := newResource.SetDesiredStateHash()
+	if err != nil {
+		return err
+	}
+
+	if r.desiredStateIsPersisted(newResource, hash) {
+		r.GetLogger().V(5).Info(fmt.Sprintf("skipping unchanged resource; kind: [%s], name: [%s], namespace: [%s]",
+			newResource.Kind, newResource.Name, newResource.Namespace))
+
+		return utils.Watch(r.WebStoreReconciler, &appsv1alpha1.WebStore{}, newResource.Object)
+	}
+
 	resourceStub := &unstructured.Unstructured{}
 	resourceStub.SetGroupVersionKind(newResource.Object.GetObjectKind().GroupVersionKind())
 	oldResource := resources.NewResourceFromClient(resourceStub, r)
@@ -214,13 +344,126 @@ func (r *WebStoreReconciler) CreateOrUpdate(
 			return err
 		}
 	} else {
+		// refuse to update a resource which is controlled by another owner, unless the adoption
+		// policy of the resource allows the web store to adopt it
+		if err := r.adopt(newResource, oldResource); err != nil {
+			return err
+		}
+
 		// update the resource
 		if err := newResource.Update(oldResource); err != nil {
 			return err
 		}
+
+		// the generation is only returned when an update was made, otherwise carry the generation
+		// forward from the existing resource so that it may be recorded on the status
+		if newResource.Object.GetGeneration() == 0 {
+			newResource.Object.SetGeneration(oldResource.Object.GetGeneration())
+		}
+	}
+
+	return utils.Watch(r.WebStoreReconciler, &appsv1alpha1.WebStore{}, newResource.Object)
+}
+
+// adopt determines whether the WebStore may control a resource which exists in the cluster, as
+// governed by the adoption policy of the resource.  A resource which is adopted is marked with the
+// adopted annotation, which is carried forward on each subsequent update.  A resource which is
+// controlled by another WebStore is never adopted.  A resource which may not be adopted fails with
+// a permanent error, as retrying can not succeed until the adoption policy or the resource changes.
+func (r *WebStoreRequest) adopt(desired, current *resources.Resource) error {
+	if err := r.checkCollision(current.Object); err != nil {
+		return err
+	}
+
+	if policy := current.AdoptedWith(); policy != "" {
+		desired.SetAdopted(policy)
+	}
+
+	owner := metav1.GetControllerOf(current.Object)
+	if owner != nil && owner.UID == r.Component.UID {
+		return nil
+	}
+
+	policy := r.Component.AdoptionPolicy(desired.Kind, desired.Name)
+
+	switch {
+	case policy == appsv1alpha1.AdoptionPolicyForce:
+	case owner == nil && policy == appsv1alpha1.AdoptionPolicyIfUnowned:
+	case owner == nil:
+		return phases.PermanentError(fmt.Errorf(
+			"unable to adopt resource; kind: [%s], name: [%s], namespace: [%s] is not controlled by WebStore [%s] "+
+				"and the adoption policy is [%s]",
+			desired.Kind, desired.Name, desired.Namespace, r.Component.Name, policy,
+		))
+	default:
+		return phases.PermanentError(fmt.Errorf(
+			"unable to adopt resource; kind: [%s], name: [%s], namespace: [%s] is controlled by %s [%s] "+
+				"and the adoption policy is [%s]",
+			desired.Kind, desired.Name, desired.Namespace, owner.Kind, owner.Name, policy,
+		))
+	}
+
+	r.GetLogger().V(0).Info(fmt.Sprintf("adopting resource; kind: [%s], name: [%s], namespace: [%s], policy: [%s]",
+		desired.Kind, desired.Name, desired.Namespace, policy))
+
+	desired.SetAdopted(string(policy))
+
+	return nil
+}
+
+// checkCollision returns an error when a resource in the cluster is controlled by another WebStore.
+func (r *WebStoreRequest) checkCollision(current client.Object) error {
+	owner := metav1.GetControllerOf(current)
+	if owner == nil || owner.UID == r.Component.UID {
+		return nil
 	}
 
-	return utils.Watch(r, newResource.Object)
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil || gv.Group != appsv1alpha1.GroupVersion.Group || owner.Kind != r.Component.GetComponentGVK().Kind {
+		return nil
+	}
+
+	return phases.PermanentError(fmt.Errorf(
+		"unable to persist resource; kind: [%s], name: [%s], namespace: [%s] is already owned by WebStore [%s]; "+
+			"use the Instance naming or a unique service name for each web store in the namespace",
+		current.GetObjectKind().GroupVersionKind().Kind, current.GetName(), current.GetNamespace(), owner.Name,
+	))
+}
+
+// desiredStateIsPersisted determines if the desired state of a resource has already been persisted.
+// The metadata of the current resource is read from the cache rather than the API server, which
+// only holds the metadata of the child resources so that it stays small.
+func (r *WebStoreRequest) desiredStateIsPersisted(desired *resources.Resource, hash string) bool {
+	if r.Cache == nil {
+		return false
+	}
+
+	// resources which do not track their generation can not be considered persisted, so they are
+	// ruled out before the current resource is read from the cache
+	if !resources.DesiredStateIsRecorded(r.Component, desired, hash) {
+		return false
+	}
+
+	current := &metav1.PartialObjectMetadata{}
+	current.SetGroupVersionKind(desired.Object.GetObjectKind().GroupVersionKind())
+
+	if err := r.Cache.Get(r.Context, client.ObjectKeyFromObject(desired.Object), current); err != nil {
+		return false
+	}
+
+	if !resources.DesiredStateIsPersisted(r.Component, desired, resources.NewResourceFromClient(current), hash) {
+		return false
+	}
+
+	// carry the generation forward so that it may be recorded on the status
+	desired.Object.SetGeneration(current.GetGeneration())
+
+	// carry the adoption forward so that it may be recorded on the status
+	if policy := current.GetAnnotations()[resources.AdoptedAnnotation]; policy != "" {
+		desired.SetAdopted(policy)
+	}
+
+	return true
 }
 
 // GetLogger returns the logger from the reconciler.
@@ -238,8 +481,8 @@ func (r *WebStoreReconciler) GetScheme() *runtime.Scheme {
 	return r.Scheme
 }
 
-// GetContext returns the context from the reconciler.
-func (r *WebStoreReconciler) GetContext() context.Context {
+// GetContext returns the context of the request.
+func (r *WebStoreRequest) GetContext() context.Context {
 	return r.Context
 }
 
@@ -248,11 +491,27 @@ func (r *WebStoreReconciler) GetName() string {
 	return r.Name
 }
 
-// GetComponent returns the component the reconciler is operating against.
-func (r *WebStoreReconciler) GetComponent() common.Component {
+// GetComponent returns the component the request is operating against.
+func (r *WebStoreRequest) GetComponent() common.Component {
 	return r.Component
 }
 
+// GetPipeline returns the pipeline of phases that the reconciler runs through.  The WebStore
+// pipeline with its default configuration is used when no pipeline has been set on the reconciler.
+// The WebStore phases are registered either way, as a pipeline which has been set may reference
+// them.
+func (r *WebStoreReconciler) GetPipeline() (*phases.Pipeline, error) {
+	if err := pipelines.RegisterWebStorePhases(); err != nil {
+		return nil, err
+	}
+
+	if r.Pipeline == nil {
+		return pipelines.WebStorePipeline(pipelines.DefaultWebStoreOptions())
+	}
+
+	return r.Pipeline, nil
+}
+
 // GetController returns the controller object associated with the reconciler.
 func (r *WebStoreReconciler) GetController() controller.Controller {
 	return r.Controller
@@ -260,41 +519,124 @@ func (r *WebStoreReconciler) GetController() controller.Controller {
 
 // GetWatches returns the objects which are current being watched by the reconciler.
 func (r *WebStoreReconciler) GetWatches() []client.Object {
-	return r.Watches
+	r.watchesLock.RLock()
+	defer r.watchesLock.RUnlock()
+
+	return append([]client.Object{}, r.watches...)
 }
 
 // SetWatch appends a watch to the list of currently watched objects.
 func (r *WebStoreReconciler) SetWatch(watch client.Object) {
-	r.Watches = append(r.Watches, watch)
+	r.watchesLock.Lock()
+	defer r.watchesLock.Unlock()
+
+	r.watches = append(r.watches, watch)
 }
 
-// UpdateStatus updates the status for a component.
-func (r *WebStoreReconciler) UpdateStatus() error {
-	return r.Status().Update(r.Context, r.Component)
+// GetWatchLock returns the lock which serializes the registration of watches by concurrent
+// reconciles.
+func (r *WebStoreReconciler) GetWatchLock() sync.Locker {
+	return &r.watchLock
+}
+
+// GetDesiredResources returns the desired child resources of the WebStore which controls an
+// object, as of the last reconcile of the WebStore.
+func (r *WebStoreReconciler) GetDesiredResources(object client.Object) []common.ComponentResource {
+	owner := metav1.GetControllerOf(object)
+	if owner == nil || owner.Kind != (&appsv1alpha1.WebStore{}).GetComponentGVK().Kind {
+		return nil
+	}
+
+	r.desiredResourcesLock.RLock()
+	defer r.desiredResourcesLock.RUnlock()
+
+	return r.desiredResources[types.NamespacedName{Name: owner.Name, Namespace: object.GetNamespace()}]
+}
+
+// GetAccessReviewCache returns the results of the access reviews of the pre-flight checks, which
+// are shared between the reconciles of each WebStore.
+func (r *WebStoreReconciler) GetAccessReviewCache() *phases.AccessReviewCache {
+	return &r.accessReviews
+}
+
+// setDesiredResources records the desired child resources of a WebStore once it has been
+// reconciled.  The record is removed when there are no desired child resources.
+func (r *WebStoreReconciler) setDesiredResources(key types.NamespacedName, desired []common.ComponentResource) {
+	r.desiredResourcesLock.Lock()
+	defer r.desiredResourcesLock.Unlock()
+
+	if len(desired) == 0 {
+		delete(r.desiredResources, key)
+
+		return
+	}
+
+	if r.desiredResources == nil {
+		r.desiredResources = map[types.NamespacedName][]common.ComponentResource{}
+	}
+
+	r.desiredResources[key] = desired
+}
+
+// UpdateStatus persists the changes to the status of a component since it was last persisted with
+// a merge patch on the status subresource.  Nothing is written when the status is unchanged.  The
+// status is persisted once per reconcile, so phases only call it to persist a change before acting
+// upon it.
+func (r *WebStoreRequest) UpdateStatus() error {
+	original := r.Component.DeepCopy()
+	original.Status = appsv1alpha1.WebStoreStatus{}
+
+	// the full status is patched when the status which was last persisted is not known
+	if r.persistedStatus != nil {
+		if equality.Semantic.DeepEqual(*r.persistedStatus, r.Component.Status) {
+			return nil
+		}
+
+		original.Status = *r.persistedStatus
+	}
+
+	if err := r.Status().Patch(r.Context, r.Component, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("unable to update status; %w", err)
+	}
+
+	r.persistedStatus = r.Component.Status.DeepCopy()
+
+	return nil
 }
 
 // CheckReady will return whether a component is ready.
-func (r *WebStoreReconciler) CheckReady() (bool, error) {
+func (r *WebStoreRequest) CheckReady() (bool, error) {
 	return dependencies.WebStoreCheckReady(r)
 }
 
 // Mutate will run the mutate phase of a resource.
-func (r *WebStoreReconciler) Mutate(
+func (r *WebStoreRequest) Mutate(
 	object *metav1.Object,
 ) ([]metav1.Object, bool, error) {
 	return mutate.WebStoreMutate(r, object)
 }
 
 // Wait will run the wait phase of a resource.
-func (r *WebStoreReconciler) Wait(
+func (r *WebStoreRequest) Wait(
 	object *metav1.Object,
 ) (bool, error) {
 	return wait.WebStoreWait(r, object)
 }
 
 func (r *WebStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// fail fast if the pipeline references phases which have not been registered
+	pipeline, err := r.GetPipeline()
+	if err != nil {
+		return err
+	}
+
+	if err := pipeline.Validate(); err != nil {
+		return err
+	}
+
 	options := controller.Options{
-		RateLimiter: utils.NewDefaultRateLimiter(5*time.Microsecond, 5*time.Minute),
+		MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		RateLimiter:             utils.NewDefaultRateLimiter(5*time.Microsecond, 5*time.Minute),
 	}
 
 	baseController, err := ctrl.NewControllerManagedBy(mgr).
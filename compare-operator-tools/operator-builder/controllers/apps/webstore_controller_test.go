@@ -0,0 +1,292 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apps
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	webstoreresources "github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/resources"
+)
+
+var _ = Describe("WebStore controller", func() {
+	const (
+		timeout  = 10 * time.Second
+		interval = 250 * time.Millisecond
+
+		deploymentName = "webstore-sample-deploy"
+		ingressName    = "webstore-sample-ing"
+	)
+
+	var (
+		ctx       context.Context
+		namespace *corev1.Namespace
+		webstore  *appsv1alpha1.WebStore
+	)
+
+	// getChild waits for a child resource of the WebStore to exist and stores it in the object.
+	getChild := func(name string, object client.Object) {
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace.Name}, object)
+		}, timeout, interval).Should(Succeed())
+	}
+
+	// getPhaseCondition returns the named phase condition from the current WebStore status.
+	getPhaseCondition := func(phase string) *common.PhaseCondition {
+		current := &appsv1alpha1.WebStore{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(webstore), current)).To(Succeed())
+
+		for i := range current.Status.Conditions {
+			if current.Status.Conditions[i].Phase == phase {
+				return &current.Status.Conditions[i]
+			}
+		}
+
+		return nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		// envtest does not run the namespace controller, so each spec uses its own namespace
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "webstore-"}}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+
+		webstore = &appsv1alpha1.WebStore{
+			ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample", Namespace: namespace.Name},
+			Spec: appsv1alpha1.WebStoreSpec{
+				WebStoreReplicas: 2,
+				WebstoreImage:    "nginx:1.17",
+				ServiceName:      "webstore-svc",
+			},
+		}
+		Expect(k8sClient.Create(ctx, webstore)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		// ensure the WebStore is gone so that its children do not outlive the spec
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, webstore))).To(Succeed())
+		Eventually(func() bool {
+			return apierrs.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(webstore), &appsv1alpha1.WebStore{}))
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("creates the child resources owned by the WebStore", func() {
+		deployment := &appsv1.Deployment{}
+		getChild(deploymentName, deployment)
+
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+		Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.17"))
+		Expect(metav1.IsControlledBy(deployment, webstore)).To(BeTrue())
+
+		service := &corev1.Service{}
+		getChild("webstore-svc", service)
+
+		Expect(service.Spec.Selector).To(Equal(map[string]string{
+			webstoreresources.NameLabel:     "webstore",
+			webstoreresources.InstanceLabel: "webstore-sample",
+		}))
+		Expect(metav1.IsControlledBy(service, webstore)).To(BeTrue())
+
+		ingress := &networkingv1beta1.Ingress{}
+		getChild(ingressName, ingress)
+
+		Expect(ingress.Spec.Rules).To(HaveLen(1))
+		Expect(ingress.Spec.Rules[0].Host).To(Equal("app.acme.com"))
+		Expect(metav1.IsControlledBy(ingress, webstore)).To(BeTrue())
+	})
+
+	It("reconciles independent WebStores in separate namespaces", func() {
+		getChild(deploymentName, &appsv1.Deployment{})
+
+		// the other WebStore shares neither the name nor the child resource names
+		other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "webstore-"}}
+		Expect(k8sClient.Create(ctx, other)).To(Succeed())
+
+		otherStore := &appsv1alpha1.WebStore{
+			ObjectMeta: metav1.ObjectMeta{Name: "webstore-other", Namespace: other.Name},
+			Spec: appsv1alpha1.WebStoreSpec{
+				WebStoreReplicas: 1,
+				WebstoreImage:    "nginx:1.17",
+				ServiceName:      "webstore-svc",
+			},
+		}
+		Expect(k8sClient.Create(ctx, otherStore)).To(Succeed())
+
+		defer func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, otherStore))).To(Succeed())
+		}()
+
+		deployment := &appsv1.Deployment{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "webstore-other-deploy", Namespace: other.Name}, deployment)
+		}, timeout, interval).Should(Succeed())
+
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+		Expect(metav1.IsControlledBy(deployment, otherStore)).To(BeTrue())
+	})
+
+	It("records the desired state hash on the child resources and the status", func() {
+		deployment := &appsv1.Deployment{}
+		getChild(deploymentName, deployment)
+
+		hash := deployment.GetAnnotations()[resources.DesiredStateHashAnnotation]
+		Expect(hash).NotTo(BeEmpty())
+
+		Eventually(func() string {
+			current := &appsv1alpha1.WebStore{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(webstore), current)).To(Succeed())
+
+			for _, resource := range current.Status.Resources {
+				if resource.Kind == "Deployment" && resource.Name == deploymentName {
+					return resource.DesiredStateHash
+				}
+			}
+
+			return ""
+		}, timeout, interval).Should(Equal(hash))
+	})
+
+	It("reports the phase conditions in the status", func() {
+		Eventually(func() *common.PhaseCondition {
+			return getPhaseCondition("CreateResourcesPhase")
+		}, timeout, interval).Should(And(
+			Not(BeNil()),
+			WithTransform(func(c *common.PhaseCondition) common.PhaseState { return c.State }, Equal(common.PhaseStateComplete)),
+		))
+
+		// envtest does not run the deployment controller, so the deployment never becomes ready
+		// and the check ready phase may never complete
+		Consistently(func() common.PhaseState {
+			if condition := getPhaseCondition("CheckReadyPhase"); condition != nil {
+				return condition.State
+			}
+
+			return common.PhaseStatePending
+		}, 2*time.Second, interval).ShouldNot(Equal(common.PhaseStateComplete))
+
+		current := &appsv1alpha1.WebStore{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(webstore), current)).To(Succeed())
+		Expect(current.Status.Created).To(BeFalse())
+	})
+
+	It("runs the web store from the active slot when the rollout strategy is blue/green", func() {
+		getChild(deploymentName, &appsv1.Deployment{})
+
+		Eventually(func() error {
+			current := &appsv1alpha1.WebStore{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(webstore), current); err != nil {
+				return err
+			}
+
+			current.Spec.Rollout.Strategy = appsv1alpha1.RolloutStrategyBlueGreen
+
+			return k8sClient.Update(ctx, current)
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() int32 {
+			deployment := &appsv1.Deployment{}
+			getChild(deploymentName+"-blue", deployment)
+
+			return *deployment.Spec.Replicas
+		}, timeout, interval).Should(Equal(int32(2)))
+
+		green := &appsv1.Deployment{}
+		getChild(deploymentName+"-green", green)
+		Expect(*green.Spec.Replicas).To(Equal(int32(0)))
+
+		Eventually(func() map[string]string {
+			service := &corev1.Service{}
+			getChild("webstore-svc", service)
+
+			return service.Spec.Selector
+		}, timeout, interval).Should(HaveKeyWithValue(webstoreresources.SlotLabel, appsv1alpha1.RolloutSlotBlue))
+
+		Eventually(func() int32 {
+			deployment := &appsv1.Deployment{}
+			getChild(deploymentName, deployment)
+
+			return *deployment.Spec.Replicas
+		}, timeout, interval).Should(Equal(int32(0)))
+	})
+
+	It("updates the child resources when the spec changes", func() {
+		getChild(deploymentName, &appsv1.Deployment{})
+
+		Eventually(func() error {
+			current := &appsv1alpha1.WebStore{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(webstore), current); err != nil {
+				return err
+			}
+
+			current.Spec.WebStoreReplicas = 3
+			current.Spec.WebstoreImage = "nginx:1.21"
+
+			return k8sClient.Update(ctx, current)
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() bool {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: namespace.Name}, deployment); err != nil {
+				return false
+			}
+
+			return *deployment.Spec.Replicas == 3 &&
+				deployment.Spec.Template.Spec.Containers[0].Image == "nginx:1.21"
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("leaves the child resources for garbage collection when the WebStore is deleted", func() {
+		deployment := &appsv1.Deployment{}
+		getChild(deploymentName, deployment)
+
+		service := &corev1.Service{}
+		getChild("webstore-svc", service)
+
+		ingress := &networkingv1beta1.Ingress{}
+		getChild(ingressName, ingress)
+
+		Expect(k8sClient.Delete(ctx, webstore)).To(Succeed())
+		Eventually(func() bool {
+			return apierrs.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(webstore), &appsv1alpha1.WebStore{}))
+		}, timeout, interval).Should(BeTrue())
+
+		// envtest does not run the garbage collector, so assert that every child is owned by the
+		// deleted WebStore and would therefore be cleaned up in a real cluster
+		for _, child := range []client.Object{deployment, service, ingress} {
+			owner := metav1.GetControllerOf(child)
+			Expect(owner).NotTo(BeNil())
+			Expect(owner.UID).To(Equal(webstore.UID))
+			Expect(owner.BlockOwnerDeletion).NotTo(BeNil())
+			Expect(*owner.BlockOwnerDeletion).To(BeTrue())
+		}
+	})
+})
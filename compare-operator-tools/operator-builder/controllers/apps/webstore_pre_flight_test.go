@@ -0,0 +1,332 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apps_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// preFlightClient is a fake client which answers access reviews and provides a rest mapper.
+type preFlightClient struct {
+	client.Client
+
+	mapper  meta.RESTMapper
+	denied  map[string]bool
+	reviews int
+}
+
+// RESTMapper returns the rest mapper of the fake client.
+func (c *preFlightClient) RESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+// Create answers access reviews by denying the verb and resource pairs of the fake client.
+func (c *preFlightClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+		attributes := review.Spec.ResourceAttributes
+		c.reviews++
+		review.Status.Allowed = !c.denied[attributes.Verb+" "+attributes.Resource]
+
+		return nil
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// newPreFlightMapper returns a rest mapper which serves the apis of the WebStore child resources,
+// excluding the apis of the provided kinds.
+func newPreFlightMapper(unavailable ...string) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+
+	kinds := []struct {
+		gvk   schema.GroupVersionKind
+		scope meta.RESTScope
+	}{
+		{gvk: appsv1.SchemeGroupVersion.WithKind("Deployment"), scope: meta.RESTScopeNamespace},
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}, scope: meta.RESTScopeNamespace},
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}, scope: meta.RESTScopeNamespace},
+		{gvk: corev1.SchemeGroupVersion.WithKind("Service"), scope: meta.RESTScopeNamespace},
+		{gvk: corev1.SchemeGroupVersion.WithKind("ConfigMap"), scope: meta.RESTScopeNamespace},
+		{gvk: corev1.SchemeGroupVersion.WithKind("Namespace"), scope: meta.RESTScopeRoot},
+	}
+
+	for _, kind := range kinds {
+		served := true
+
+		for _, excluded := range unavailable {
+			if kind.gvk.Kind == excluded {
+				served = false
+			}
+		}
+
+		if served {
+			mapper.Add(kind.gvk, kind.scope)
+		}
+	}
+
+	return mapper
+}
+
+// newPreFlightReconciler returns a reconciler for a WebStore with the provided replicas whose
+// resources have been set, and which is backed by a fake client seeded with the provided objects.
+func newPreFlightReconciler(
+	t *testing.T,
+	replicas int,
+	preFlightClient *preFlightClient,
+	objects ...client.Object,
+) *appscontrollers.WebStoreRequest {
+	preFlightClient.Client = testutil.NewClient(objects...)
+
+	r := testutil.NewRequest(preFlightClient, testutil.WebStoreFixture("webstore-sample", appsv1alpha1.WebStoreSpec{
+		WebStoreReplicas: replicas,
+		WebstoreImage:    "nginx:1.17",
+		ServiceName:      "webstore-svc",
+	}))
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	return r
+}
+
+// quotaFixture returns a resource quota with the hard limit and usage for pods.
+func quotaFixture(name, hard, used string, scopes ...corev1.ResourceQuotaScope) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testutil.Namespace},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard:   corev1.ResourceList{corev1.ResourcePods: resource.MustParse(hard)},
+			Scopes: scopes,
+		},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse(hard)},
+			Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse(used)},
+		},
+	}
+}
+
+// existingDeploymentFixture returns the WebStore deployment as it exists in the cluster.
+func existingDeploymentFixture(replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample-deploy", Namespace: testutil.Namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "webstore-container", Image: "nginx:1.17"}},
+				},
+			},
+		},
+	}
+}
+
+func TestPreFlightPhase(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    int
+		client      *preFlightClient
+		existing    []client.Object
+		wantErrs    []string
+		wantProceed bool
+	}{
+		{
+			name:        "all checks pass",
+			replicas:    2,
+			client:      &preFlightClient{mapper: newPreFlightMapper()},
+			existing:    []client.Object{testutil.NamespaceFixture(testutil.Namespace)},
+			wantProceed: true,
+		},
+		{
+			name:     "api is not available",
+			replicas: 2,
+			client:   &preFlightClient{mapper: newPreFlightMapper("Ingress")},
+			existing: []client.Object{testutil.NamespaceFixture(testutil.Namespace)},
+			wantErrs: []string{"api [networking.k8s.io/v1beta1, Kind=Ingress] is not available in the cluster"},
+		},
+		{
+			name:     "access is denied",
+			replicas: 2,
+			client: &preFlightClient{
+				mapper: newPreFlightMapper(),
+				denied: map[string]bool{"create deployments": true, "patch services": true},
+			},
+			existing: []client.Object{testutil.NamespaceFixture(testutil.Namespace)},
+			wantErrs: []string{
+				"not permitted to create deployments.apps in namespace [team-a]",
+				"not permitted to patch services in namespace [team-a]",
+			},
+		},
+		{
+			name:     "namespace does not exist",
+			replicas: 2,
+			client:   &preFlightClient{mapper: newPreFlightMapper()},
+			wantErrs: []string{"namespace [team-a] does not exist"},
+		},
+		{
+			name:     "resource quota has insufficient headroom",
+			replicas: 2,
+			client:   &preFlightClient{mapper: newPreFlightMapper()},
+			existing: []client.Object{
+				testutil.NamespaceFixture(testutil.Namespace),
+				quotaFixture("pods", "3", "2"),
+			},
+			wantErrs: []string{
+				"resource quota [pods] in namespace [team-a] has insufficient pods; requires 2 more, 1 available",
+			},
+		},
+		{
+			name:     "resource quota has headroom for the additional replicas",
+			replicas: 2,
+			client:   &preFlightClient{mapper: newPreFlightMapper()},
+			existing: []client.Object{
+				testutil.NamespaceFixture(testutil.Namespace),
+				quotaFixture("pods", "3", "2"),
+				existingDeploymentFixture(1),
+			},
+			wantProceed: true,
+		},
+		{
+			name:     "scoped resource quotas are ignored",
+			replicas: 2,
+			client:   &preFlightClient{mapper: newPreFlightMapper()},
+			existing: []client.Object{
+				testutil.NamespaceFixture(testutil.Namespace),
+				quotaFixture("best-effort", "0", "0", corev1.ResourceQuotaScopeBestEffort),
+			},
+			wantProceed: true,
+		},
+		{
+			name:     "every failure is reported",
+			replicas: 2,
+			client: &preFlightClient{
+				mapper: newPreFlightMapper(),
+				denied: map[string]bool{"get ingresses": true},
+			},
+			wantErrs: []string{
+				"not permitted to get ingresses.networking.k8s.io in namespace [team-a]",
+				"namespace [team-a] does not exist",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newPreFlightReconciler(t, tt.replicas, tt.client, tt.existing...)
+
+			proceed, err := (&phases.PreFlightPhase{}).Execute(r)
+			if proceed != tt.wantProceed {
+				t.Errorf("Execute() proceed = %v, want %v", proceed, tt.wantProceed)
+			}
+
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("Execute() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("Execute() expected errors %v", tt.wantErrs)
+			}
+
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Execute() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPreFlightPhaseCustomChecks(t *testing.T) {
+	r := newPreFlightReconciler(t, 2, &preFlightClient{mapper: newPreFlightMapper()})
+
+	// only the declared checks run, so the missing namespace is not reported
+	phase := &phases.PreFlightPhase{Checks: []phases.PreFlightCheck{phases.APIAvailabilityCheck}}
+
+	if proceed, err := phase.Execute(r); !proceed || err != nil {
+		t.Errorf("Execute() = %v, %v, want true, nil", proceed, err)
+	}
+}
+
+func TestPreFlightPhaseReusesAccessReviews(t *testing.T) {
+	preFlightClient := &preFlightClient{
+		mapper: newPreFlightMapper(),
+		denied: map[string]bool{"patch services": true},
+	}
+
+	r := newPreFlightReconciler(t, 2, preFlightClient, testutil.NamespaceFixture(testutil.Namespace))
+	phase := &phases.PreFlightPhase{Checks: []phases.PreFlightCheck{phases.RBACCheck}}
+
+	// access is reviewed once for each verb and kind of child resource in the namespace
+	_, err := phase.Execute(r)
+	if err == nil || !strings.Contains(err.Error(), "not permitted to patch services") {
+		t.Fatalf("Execute() error = %v, want the denied access reported", err)
+	}
+
+	// the config map, deployment, ingress, network policy and service kinds
+	reviews := preFlightClient.reviews
+	if kinds := 5; reviews != kinds*3 {
+		t.Errorf("access reviews = %d, want %d", reviews, kinds*3)
+	}
+
+	// a subsequent reconcile of any web store reuses the results, including the denied access
+	request := r.NewRequest(r.Context, r.Component)
+	if err := request.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	_, err = phase.Execute(request)
+	if err == nil || !strings.Contains(err.Error(), "not permitted to patch services") {
+		t.Fatalf("Execute() error = %v, want the denied access reported", err)
+	}
+
+	if preFlightClient.reviews != reviews {
+		t.Errorf("access reviews = %d, want the %d reviews to be reused", preFlightClient.reviews, reviews)
+	}
+
+	// the results expire after the interval
+	r.GetAccessReviewCache().TTL = time.Nanosecond
+
+	time.Sleep(time.Millisecond)
+
+	if _, err := phase.Execute(request); err == nil {
+		t.Fatalf("Execute() expected the denied access to be reported")
+	}
+
+	if preFlightClient.reviews <= reviews {
+		t.Errorf("access reviews = %d, want the expired results to be reviewed again", preFlightClient.reviews)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apps_test
+
+import (
+	"context"
+	"testing"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// countingClient is a fake client which counts the writes to the status subresource.
+type countingClient struct {
+	*preFlightClient
+
+	statusUpdates int
+	statusPatches int
+}
+
+// Status returns a status writer which counts the writes to the status subresource.
+func (c *countingClient) Status() client.StatusWriter {
+	return &countingStatusWriter{StatusWriter: c.preFlightClient.Status(), client: c}
+}
+
+// countingStatusWriter is a status writer which counts its writes on the client.
+type countingStatusWriter struct {
+	client.StatusWriter
+
+	client *countingClient
+}
+
+func (w *countingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.client.statusUpdates++
+
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *countingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.client.statusPatches++
+
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// newCountingReconciler returns a reconciler which is backed by a counting client seeded with the
+// component and its namespace.
+func newCountingReconciler(component *appsv1alpha1.WebStore) (*appscontrollers.WebStoreReconciler, *countingClient) {
+	countingClient := &countingClient{
+		preFlightClient: &preFlightClient{
+			Client: testutil.NewClient(component, testutil.NamespaceFixture(testutil.Namespace)),
+			mapper: newPreFlightMapper(),
+		},
+	}
+
+	return testutil.NewReconciler(countingClient), countingClient
+}
+
+func TestReconcilePersistsStatusOnce(t *testing.T) {
+	component := adoptionFixture(appsv1alpha1.WebStoreAdoption{})
+	r, counter := newCountingReconciler(component)
+	request := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(component)}
+
+	// the deployments never become ready with a fake client, so each reconcile stops at the check
+	// ready phase after running each of the phases before it
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if counter.statusUpdates != 0 || counter.statusPatches != 1 {
+		t.Errorf("status writes = %d updates, %d patches, want a single patch", counter.statusUpdates, counter.statusPatches)
+	}
+
+	persisted := &appsv1alpha1.WebStore{}
+	if err := r.Get(context.Background(), request.NamespacedName, persisted); err != nil {
+		t.Fatalf("unable to get web store; %v", err)
+	}
+
+	if len(persisted.Status.Resources) == 0 {
+		t.Errorf("persisted status has no resource conditions")
+	}
+
+	var checkReady *common.PhaseCondition
+
+	for i := range persisted.Status.Conditions {
+		if persisted.Status.Conditions[i].Phase == "CheckReadyPhase" {
+			checkReady = &persisted.Status.Conditions[i]
+		}
+	}
+
+	if checkReady == nil || checkReady.State != common.PhaseStatePending {
+		t.Errorf("check ready condition = %+v, want a pending condition", checkReady)
+	}
+
+	// nothing changes on a subsequent reconcile, so the status is not written
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if counter.statusUpdates != 0 || counter.statusPatches != 1 {
+		t.Errorf("status writes = %d updates, %d patches, want no writes for an unchanged status",
+			counter.statusUpdates, counter.statusPatches)
+	}
+}
+
+func TestUpdateStatusSkipsUnchangedStatus(t *testing.T) {
+	component := adoptionFixture(appsv1alpha1.WebStoreAdoption{})
+	r, counter := newCountingReconciler(component)
+
+	key := client.ObjectKeyFromObject(component)
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	writes := counter.statusPatches
+
+	component = &appsv1alpha1.WebStore{}
+	if err := r.Get(context.Background(), key, component); err != nil {
+		t.Fatalf("unable to get web store; %v", err)
+	}
+
+	request := r.NewRequest(context.Background(), component)
+
+	if err := request.UpdateStatus(); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	if counter.statusPatches != writes {
+		t.Errorf("status patches = %d, want %d for an unchanged status", counter.statusPatches, writes)
+	}
+
+	// a change is patched against the status which was last persisted
+	request.Component.Status.Revision = 3
+
+	if err := request.UpdateStatus(); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	if counter.statusPatches != writes+1 || counter.statusUpdates != 0 {
+		t.Errorf("status writes = %d updates, %d patches, want a single additional patch",
+			counter.statusUpdates, counter.statusPatches-writes)
+	}
+
+	persisted := &appsv1alpha1.WebStore{}
+	if err := r.Get(context.Background(), key, persisted); err != nil {
+		t.Fatalf("unable to get web store; %v", err)
+	}
+
+	if persisted.Status.Revision != 3 || len(persisted.Status.Conditions) != len(request.Component.Status.Conditions) {
+		t.Errorf("persisted status = %+v, want the patched revision along with the existing conditions", persisted.Status)
+	}
+}
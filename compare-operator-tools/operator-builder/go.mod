@@ -3,12 +3,13 @@ module github.com/scottd018/demos
 go 1.15
 
 require (
-	github.com/banzaicloud/k8s-objectmatcher v1.5.2
-	github.com/banzaicloud/operator-tools v0.25.4
+	github.com/asaskevich/govalidator v0.0.0-20200428143746-21a406dcc535 // indirect
+	github.com/evanphx/json-patch v4.11.0+incompatible
 	github.com/go-logr/logr v0.4.0
-	github.com/imdario/mergo v0.3.12
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.14.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/cobra v1.1.3
 	k8s.io/api v0.21.3
 	k8s.io/apiextensions-apiserver v0.21.3
@@ -0,0 +1,251 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	"github.com/scottd018/demos/apis/common"
+)
+
+const (
+	// CASecretSuffix is the suffix of the name of the secret which holds the certificate authority of
+	// a self-signed certificate.
+	CASecretSuffix = "-ca"
+
+	// CACertKey is the key of the certificate authority in the secret of a self-signed certificate.
+	CACertKey = "ca.crt"
+
+	// CADuration is how long a generated certificate authority is valid for.
+	CADuration = 10 * 365 * 24 * time.Hour
+)
+
+// keyPair is a certificate and its private key.
+type keyPair struct {
+	certificate *x509.Certificate
+	key         crypto.Signer
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+// ensureSelfSignedCertificate ensures that the secrets of a self-signed certificate and of its
+// certificate authority exist and are not due for renewal.  The certificate authority is renewed
+// before it would expire within the lifetime of a certificate which it signs.
+func ensureSelfSignedCertificate(r common.ComponentReconciler, component *appsv1alpha1.WebStore) error {
+	spec := component.Spec.TLS
+	caName := spec.SecretName + CASecretSuffix
+
+	ca, err := getKeyPair(r, component.Namespace, caName)
+	if err != nil {
+		return err
+	}
+
+	if ca == nil || time.Until(ca.certificate.NotAfter) < spec.Duration.Duration+spec.RenewBefore.Duration {
+		if ca, err = issueKeyPair(&x509.Certificate{
+			Subject:               pkix.Name{CommonName: fmt.Sprintf("%s-%s-ca", component.Namespace, component.Name)},
+			NotAfter:              time.Now().Add(CADuration),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, nil); err != nil {
+			return err
+		}
+
+		r.GetLogger().V(0).Info(fmt.Sprintf("issued certificate authority in secret [%s]", caName))
+
+		if err := writeSecret(r, component, caName, ca, nil); err != nil {
+			return err
+		}
+	}
+
+	current, err := getKeyPair(r, component.Namespace, spec.SecretName)
+	if err != nil {
+		return err
+	}
+
+	if current != nil &&
+		current.certificate.CheckSignatureFrom(ca.certificate) == nil &&
+		current.certificate.VerifyHostname(webstore.IngressHost) == nil &&
+		time.Until(current.certificate.NotAfter) > spec.RenewBefore.Duration {
+		return nil
+	}
+
+	issued, err := issueKeyPair(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: webstore.IngressHost},
+		DNSNames:    []string{webstore.IngressHost},
+		NotAfter:    time.Now().Add(spec.Duration.Duration),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	if err != nil {
+		return err
+	}
+
+	r.GetLogger().V(0).Info(fmt.Sprintf("issued self-signed certificate in secret [%s]", spec.SecretName))
+
+	return writeSecret(r, component, spec.SecretName, issued, ca.certPEM)
+}
+
+// issueKeyPair issues a certificate from a template, which is signed by the certificate authority
+// or is self-signed when the certificate authority is nil.
+func issueKeyPair(template *x509.Certificate, ca *keyPair) (*keyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate private key; %v", err)
+	}
+
+	if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, fmt.Errorf("unable to generate serial number; %v", err)
+	}
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+
+	parent, signer := template, crypto.Signer(key)
+	if ca != nil {
+		parent, signer = ca.certificate, ca.key
+	}
+
+	content, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate; %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(content)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse certificate; %v", err)
+	}
+
+	keyContent, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal private key; %v", err)
+	}
+
+	return &keyPair{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: content}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyContent}),
+	}, nil
+}
+
+// getKeyPair returns the key pair which is held by a secret, or nil if the secret does not exist
+// or does not hold a valid key pair.
+func getKeyPair(r common.ComponentReconciler, namespace, name string) (*keyPair, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(r.GetContext(), client.ObjectKey{Name: name, Namespace: namespace}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("unable to get secret [%s]; %v", name, err)
+	}
+
+	pair, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		r.GetLogger().V(2).Info(fmt.Sprintf("replacing invalid key pair in secret [%s]; %v", name, err))
+
+		return nil, nil
+	}
+
+	certificate, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil
+	}
+
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil
+	}
+
+	return &keyPair{
+		certificate: certificate,
+		key:         key,
+		certPEM:     secret.Data[corev1.TLSCertKey],
+		keyPEM:      secret.Data[corev1.TLSPrivateKeyKey],
+	}, nil
+}
+
+// writeSecret creates or updates a TLS secret which holds a key pair, and optionally the
+// certificate of the certificate authority which signed it.
+func writeSecret(
+	r common.ComponentReconciler,
+	component *appsv1alpha1.WebStore,
+	name string,
+	pair *keyPair,
+	caPEM []byte,
+) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: component.Namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pair.certPEM,
+			corev1.TLSPrivateKeyKey: pair.keyPEM,
+		},
+	}
+
+	if caPEM != nil {
+		secret.Data[CACertKey] = caPEM
+	}
+
+	if err := ctrl.SetControllerReference(component, secret, r.GetScheme()); err != nil {
+		return fmt.Errorf("unable to set owner reference on secret [%s]; %v", name, err)
+	}
+
+	existing := &corev1.Secret{}
+	if err := r.Get(r.GetContext(), client.ObjectKeyFromObject(secret), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("unable to get secret [%s]; %v", name, err)
+		}
+
+		if err := r.Create(r.GetContext(), secret); err != nil {
+			return fmt.Errorf("unable to create secret [%s]; %v", name, err)
+		}
+
+		return nil
+	}
+
+	// a secret which is not owned by the web store is never overwritten
+	if !metav1.IsControlledBy(existing, component) {
+		return fmt.Errorf("unable to write secret [%s]; secret exists and is not owned by webstore [%s]", name, component.Name)
+	}
+
+	secret.ResourceVersion = existing.ResourceVersion
+
+	if err := r.Update(r.GetContext(), secret); err != nil {
+		return fmt.Errorf("unable to update secret [%s]; %v", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/resources"
+)
+
+// PhaseName is the name that the WebStoreCertificatePhase is registered with.
+const PhaseName = "CertificatePhase"
+
+// WebStoreCertificatePhase provisions the certificate of the web store Ingress and reports its
+// expiry.  It runs after the child resources are persisted, as cert-manager only writes the
+// certificate once the Certificate resource exists.
+type WebStoreCertificatePhase struct{}
+
+// WebStoreCertificatePhase.DefaultRequeue returns the default result to requeue the phase.
+func (phase *WebStoreCertificatePhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+// WebStoreCertificatePhase.Execute issues or rotates a self-signed certificate when requested, and
+// waits for the certificate secret to hold a certificate and key before recording its expiry.
+func (phase *WebStoreCertificatePhase) Execute(r common.ComponentReconciler) (proceedToNextPhase bool, err error) {
+	component, ok := r.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return false, fmt.Errorf("unable to provision certificate for component of type %T", r.GetComponent())
+	}
+
+	spec := component.Spec.TLS
+
+	// the status is persisted when the phase exits
+	if spec == nil {
+		component.Status.TLS = nil
+
+		return true, nil
+	}
+
+	if spec.Mode == appsv1alpha1.TLSModeSelfSigned {
+		if err := ensureSelfSignedCertificate(r, component); err != nil {
+			return false, err
+		}
+	}
+
+	secret := &resources.Resource{Reconciler: r}
+	secret.Name = spec.SecretName
+	secret.Namespace = component.Namespace
+
+	ready, err := resources.SecretIsReady(secret, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	if err != nil {
+		return false, err
+	}
+
+	if !ready {
+		r.GetLogger().V(2).Info(fmt.Sprintf("waiting for certificate in secret [%s]", spec.SecretName))
+
+		return false, nil
+	}
+
+	certificate, err := getCertificate(r, component.Namespace, spec.SecretName)
+	if err != nil {
+		return false, err
+	}
+
+	component.Status.TLS = &appsv1alpha1.WebStoreTLSStatus{
+		SecretName: spec.SecretName,
+		NotAfter:   &metav1.Time{Time: certificate.NotAfter},
+	}
+
+	return true, nil
+}
+
+// WebStoreCertificatePhase.RequeueAfter returns the interval after which a self-signed certificate
+// is due to be rotated, as nothing else triggers a reconcile when it nears its expiry.
+func (phase *WebStoreCertificatePhase) RequeueAfter(r common.ComponentReconciler) time.Duration {
+	component, ok := r.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return 0
+	}
+
+	spec, status := component.Spec.TLS, component.Status.TLS
+	if spec == nil || spec.Mode != appsv1alpha1.TLSModeSelfSigned || status == nil || status.NotAfter == nil {
+		return 0
+	}
+
+	return time.Until(status.NotAfter.Add(-spec.RenewBefore.Duration))
+}
+
+// getCertificate returns the certificate which is held by a secret.
+func getCertificate(r common.ComponentReconciler, namespace, name string) (*x509.Certificate, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(r.GetContext(), client.ObjectKey{Name: name, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("unable to get certificate secret [%s]; %v", name, err)
+	}
+
+	certificate, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse certificate in secret [%s]; %v", name, err)
+	}
+
+	return certificate, nil
+}
+
+// parseCertificate parses the first certificate of PEM encoded content.
+func parseCertificate(content []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/certificates"
+	"github.com/scottd018/demos/internal/resources"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// certificateFixture returns a WebStore which provisions its certificate with the TLS mode, or
+// which does not terminate TLS when the mode is empty.
+func certificateFixture(mode appsv1alpha1.WebStoreTLSMode) *appsv1alpha1.WebStore {
+	spec := appsv1alpha1.WebStoreSpec{}
+
+	if mode != "" {
+		spec.TLS = &appsv1alpha1.WebStoreTLS{
+			Mode:   mode,
+			Issuer: appsv1alpha1.WebStoreTLSIssuer{Name: "letsencrypt"},
+		}
+	}
+
+	return testutil.WebStoreFixture("webstore-sample", spec)
+}
+
+// secretFixture returns a TLS secret which holds a certificate for the ingress host that expires
+// at the provided time.
+func secretFixture(t *testing.T, notAfter time.Time) *corev1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key; %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: webstore.IngressHost},
+		DNSNames:     []string{webstore.IngressHost},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	content, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("unable to create certificate; %v", err)
+	}
+
+	keyContent, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unable to marshal key; %v", err)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webstore-tls", Namespace: testutil.Namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: content}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyContent}),
+		},
+	}
+}
+
+// newCertificateReconciler returns a reconciler for the component which is backed by a fake client
+// seeded with the component and the provided objects.
+func newCertificateReconciler(component *appsv1alpha1.WebStore, objects ...client.Object) *appscontrollers.WebStoreRequest {
+	return testutil.NewRequest(testutil.NewClient(append(objects, component)...), component)
+}
+
+// execute executes the certificate phase against the reconciler.
+func execute(t *testing.T, r *appscontrollers.WebStoreRequest) bool {
+	proceed, err := (&certificates.WebStoreCertificatePhase{}).Execute(r)
+	if err != nil {
+		t.Fatalf("WebStoreCertificatePhase.Execute() error = %v", err)
+	}
+
+	return proceed
+}
+
+// getSecret returns a persisted secret.
+func getSecret(t *testing.T, r *appscontrollers.WebStoreRequest, name string) *corev1.Secret {
+	secret := &corev1.Secret{}
+	if err := r.Get(r.Context, client.ObjectKey{Name: name, Namespace: testutil.Namespace}, secret); err != nil {
+		t.Fatalf("unable to get secret %s; %v", name, err)
+	}
+
+	return secret
+}
+
+func TestWebStoreCertificatePhaseWithoutTLS(t *testing.T) {
+	component := certificateFixture("")
+	component.Status.TLS = &appsv1alpha1.WebStoreTLSStatus{SecretName: "webstore-tls"}
+
+	r := newCertificateReconciler(component)
+
+	if !execute(t, r) {
+		t.Errorf("CertificatePhase did not proceed")
+	}
+
+	if r.Component.Status.TLS != nil {
+		t.Errorf("status.tls = %+v, want nil", r.Component.Status.TLS)
+	}
+}
+
+func TestWebStoreCertificatePhaseWaitsForSecret(t *testing.T) {
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		mode     appsv1alpha1.WebStoreTLSMode
+		existing []client.Object
+		want     bool
+	}{
+		{name: "existing secret which is missing", mode: appsv1alpha1.TLSModeSecret, want: false},
+		{
+			name:     "existing secret without a key",
+			mode:     appsv1alpha1.TLSModeSecret,
+			existing: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "webstore-tls", Namespace: testutil.Namespace}}},
+			want:     false,
+		},
+		{
+			name:     "existing secret with a certificate and key",
+			mode:     appsv1alpha1.TLSModeSecret,
+			existing: []client.Object{secretFixture(t, notAfter)},
+			want:     true,
+		},
+		{name: "cert-manager secret which has not been issued", mode: appsv1alpha1.TLSModeCertManager, want: false},
+		{
+			name:     "cert-manager secret which has been issued",
+			mode:     appsv1alpha1.TLSModeCertManager,
+			existing: []client.Object{secretFixture(t, notAfter)},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newCertificateReconciler(certificateFixture(tt.mode), tt.existing...)
+
+			if got := execute(t, r); got != tt.want {
+				t.Fatalf("CertificatePhase proceed = %v, want %v", got, tt.want)
+			}
+
+			if !tt.want {
+				return
+			}
+
+			status := r.Component.Status.TLS
+			if status == nil || status.SecretName != "webstore-tls" || !status.NotAfter.Time.Equal(notAfter) {
+				t.Errorf("status.tls = %+v, want webstore-tls expiring at %s", status, notAfter)
+			}
+		})
+	}
+}
+
+func TestWebStoreCertificatePhaseSelfSigned(t *testing.T) {
+	r := newCertificateReconciler(certificateFixture(appsv1alpha1.TLSModeSelfSigned))
+
+	if !execute(t, r) {
+		t.Fatalf("CertificatePhase did not proceed")
+	}
+
+	secret := getSecret(t, r, "webstore-tls")
+	if !metav1.IsControlledBy(secret, r.Component) {
+		t.Errorf("secret is not controlled by the webstore")
+	}
+
+	// the certificate is valid for the ingress host when verified against the certificate authority
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(secret.Data[certificates.CACertKey]) {
+		t.Fatalf("secret does not hold the certificate authority")
+	}
+
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unable to parse certificate; %v", err)
+	}
+
+	if _, err := certificate.Verify(x509.VerifyOptions{DNSName: webstore.IngressHost, Roots: roots}); err != nil {
+		t.Errorf("certificate does not verify; %v", err)
+	}
+
+	if status := r.Component.Status.TLS; status == nil || !status.NotAfter.Time.Equal(certificate.NotAfter) {
+		t.Errorf("status.tls = %+v, want the certificate expiry %s", status, certificate.NotAfter)
+	}
+
+	// a certificate which is not due for renewal is kept
+	execute(t, r)
+
+	if current := getSecret(t, r, "webstore-tls"); !bytes.Equal(current.Data[corev1.TLSCertKey], secret.Data[corev1.TLSCertKey]) {
+		t.Errorf("certificate was reissued before it was due for renewal")
+	}
+
+	// a certificate which is due for renewal is reissued by the same certificate authority
+	r.Component.Spec.TLS.RenewBefore = metav1.Duration{Duration: r.Component.Spec.TLS.Duration.Duration + time.Hour}
+	execute(t, r)
+
+	renewed := getSecret(t, r, "webstore-tls")
+	if bytes.Equal(renewed.Data[corev1.TLSCertKey], secret.Data[corev1.TLSCertKey]) {
+		t.Errorf("certificate was not reissued when it was due for renewal")
+	}
+
+	if !bytes.Equal(renewed.Data[certificates.CACertKey], secret.Data[certificates.CACertKey]) {
+		t.Errorf("certificate authority was reissued before it was due for renewal")
+	}
+}
+
+func TestWebStoreCertificatePhaseRequeueAfter(t *testing.T) {
+	phase := &certificates.WebStoreCertificatePhase{}
+
+	// a self-signed certificate is rotated once it is due for renewal
+	r := newCertificateReconciler(certificateFixture(appsv1alpha1.TLSModeSelfSigned))
+	execute(t, r)
+
+	spec := r.Component.Spec.TLS
+	want := spec.Duration.Duration - spec.RenewBefore.Duration
+
+	if got := phase.RequeueAfter(r); got <= want-time.Minute || got > want {
+		t.Errorf("RequeueAfter() = %s, want %s", got, want)
+	}
+
+	// a certificate which is issued by cert-manager is renewed by cert-manager
+	r = newCertificateReconciler(
+		certificateFixture(appsv1alpha1.TLSModeCertManager),
+		secretFixture(t, time.Now().Add(time.Hour)),
+	)
+	execute(t, r)
+
+	if got := phase.RequeueAfter(r); got != 0 {
+		t.Errorf("RequeueAfter() = %s, want no requeue", got)
+	}
+}
+
+func TestWebStoreCertificatePhaseSelfSignedDoesNotOverwriteSecret(t *testing.T) {
+	r := newCertificateReconciler(
+		certificateFixture(appsv1alpha1.TLSModeSelfSigned),
+		secretFixture(t, time.Now().Add(time.Hour)),
+	)
+
+	if _, err := (&certificates.WebStoreCertificatePhase{}).Execute(r); err == nil {
+		t.Errorf("WebStoreCertificatePhase.Execute() error = nil, want an error for a secret which is not owned")
+	}
+}
+
+func TestWebStoreCertificateResources(t *testing.T) {
+	tests := []struct {
+		name            string
+		mode            appsv1alpha1.WebStoreTLSMode
+		wantCertificate bool
+		wantSecret      string
+	}{
+		{name: "without tls", mode: ""},
+		{name: "self-signed", mode: appsv1alpha1.TLSModeSelfSigned, wantSecret: "webstore-tls"},
+		{name: "cert-manager", mode: appsv1alpha1.TLSModeCertManager, wantCertificate: true, wantSecret: "webstore-tls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newCertificateReconciler(certificateFixture(tt.mode))
+			if err := r.SetResources(); err != nil {
+				t.Fatalf("SetResources() error = %v", err)
+			}
+
+			var (
+				gotCertificate bool
+				gotSecret      interface{}
+			)
+
+			for _, resource := range r.GetResources() {
+				object, err := resource.(*resources.Resource).ToUnstructured()
+				if err != nil {
+					t.Fatalf("ToUnstructured() error = %v", err)
+				}
+
+				switch resource.GetKind() {
+				case webstore.CertificateKind:
+					gotCertificate = true
+				case "Ingress":
+					if tls, found := object.Object["spec"].(map[string]interface{})["tls"].([]interface{}); found {
+						gotSecret = tls[0].(map[string]interface{})["secretName"]
+					}
+				}
+			}
+
+			if gotCertificate != tt.wantCertificate {
+				t.Errorf("certificate rendered = %v, want %v", gotCertificate, tt.wantCertificate)
+			}
+
+			if (tt.wantSecret == "" && gotSecret != nil) || (tt.wantSecret != "" && gotSecret != tt.wantSecret) {
+				t.Errorf("ingress tls secret = %v, want %q", gotSecret, tt.wantSecret)
+			}
+		})
+	}
+}
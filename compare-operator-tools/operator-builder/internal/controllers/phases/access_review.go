@@ -0,0 +1,83 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+// DefaultAccessReviewTTL is the default interval for which the result of an access review is
+// reused before the access is reviewed again.
+const DefaultAccessReviewTTL = 5 * time.Minute
+
+// AccessReviewCache holds the results of the access reviews of the RBAC check, so that the access
+// to each kind of child resource in each namespace is reviewed once per interval rather than on
+// each reconcile.  The zero value is ready to use.
+type AccessReviewCache struct {
+	// TTL is the interval for which a result is reused.  The DefaultAccessReviewTTL is used when
+	// it is not set.
+	TTL time.Duration
+
+	lock    sync.Mutex
+	results map[authorizationv1.ResourceAttributes]accessReviewResult
+}
+
+// accessReviewResult is the result of an access review along with the time that it was reviewed.
+type accessReviewResult struct {
+	allowed  bool
+	reviewed time.Time
+}
+
+// accessReviewCacher is implemented by reconcilers which hold an access review cache which outlives
+// a single reconcile.
+type accessReviewCacher interface {
+	GetAccessReviewCache() *AccessReviewCache
+}
+
+// Get returns the result of an access review, or false if the access has not been reviewed within
+// the interval.
+func (c *AccessReviewCache) Get(attributes authorizationv1.ResourceAttributes) (allowed, found bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = DefaultAccessReviewTTL
+	}
+
+	result, found := c.results[attributes]
+	if !found || time.Since(result.reviewed) > ttl {
+		return false, false
+	}
+
+	return result.allowed, true
+}
+
+// Set stores the result of an access review.
+func (c *AccessReviewCache) Set(attributes authorizationv1.ResourceAttributes, allowed bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.results == nil {
+		c.results = map[authorizationv1.ResourceAttributes]accessReviewResult{}
+	}
+
+	c.results[attributes] = accessReviewResult{allowed: allowed, reviewed: time.Now()}
+}
@@ -17,19 +17,19 @@ limitations under the License.
 package phases
 
 import (
-	"time"
-
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/scottd018/demos/apis/common"
 	"github.com/scottd018/demos/internal/resources"
 )
 
-// CheckReadyPhase.DefaultRequeue executes checking for a parent components readiness status.
+// CheckReadyPhase.DefaultRequeue returns the default result to requeue the phase, which waits for
+// the resources of a component to become ready.  It is overridden by the requeue interval of the
+// phase in a pipeline.
 func (phase *CheckReadyPhase) DefaultRequeue() ctrl.Result {
 	return ctrl.Result{
 		Requeue:      true,
-		RequeueAfter: 5 * time.Second,
+		RequeueAfter: DefaultCheckReadyRequeueAfter,
 	}
 }
 
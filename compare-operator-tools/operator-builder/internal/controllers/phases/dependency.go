@@ -39,16 +39,22 @@ func (phase *DependencyPhase) Execute(r common.ComponentReconciler) (proceedToNe
 	component := r.GetComponent()
 
 	if !collectionConfigIsReady(r) {
-		return false, nil
+		return false, DependencyNotReadyError(
+			fmt.Errorf("collection of kind: [%s] is not ready", helpers.CollectionAPIKind),
+		)
 	}
 
 	// TODO: set DependenciesSatisfied field (see next TODO below)
 	if !component.GetDependencyStatus() {
 		satisfied, err := dependenciesSatisfied(r)
-		if err != nil || !satisfied {
+		if err != nil {
 			return false, err
 		}
 
+		if !satisfied {
+			return false, DependencyNotReadyError(fmt.Errorf("dependencies are not satisfied"))
+		}
+
 		// dependencies satisfied; set and update status and continue
 		// TODO: needs implemented
 	}
@@ -95,18 +101,10 @@ func dependencySatisfied(r common.ComponentReconciler, dependency common.Compone
 
 // collectionConfigIsReady determines if a component's collection is ready.
 func collectionConfigIsReady(r common.ComponentReconciler) bool {
-	// get a list of configurations from the cluster
-	collectionConfigs, err := helpers.GetCollectionConfigs(r)
-	if err != nil {
-		r.GetLogger().V(0).Info("unable to find resource of kind: [" + helpers.CollectionAPIKind + "]")
-
-		return false
-	}
-
-	// configuration is not ready if we do not have exactly one configuration
-	if len(collectionConfigs.Items) != 1 {
+	// get the collection referenced by the component from the cluster
+	if _, err := helpers.GetCollection(r); err != nil {
 		r.GetLogger().V(0).Info(
-			fmt.Sprintf("expected only 1 resource of kind: [%s]; found %v", helpers.CollectionAPIKind, len(collectionConfigs.Items)),
+			fmt.Sprintf("unable to find collection of kind: [%s]; %v", helpers.CollectionAPIKind, err),
 		)
 
 		return false
@@ -0,0 +1,125 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"errors"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Below are the classes of the errors which are returned from a phase.  The class of an error
+// determines how the request is requeued when a phase exits with the error.
+var (
+	// ErrTransient classifies an error which may succeed when the phase is retried, e.g. a timeout
+	// when calling the API server.  The request is requeued with backoff.
+	ErrTransient = errors.New("transient error")
+
+	// ErrPermanent classifies an error which can not succeed until the component or the cluster
+	// changes, e.g. a child resource which the API server rejects as invalid.  The error is reported
+	// in the degraded condition of the component and the request is requeued after a long interval.
+	ErrPermanent = errors.New("permanent error")
+
+	// ErrDependencyNotReady classifies an error which succeeds once something that the component
+	// depends upon becomes ready, e.g. a missing namespace.  The phase is pending and the request is
+	// requeued by the phase.
+	ErrDependencyNotReady = errors.New("dependency not ready")
+
+	// ErrConflict classifies an error which succeeds once it is retried against the latest version
+	// of an object, e.g. an optimistic lock conflict.  The request is requeued immediately.
+	ErrConflict = errors.New("conflict")
+)
+
+// PhaseError is an error which has been classified by one of the error classes.
+type PhaseError struct {
+	class error
+	err   error
+}
+
+// Error returns the message of the underlying error.
+func (e *PhaseError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error, so that it may be inspected with errors.Is and errors.As.
+func (e *PhaseError) Unwrap() error {
+	return e.err
+}
+
+// Is determines if the error belongs to the target error class.
+func (e *PhaseError) Is(target error) bool {
+	return e.class == target
+}
+
+// Class returns the class of the error.
+func (e *PhaseError) Class() error {
+	return e.class
+}
+
+// TransientError classifies an error as transient.
+func TransientError(err error) error {
+	return classify(ErrTransient, err)
+}
+
+// PermanentError classifies an error as permanent.
+func PermanentError(err error) error {
+	return classify(ErrPermanent, err)
+}
+
+// DependencyNotReadyError classifies an error as a dependency which is not ready.
+func DependencyNotReadyError(err error) error {
+	return classify(ErrDependencyNotReady, err)
+}
+
+// ConflictError classifies an error as a conflict.
+func ConflictError(err error) error {
+	return classify(ErrConflict, err)
+}
+
+// classify wraps an error in the error class.
+func classify(class, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &PhaseError{class: class, err: err}
+}
+
+// ErrorClass returns the class of an error.  An error which has been explicitly classified keeps its
+// class; otherwise an error from the API server is classified by its status, and any other error
+// is classified as transient.
+func ErrorClass(err error) error {
+	var phaseError *PhaseError
+	if errors.As(err, &phaseError) {
+		return phaseError.Class()
+	}
+
+	switch {
+	case apierrs.IsConflict(err):
+		return ErrConflict
+	case apierrs.IsInvalid(err), apierrs.IsBadRequest(err), apierrs.IsMethodNotSupported(err):
+		return ErrPermanent
+	default:
+		return ErrTransient
+	}
+}
+
+// IsOptimisticLockError determines if an error is a conflict, e.g. when an object has been modified
+// since it was read.
+func IsOptimisticLockError(err error) bool {
+	return err != nil && ErrorClass(err) == ErrConflict
+}
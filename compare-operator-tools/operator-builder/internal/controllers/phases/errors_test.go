@@ -0,0 +1,286 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+)
+
+// erroringPhase is a phase which fails with its error.
+type erroringPhase struct {
+	err error
+}
+
+func (*erroringPhase) DefaultRequeue() ctrl.Result {
+	return ctrl.Result{Requeue: true, RequeueAfter: phases.DefaultCheckReadyRequeueAfter}
+}
+
+func (phase *erroringPhase) Execute(common.ComponentReconciler) (bool, error) {
+	return phase.err == nil, phase.err
+}
+
+// stubReconciler is a reconciler which only provides its component and a logger, as the exit of a
+// phase only updates the conditions of the component in memory.
+type stubReconciler struct {
+	common.ComponentReconciler
+
+	component common.Component
+}
+
+func (r *stubReconciler) GetComponent() common.Component {
+	return r.component
+}
+
+func (*stubReconciler) GetLogger() logr.Logger {
+	return logr.Discard()
+}
+
+func TestErrorClass(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "unclassified error is transient",
+			err:  errors.New("connection refused"),
+			want: phases.ErrTransient,
+		},
+		{
+			name: "permanent error",
+			err:  phases.PermanentError(errors.New("invalid")),
+			want: phases.ErrPermanent,
+		},
+		{
+			name: "wrapped dependency not ready error",
+			err:  fmt.Errorf("unable to run phase; %w", phases.DependencyNotReadyError(errors.New("not ready"))),
+			want: phases.ErrDependencyNotReady,
+		},
+		{
+			name: "api conflict",
+			err:  fmt.Errorf("unable to update resource; %w", apierrs.NewConflict(deployments, "webstore", errors.New("modified"))),
+			want: phases.ErrConflict,
+		},
+		{
+			name: "api invalid",
+			err:  apierrs.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "webstore", field.ErrorList{}),
+			want: phases.ErrPermanent,
+		},
+		{
+			name: "api timeout",
+			err:  apierrs.NewServerTimeout(deployments, "patch", 1),
+			want: phases.ErrTransient,
+		},
+		{
+			name: "explicit class takes precedence over the api status",
+			err:  phases.TransientError(apierrs.NewBadRequest("webhook unavailable")),
+			want: phases.ErrTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := phases.ErrorClass(tt.err); got != tt.want {
+				t.Errorf("ErrorClass() = %v, want %v", got, tt.want)
+			}
+
+			// explicitly classified errors belong to their class
+			var phaseError *phases.PhaseError
+			if errors.As(tt.err, &phaseError) && !errors.Is(tt.err, tt.want) {
+				t.Errorf("errors.Is() = false, want the error to belong to %v", tt.want)
+			}
+
+			if phases.IsOptimisticLockError(tt.err) != (tt.want == phases.ErrConflict) {
+				t.Errorf("IsOptimisticLockError() = %v", phases.IsOptimisticLockError(tt.err))
+			}
+		})
+	}
+}
+
+func TestHandlePhaseExitErrorClass(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantState    common.PhaseState
+		wantResult   ctrl.Result
+		wantErr      bool
+		wantDegraded bool
+	}{
+		{
+			name:       "transient error is returned for backoff",
+			err:        phases.TransientError(errors.New("connection refused")),
+			wantState:  common.PhaseStateFailed,
+			wantResult: ctrl.Result{},
+			wantErr:    true,
+		},
+		{
+			name:         "permanent error degrades the component and requeues after a long interval",
+			err:          phases.PermanentError(errors.New("invalid")),
+			wantState:    common.PhaseStateFailed,
+			wantResult:   ctrl.Result{Requeue: true, RequeueAfter: phases.PermanentErrorRequeueAfter},
+			wantDegraded: true,
+		},
+		{
+			name:       "dependency not ready error requeues with the phase",
+			err:        phases.DependencyNotReadyError(errors.New("namespace does not exist")),
+			wantState:  common.PhaseStatePending,
+			wantResult: ctrl.Result{Requeue: true, RequeueAfter: phases.DefaultCheckReadyRequeueAfter},
+		},
+		{
+			name:       "conflict requeues immediately",
+			err:        phases.ConflictError(errors.New("modified")),
+			wantState:  common.PhaseStatePending,
+			wantResult: ctrl.Result{Requeue: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component := &appsv1alpha1.WebStore{}
+			r := &stubReconciler{component: component}
+			phase := &phases.PipelinePhase{Phase: &erroringPhase{err: tt.err}, Name: "ErroringPhase"}
+
+			result, err := phases.HandlePhaseExit(r, phase, false, tt.err)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HandlePhaseExit() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if result != tt.wantResult {
+				t.Errorf("HandlePhaseExit() result = %+v, want %+v", result, tt.wantResult)
+			}
+
+			conditions := component.GetPhaseConditions()
+			if len(conditions) != 1 || conditions[0].State != tt.wantState {
+				t.Errorf("conditions = %+v, want a single condition in state %s", conditions, tt.wantState)
+			}
+
+			degraded := component.GetDegradedCondition()
+			if (degraded != nil) != tt.wantDegraded {
+				t.Fatalf("degraded condition = %+v, want degraded %v", degraded, tt.wantDegraded)
+			}
+
+			if !tt.wantDegraded {
+				return
+			}
+
+			if degraded.Phase != "ErroringPhase" || degraded.Message != tt.err.Error() {
+				t.Errorf("degraded condition = %+v, want the permanent error of the phase", degraded)
+			}
+
+			// the degraded condition is cleared once the phase succeeds
+			if _, err := phases.HandlePhaseExit(r, phase, true, nil); err != nil {
+				t.Fatalf("HandlePhaseExit() error = %v", err)
+			}
+
+			if degraded := component.GetDegradedCondition(); degraded != nil {
+				t.Errorf("degraded condition = %+v, want it to be cleared", degraded)
+			}
+		})
+	}
+}
+
+// requeueingPhase is a phase which asks to run again after its interval once it completes.
+type requeueingPhase struct {
+	erroringPhase
+
+	requeueAfter time.Duration
+}
+
+func (phase *requeueingPhase) RequeueAfter(common.ComponentReconciler) time.Duration {
+	return phase.requeueAfter
+}
+
+func TestHandlePhaseExitRequeuePhase(t *testing.T) {
+	tests := []struct {
+		name         string
+		requeueAfter time.Duration
+		want         ctrl.Result
+	}{
+		{
+			name:         "completed phase is requeued after its interval",
+			requeueAfter: time.Hour,
+			want:         ctrl.Result{Requeue: true, RequeueAfter: time.Hour},
+		},
+		{
+			name: "completed phase without an interval is not requeued",
+			want: ctrl.Result{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &stubReconciler{component: &appsv1alpha1.WebStore{}}
+			phase := &phases.PipelinePhase{Phase: &requeueingPhase{requeueAfter: tt.requeueAfter}, Name: "RequeueingPhase"}
+
+			result, err := phases.HandlePhaseExit(r, phase, true, nil)
+			if err != nil {
+				t.Fatalf("HandlePhaseExit() error = %v", err)
+			}
+
+			if result != tt.want {
+				t.Errorf("HandlePhaseExit() result = %+v, want %+v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestEarliestResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []ctrl.Result
+		want    ctrl.Result
+	}{
+		{
+			name:    "results which do not requeue are not requeued",
+			results: []ctrl.Result{{}, {}},
+			want:    ctrl.Result{},
+		},
+		{
+			name:    "the earliest interval is requeued",
+			results: []ctrl.Result{{}, {Requeue: true, RequeueAfter: time.Hour}, {Requeue: true, RequeueAfter: time.Minute}},
+			want:    ctrl.Result{Requeue: true, RequeueAfter: time.Minute},
+		},
+		{
+			name:    "an immediate requeue is the earliest",
+			results: []ctrl.Result{{Requeue: true, RequeueAfter: time.Minute}, {Requeue: true}},
+			want:    ctrl.Result{Requeue: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := phases.EarliestResult(tt.results...); got != tt.want {
+				t.Errorf("EarliestResult() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -18,53 +18,63 @@ package phases
 
 import (
 	"fmt"
-	"strings"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/scottd018/demos/apis/common"
 )
 
-const optimisticLockErrorMsg = "the object has been modified; please apply your changes to the latest version and try again"
-
 // Requeue will return the default result to requeue a reconciler request when needed.
 func Requeue() ctrl.Result {
 	return ctrl.Result{Requeue: true}
 }
 
-// IsOptimisticLockError checks to see if the error is a locking error.
-func IsOptimisticLockError(err error) bool {
-	return strings.Contains(err.Error(), optimisticLockErrorMsg)
-}
-
 // DefaultReconcileResult will return the default reconcile result when requeuing is not needed.
 func DefaultReconcileResult() ctrl.Result {
 	return ctrl.Result{}
 }
 
-// updatePhaseConditions updates the status.conditions field of the parent custom resource.
+// EarliestResult returns the result which requeues a request the soonest, so that the results of
+// the completed phases of a pipeline may be combined.  The default reconcile result is returned
+// when none of the results requeue the request.
+func EarliestResult(results ...ctrl.Result) ctrl.Result {
+	earliest := DefaultReconcileResult()
+
+	for _, result := range results {
+		switch {
+		case result.IsZero():
+			continue
+		case earliest.IsZero(), result.RequeueAfter < earliest.RequeueAfter:
+			earliest = result
+		}
+	}
+
+	return earliest
+}
+
+// updatePhaseConditions updates the status.conditions field of the parent custom resource.  The
+// change is accumulated in memory and persisted once the reconcile exits.
 func updatePhaseConditions(
 	r common.ComponentReconciler,
 	condition *common.PhaseCondition,
-) error {
+) {
 	r.GetComponent().SetPhaseCondition(*condition)
-
-	return r.UpdateStatus()
 }
 
-// updateResourceConditions updates the status.resourceConditions field of the parent custom resource.
+// updateResourceConditions updates the status.resourceConditions field of the parent custom
+// resource.  The change is accumulated in memory and persisted once the reconcile exits.
 func updateResourceConditions(
 	r common.ComponentReconciler,
 	resource common.Resource,
 	condition *common.ResourceCondition,
-) error {
+) {
 	resource.ResourceCondition = *condition
 	r.GetComponent().SetResource(resource)
-
-	return r.UpdateStatus()
 }
 
-// HandlePhaseExit will perform the steps required to exit a phase.
+// HandlePhaseExit will perform the steps required to exit a phase.  The class of a phase error
+// determines the condition of the phase and how the request is requeued.  The condition is only
+// set in memory, as the status of the component is persisted once the reconcile exits.
 func HandlePhaseExit(
 	reconciler common.ComponentReconciler,
 	phase Phase,
@@ -77,36 +87,64 @@ func HandlePhaseExit(
 
 	switch {
 	case phaseError != nil:
-		if IsOptimisticLockError(phaseError) {
-			phaseError = nil
-			condition = GetSuccessCondition(phase)
-		} else {
-			condition = GetFailCondition(phase, phaseError)
-		}
-		result = DefaultReconcileResult()
+		condition, result, phaseError = handlePhaseError(reconciler, phase, phaseError)
 	case !phaseIsReady:
 		condition = GetPendingCondition(phase)
 		result = phase.DefaultRequeue()
 	default:
 		condition = GetSuccessCondition(phase)
-		result = DefaultReconcileResult()
-	}
+		result = getCompletedResult(reconciler, phase)
 
-	// update the status conditions and return any errors
-	if updateError := updatePhaseConditions(reconciler, &condition); updateError != nil {
-		// adjust the message if we had both an update error and a phase error
-		if !IsOptimisticLockError(updateError) {
-			if phaseError != nil {
-				phaseError = fmt.Errorf("failed to update status conditions; %v; %v", updateError, phaseError)
-			} else {
-				phaseError = updateError
-			}
+		if message := getPhaseMessage(reconciler, phase); message != "" {
+			condition.Message = message
+		}
+
+		// the permanent error of the phase has been resolved
+		if degraded := reconciler.GetComponent().GetDegradedCondition(); degraded != nil && degraded.Phase == condition.Phase {
+			reconciler.GetComponent().SetDegradedCondition(nil)
 		}
 	}
 
+	updatePhaseConditions(reconciler, &condition)
+
 	return result, phaseError
 }
 
+// handlePhaseError returns the condition of a phase which exited with an error, along with the
+// result and error that the request is requeued with, as determined by the class of the error.
+func handlePhaseError(
+	reconciler common.ComponentReconciler,
+	phase Phase,
+	phaseError error,
+) (common.PhaseCondition, ctrl.Result, error) {
+	switch ErrorClass(phaseError) {
+	case ErrConflict:
+		// retry immediately against the latest version of the object
+		return GetPendingCondition(phase), Requeue(), nil
+	case ErrDependencyNotReady:
+		condition := GetPendingCondition(phase)
+		condition.Message = fmt.Sprintf("%s; %v", condition.Message, phaseError)
+
+		return condition, phase.DefaultRequeue(), nil
+	case ErrPermanent:
+		// retrying can not succeed until the error is resolved, so the error is reported rather than
+		// returned to avoid requeuing with backoff; the request is requeued after a long interval as
+		// the error may be resolved outside of the component, which does not trigger a new request
+		condition := GetFailCondition(phase, phaseError)
+
+		reconciler.GetLogger().V(0).Info(fmt.Sprintf("phase failed with permanent error; phase: [%s]; %v", condition.Phase, phaseError))
+		reconciler.GetComponent().SetDegradedCondition(&common.DegradedCondition{
+			Phase:   condition.Phase,
+			Message: phaseError.Error(),
+		})
+
+		return condition, ctrl.Result{Requeue: true, RequeueAfter: PermanentErrorRequeueAfter}, nil
+	default:
+		// the error is returned so that the request is requeued with backoff
+		return GetFailCondition(phase, phaseError), DefaultReconcileResult(), phaseError
+	}
+}
+
 // handleResourcePhaseExit will perform the steps required to exit a phase.
 func handleResourcePhaseExit(
 	reconciler common.ComponentReconciler,
@@ -119,26 +157,14 @@ func handleResourcePhaseExit(
 
 	switch {
 	case phaseError != nil:
-		if IsOptimisticLockError(phaseError) {
-			phaseError = nil
-		}
+		// report the error on the resource, e.g. when the resource may not be adopted; the error is
+		// returned so that the request is requeued as determined by its class
+		condition.Message = phaseError.Error()
 	case !phaseIsReady:
 		condition.Message = fmt.Sprintf("unable to proceed with resource creation; phase %v is not ready", getResourcePhaseName(phase))
 	}
 
-	// update the status conditions and return any errors
-	if updateError := updateResourceConditions(reconciler, resource, &condition); updateError != nil {
-		// adjust the message if we had both an update error and a phase error
-		if !IsOptimisticLockError(updateError) {
-			if phaseError != nil {
-				phaseError = fmt.Errorf("failed to update resource conditions; %v; %v", updateError, phaseError)
-			} else {
-				phaseError = updateError
-			}
-		}
-	} else {
-		condition.Message = "resource creation successful"
-	}
+	updateResourceConditions(reconciler, resource, &condition)
 
 	return (phaseError == nil && phaseIsReady), phaseError
 }
@@ -0,0 +1,137 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"fmt"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// DefaultCheckReadyRequeueAfter is the default interval after which a request is requeued while
+// waiting for the resources of a component to become ready.
+const DefaultCheckReadyRequeueAfter = 5 * time.Second
+
+// PermanentErrorRequeueAfter is the interval after which a request is requeued when a phase fails
+// with a permanent error.  The error may be resolved outside of the component, e.g. by removing an
+// object which may not be adopted, which does not trigger a new request.
+const PermanentErrorRequeueAfter = 5 * time.Minute
+
+// PhaseDefinition defines a phase within a pipeline by the name that it has been registered with.
+type PhaseDefinition struct {
+	// Name is the name that the phase has been registered with.
+	Name string
+
+	// RequeueAfter is the interval after which a request is requeued when the phase is not ready.
+	// The default requeue result of the phase is used when it is not set.
+	RequeueAfter time.Duration
+}
+
+// Pipeline defines the phases that a component runs through and the order in which they run
+// during the reconcile process.
+type Pipeline struct {
+	// Create defines the phases which run until the component has been successfully reconciled.
+	Create []PhaseDefinition
+
+	// Update defines the phases which run once the component has been successfully reconciled.
+	// The create phases are used when it is not set.
+	Update []PhaseDefinition
+}
+
+// PipelinePhase is a registered phase along with the configuration from its phase definition.
+type PipelinePhase struct {
+	Phase
+
+	Name         string
+	RequeueAfter time.Duration
+}
+
+// DefaultPipeline returns the pipeline which is used when a component does not declare its own.
+func DefaultPipeline() *Pipeline {
+	return &Pipeline{
+		Create: []PhaseDefinition{
+			{Name: "DependencyPhase"},
+			{Name: "PreFlightPhase"},
+			{Name: "CreateResourcesPhase"},
+			{Name: "CheckReadyPhase", RequeueAfter: DefaultCheckReadyRequeueAfter},
+			{Name: "CompletePhase"},
+		},
+	}
+}
+
+// Validate ensures that every phase of a pipeline has been registered.
+func (pipeline *Pipeline) Validate() error {
+	if len(pipeline.Create) == 0 {
+		return fmt.Errorf("invalid pipeline; at least one create phase is required")
+	}
+
+	for _, definitions := range [][]PhaseDefinition{pipeline.Create, pipeline.Update} {
+		if _, err := resolvePhases(definitions); err != nil {
+			return fmt.Errorf("invalid pipeline; %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CreatePhases returns the phases which run until the component has been successfully reconciled.
+func (pipeline *Pipeline) CreatePhases() ([]*PipelinePhase, error) {
+	return resolvePhases(pipeline.Create)
+}
+
+// UpdatePhases returns the phases which run once the component has been successfully reconciled.
+func (pipeline *Pipeline) UpdatePhases() ([]*PipelinePhase, error) {
+	if len(pipeline.Update) == 0 {
+		return pipeline.CreatePhases()
+	}
+
+	return resolvePhases(pipeline.Update)
+}
+
+// DefaultRequeue returns the requeue result using the configured requeue interval of the phase,
+// falling back to the default requeue result of the underlying phase.
+func (phase *PipelinePhase) DefaultRequeue() ctrl.Result {
+	if phase.RequeueAfter > 0 {
+		return ctrl.Result{
+			Requeue:      true,
+			RequeueAfter: phase.RequeueAfter,
+		}
+	}
+
+	return phase.Phase.DefaultRequeue()
+}
+
+// resolvePhases looks up the registered phase for each phase definition.
+func resolvePhases(definitions []PhaseDefinition) ([]*PipelinePhase, error) {
+	phases := make([]*PipelinePhase, len(definitions))
+
+	for i, definition := range definitions {
+		phase, err := GetPhase(definition.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		phases[i] = &PipelinePhase{
+			Phase:        phase,
+			Name:         definition.Name,
+			RequeueAfter: definition.RequeueAfter,
+		}
+	}
+
+	return phases, nil
+}
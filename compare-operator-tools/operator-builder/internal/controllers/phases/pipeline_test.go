@@ -0,0 +1,204 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases_test
+
+import (
+	"testing"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+)
+
+// customPhase is a phase which is registered by name for use in a pipeline.
+type customPhase struct{}
+
+func (*customPhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+func (*customPhase) Execute(common.ComponentReconciler) (bool, error) {
+	return true, nil
+}
+
+func init() {
+	if err := phases.RegisterPhase("CustomPhase", &customPhase{}); err != nil {
+		panic(err)
+	}
+}
+
+// phaseNames returns the names of the phases of a pipeline.
+func phaseNames(pipelinePhases []*phases.PipelinePhase) []string {
+	names := make([]string, len(pipelinePhases))
+	for i := range pipelinePhases {
+		names[i] = pipelinePhases[i].Name
+	}
+
+	return names
+}
+
+func TestRegisterPhase(t *testing.T) {
+	if err := phases.RegisterPhase("CustomPhase", &customPhase{}); err == nil {
+		t.Errorf("RegisterPhase() expected an error when registering a duplicate name")
+	}
+
+	if err := phases.RegisterPhase("CheckReadyPhase", &customPhase{}); err == nil {
+		t.Errorf("RegisterPhase() expected an error when replacing a built-in phase")
+	}
+
+	if err := phases.RegisterPhase("", &customPhase{}); err == nil {
+		t.Errorf("RegisterPhase() expected an error when registering an empty name")
+	}
+
+	if err := phases.RegisterPhase("NilPhase", nil); err == nil {
+		t.Errorf("RegisterPhase() expected an error when registering a nil phase")
+	}
+}
+
+func TestPipelineValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline *phases.Pipeline
+		wantErr  bool
+	}{
+		{
+			name:     "default pipeline",
+			pipeline: phases.DefaultPipeline(),
+		},
+		{
+			name: "custom phase",
+			pipeline: &phases.Pipeline{
+				Create: []phases.PhaseDefinition{{Name: "CustomPhase"}, {Name: "CompletePhase"}},
+			},
+		},
+		{
+			name:     "no create phases",
+			pipeline: &phases.Pipeline{},
+			wantErr:  true,
+		},
+		{
+			name: "unregistered create phase",
+			pipeline: &phases.Pipeline{
+				Create: []phases.PhaseDefinition{{Name: "MissingPhase"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unregistered update phase",
+			pipeline: &phases.Pipeline{
+				Create: []phases.PhaseDefinition{{Name: "CompletePhase"}},
+				Update: []phases.PhaseDefinition{{Name: "MissingPhase"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.pipeline.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPipelinePhases(t *testing.T) {
+	pipeline := &phases.Pipeline{
+		Create: []phases.PhaseDefinition{
+			{Name: "DependencyPhase"},
+			{Name: "CustomPhase"},
+			{Name: "CompletePhase"},
+		},
+		Update: []phases.PhaseDefinition{
+			{Name: "CustomPhase"},
+			{Name: "CompletePhase"},
+		},
+	}
+
+	createPhases, err := pipeline.CreatePhases()
+	if err != nil {
+		t.Fatalf("CreatePhases() error = %v", err)
+	}
+
+	if got := phaseNames(createPhases); len(got) != 3 || got[0] != "DependencyPhase" || got[1] != "CustomPhase" {
+		t.Errorf("CreatePhases() = %v", got)
+	}
+
+	if _, ok := createPhases[1].Phase.(*customPhase); !ok {
+		t.Errorf("CreatePhases() resolved %T, want *customPhase", createPhases[1].Phase)
+	}
+
+	updatePhases, err := pipeline.UpdatePhases()
+	if err != nil {
+		t.Fatalf("UpdatePhases() error = %v", err)
+	}
+
+	if got := phaseNames(updatePhases); len(got) != 2 || got[0] != "CustomPhase" {
+		t.Errorf("UpdatePhases() = %v", got)
+	}
+
+	// the create phases are used when no update phases are declared
+	pipeline.Update = nil
+
+	updatePhases, err = pipeline.UpdatePhases()
+	if err != nil {
+		t.Fatalf("UpdatePhases() error = %v", err)
+	}
+
+	if got := phaseNames(updatePhases); len(got) != 3 {
+		t.Errorf("UpdatePhases() = %v, want the create phases", got)
+	}
+}
+
+func TestPipelinePhaseRequeue(t *testing.T) {
+	pipeline := &phases.Pipeline{
+		Create: []phases.PhaseDefinition{
+			{Name: "CheckReadyPhase", RequeueAfter: 30 * time.Second},
+			{Name: "CustomPhase"},
+		},
+	}
+
+	pipelinePhases, err := pipeline.CreatePhases()
+	if err != nil {
+		t.Fatalf("CreatePhases() error = %v", err)
+	}
+
+	if got := pipelinePhases[0].DefaultRequeue(); !got.Requeue || got.RequeueAfter != 30*time.Second {
+		t.Errorf("DefaultRequeue() = %+v, want the configured interval", got)
+	}
+
+	if got := pipelinePhases[1].DefaultRequeue(); got != phases.Requeue() {
+		t.Errorf("DefaultRequeue() = %+v, want the default requeue of the phase", got)
+	}
+
+	unconfigured, err := (&phases.Pipeline{Create: []phases.PhaseDefinition{{Name: "CheckReadyPhase"}}}).CreatePhases()
+	if err != nil {
+		t.Fatalf("CreatePhases() error = %v", err)
+	}
+
+	// the check ready phase waits between checks when the pipeline does not configure an interval
+	if got := unconfigured[0].DefaultRequeue(); got.RequeueAfter != phases.DefaultCheckReadyRequeueAfter {
+		t.Errorf("DefaultRequeue() = %+v, want the default interval of the check ready phase", got)
+	}
+
+	// conditions are named by the name that the phase was registered with
+	if got := phases.GetPendingCondition(pipelinePhases[1]).Phase; got != "CustomPhase" {
+		t.Errorf("GetPendingCondition() phase = %s, want CustomPhase", got)
+	}
+}
@@ -17,11 +17,29 @@ limitations under the License.
 package phases
 
 import (
+	"fmt"
+	"strings"
+
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/scottd018/demos/apis/common"
 )
 
+// PreFlightCheck defines a check which must pass prior to attempting resource creation.  It returns
+// a message for each failure that it finds, or an error if the check itself was unable to run.
+type PreFlightCheck func(common.ComponentReconciler) (failures []string, err error)
+
+// DefaultPreFlightChecks returns the pre-flight checks which run when a PreFlightPhase does not
+// declare its own checks.
+func DefaultPreFlightChecks() []PreFlightCheck {
+	return []PreFlightCheck{
+		APIAvailabilityCheck,
+		RBACCheck,
+		NamespaceCheck,
+		ResourceQuotaCheck,
+	}
+}
+
 // PreFlightPhase.DefaultRequeue executes checking for a parent components readiness status.
 func (phase *PreFlightPhase) DefaultRequeue() ctrl.Result {
 	return Requeue()
@@ -31,5 +49,27 @@ func (phase *PreFlightPhase) DefaultRequeue() ctrl.Result {
 func (phase *PreFlightPhase) Execute(
 	r common.ComponentReconciler,
 ) (proceedToNextPhase bool, err error) {
+	checks := phase.Checks
+	if checks == nil {
+		checks = DefaultPreFlightChecks()
+	}
+
+	// run every check so that all failures are reported at once
+	var allFailures []string
+
+	for _, check := range checks {
+		failures, err := check(r)
+		if err != nil {
+			return false, fmt.Errorf("unable to run pre-flight checks; %v", err)
+		}
+
+		allFailures = append(allFailures, failures...)
+	}
+
+	if len(allFailures) > 0 {
+		// the failures are resolved by changes to the cluster, e.g. once the namespace is created
+		return false, DependencyNotReadyError(fmt.Errorf("pre-flight checks failed; %s", strings.Join(allFailures, "; ")))
+	}
+
 	return true, nil
 }
@@ -0,0 +1,471 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/resources"
+)
+
+// persistVerbs are the verbs which are required to persist a child resource.
+var persistVerbs = []string{"get", "create", "patch"}
+
+// quotaAliases are the quota resource names which are equivalent to another quota resource name.
+var quotaAliases = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourceCPU:    corev1.ResourceRequestsCPU,
+	corev1.ResourceMemory: corev1.ResourceRequestsMemory,
+}
+
+// replicatedKinds are the kinds which run a single pod when their replicas are not set.
+var replicatedKinds = map[string]bool{
+	"Deployment":  true,
+	"ReplicaSet":  true,
+	"StatefulSet": true,
+}
+
+// APIAvailabilityCheck ensures that the API for each child resource is served by the cluster.
+func APIAvailabilityCheck(r common.ComponentReconciler) ([]string, error) {
+	var failures []string
+
+	checked := map[schema.GroupVersionKind]bool{}
+
+	for _, resource := range r.GetResources() {
+		gvk := resourceGVK(resource)
+		if checked[gvk] {
+			continue
+		}
+
+		checked[gvk] = true
+
+		if _, err := resourceMapping(r, resource); err != nil {
+			if !meta.IsNoMatchError(err) {
+				return nil, err
+			}
+
+			failures = append(failures, fmt.Sprintf("api [%s] is not available in the cluster", gvk))
+		}
+	}
+
+	return failures, nil
+}
+
+// RBACCheck ensures that the controller is permitted to persist each kind of child resource in its
+// namespace.  The results are reused for an interval when the reconciler holds an access review
+// cache, as access rarely changes and each review is a request to the API server.
+func RBACCheck(r common.ComponentReconciler) ([]string, error) {
+	var failures []string
+
+	var cache *AccessReviewCache
+	if cacher, ok := r.(accessReviewCacher); ok {
+		cache = cacher.GetAccessReviewCache()
+	}
+
+	checked := map[authorizationv1.ResourceAttributes]bool{}
+
+	for _, resource := range r.GetResources() {
+		mapping, err := resourceMapping(r, resource)
+		if err != nil {
+			// unavailable apis are reported by the api availability check
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		namespace := ""
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			namespace = resource.GetNamespace()
+		}
+
+		for _, verb := range persistVerbs {
+			attributes := authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     mapping.Resource.Group,
+				Version:   mapping.Resource.Version,
+				Resource:  mapping.Resource.Resource,
+			}
+
+			if checked[attributes] {
+				continue
+			}
+
+			checked[attributes] = true
+
+			allowed, err := reviewAccess(r, cache, attributes)
+			if err != nil {
+				return nil, fmt.Errorf("unable to review access to %s; %v", mapping.Resource.GroupResource(), err)
+			}
+
+			if !allowed {
+				failures = append(failures, fmt.Sprintf("not permitted to %s %s %s",
+					verb, mapping.Resource.GroupResource(), describeScope(namespace)))
+			}
+		}
+	}
+
+	return failures, nil
+}
+
+// reviewAccess determines if the controller is permitted access to a kind of resource, reusing the
+// result of a previous review from the cache when one is provided.
+func reviewAccess(
+	r common.ComponentReconciler,
+	cache *AccessReviewCache,
+	attributes authorizationv1.ResourceAttributes,
+) (bool, error) {
+	if cache != nil {
+		if allowed, found := cache.Get(attributes); found {
+			return allowed, nil
+		}
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes.DeepCopy()},
+	}
+
+	if err := r.Create(r.GetContext(), review); err != nil {
+		return false, err
+	}
+
+	if cache != nil {
+		cache.Set(attributes, review.Status.Allowed)
+	}
+
+	return review.Status.Allowed, nil
+}
+
+// NamespaceCheck ensures that the namespace of each child resource exists, unless the namespace
+// is itself a child resource.
+func NamespaceCheck(r common.ComponentReconciler) ([]string, error) {
+	var failures []string
+
+	created := map[string]bool{}
+	required := map[string]bool{}
+
+	for _, resource := range r.GetResources() {
+		if resource.GetGroup() == "" && resource.GetKind() == "Namespace" {
+			created[resource.GetName()] = true
+		}
+
+		if resource.GetNamespace() != "" {
+			required[resource.GetNamespace()] = true
+		}
+	}
+
+	for _, name := range sortedKeys(required) {
+		if created[name] {
+			continue
+		}
+
+		namespace := &corev1.Namespace{}
+		if err := r.Get(r.GetContext(), client.ObjectKey{Name: name}, namespace); err != nil {
+			switch {
+			case apierrs.IsNotFound(err):
+				failures = append(failures, fmt.Sprintf("namespace [%s] does not exist", name))
+			case apierrs.IsForbidden(err):
+				// a controller which is restricted to its watched namespaces may not read namespaces
+				r.GetLogger().V(4).Info(fmt.Sprintf("skipping check for namespace [%s]; %v", name, err))
+			default:
+				return nil, err
+			}
+
+			continue
+		}
+
+		if namespace.Status.Phase == corev1.NamespaceTerminating {
+			failures = append(failures, fmt.Sprintf("namespace [%s] is terminating", name))
+		}
+	}
+
+	return failures, nil
+}
+
+// ResourceQuotaCheck ensures that the resource quotas of each namespace have enough headroom for
+// the additional pods which are requested by the child resources.
+func ResourceQuotaCheck(r common.ComponentReconciler) ([]string, error) {
+	var failures []string
+
+	// calculate the additional usage that each namespace requires
+	required := map[string]corev1.ResourceList{}
+	namespaces := map[string]bool{}
+
+	for _, resource := range r.GetResources() {
+		additional, err := additionalUsage(r, resource)
+		if err != nil {
+			return nil, err
+		}
+
+		if additional == nil {
+			continue
+		}
+
+		if !namespaces[resource.GetNamespace()] {
+			namespaces[resource.GetNamespace()] = true
+			required[resource.GetNamespace()] = corev1.ResourceList{}
+		}
+
+		addResourceList(required[resource.GetNamespace()], additional)
+	}
+
+	for _, namespace := range sortedKeys(namespaces) {
+		quotas := &corev1.ResourceQuotaList{}
+		if err := r.List(r.GetContext(), quotas, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("unable to list resource quotas in namespace [%s]; %v", namespace, err)
+		}
+
+		for i := range quotas.Items {
+			failures = append(failures, quotaFailures(&quotas.Items[i], required[namespace])...)
+		}
+	}
+
+	return failures, nil
+}
+
+// quotaFailures returns a failure for each resource of a quota which does not have enough headroom
+// for the required usage.
+func quotaFailures(quota *corev1.ResourceQuota, required corev1.ResourceList) []string {
+	var failures []string
+
+	// scoped quotas only apply to a subset of pods, which cannot be determined in advance
+	if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+		return nil
+	}
+
+	hard := quota.Status.Hard
+	if hard == nil {
+		hard = quota.Spec.Hard
+	}
+
+	for _, name := range sortedResourceNames(hard) {
+		requiredName := name
+		if alias, found := quotaAliases[name]; found {
+			requiredName = alias
+		}
+
+		requested, found := required[requiredName]
+		if !found || requested.Sign() <= 0 {
+			continue
+		}
+
+		available := hard[name].DeepCopy()
+		available.Sub(quota.Status.Used[name])
+
+		if requested.Cmp(available) > 0 {
+			if available.Sign() < 0 {
+				available = resource.Quantity{}
+			}
+
+			failures = append(failures, fmt.Sprintf(
+				"resource quota [%s] in namespace [%s] has insufficient %s; requires %s more, %s available",
+				quota.Name, quota.Namespace, name, requested.String(), available.String(),
+			))
+		}
+	}
+
+	return failures
+}
+
+// additionalUsage returns the usage that a child resource requires in addition to the usage of
+// its current state in the cluster.  It returns nil for resources which do not run replicated pods.
+func additionalUsage(r common.ComponentReconciler, resource common.ComponentResource) (corev1.ResourceList, error) {
+	desired, err := workloadUsage(resource.GetObject())
+	if desired == nil || err != nil {
+		return nil, err
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(resourceGVK(resource))
+
+	if err := r.Get(r.GetContext(), client.ObjectKeyFromObject(resource.GetObject()), current); err != nil {
+		if apierrs.IsNotFound(err) {
+			return desired, nil
+		}
+
+		return nil, err
+	}
+
+	currentUsage, err := workloadUsage(current)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, quantity := range currentUsage {
+		additional := desired[name].DeepCopy()
+		additional.Sub(quantity)
+		desired[name] = additional
+	}
+
+	return desired, nil
+}
+
+// workloadUsage returns the total usage of the pods of a workload.  It returns nil for resources
+// which do not run replicated pods from a pod template.
+func workloadUsage(object client.Object) (corev1.ResourceList, error) {
+	// objects which are built in memory may contain non-json types, so convert through the resource
+	converted, err := resources.NewResourceFromClient(object).ToUnstructured()
+	if err != nil {
+		return nil, err
+	}
+
+	content := converted.Object
+
+	replicas, found, err := unstructured.NestedInt64(content, "spec", "replicas")
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		if !replicatedKinds[object.GetObjectKind().GroupVersionKind().Kind] {
+			return nil, nil
+		}
+
+		replicas = 1
+	}
+
+	templateContent, found, err := unstructured.NestedMap(content, "spec", "template")
+	if !found || err != nil {
+		return nil, err
+	}
+
+	template := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(templateContent, template); err != nil {
+		return nil, err
+	}
+
+	usage := corev1.ResourceList{}
+
+	for i := int64(0); i < replicas; i++ {
+		addResourceList(usage, podUsage(&template.Spec))
+	}
+
+	return usage, nil
+}
+
+// podUsage returns the usage of a single pod as it is calculated by a resource quota.  The usage
+// of a pod is the greater of the sum of its containers and the largest of its init containers.
+func podUsage(spec *corev1.PodSpec) corev1.ResourceList {
+	usage := corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")}
+
+	for i := range spec.Containers {
+		addResourceList(usage, containerUsage(&spec.Containers[i]))
+	}
+
+	for i := range spec.InitContainers {
+		for name, quantity := range containerUsage(&spec.InitContainers[i]) {
+			if quantity.Cmp(usage[name]) > 0 {
+				usage[name] = quantity
+			}
+		}
+	}
+
+	return usage
+}
+
+// containerUsage returns the compute resources of a container as quota resource names.
+func containerUsage(container *corev1.Container) corev1.ResourceList {
+	usage := corev1.ResourceList{}
+
+	for name, quantity := range container.Resources.Requests {
+		usage[corev1.ResourceName("requests."+string(name))] = quantity.DeepCopy()
+	}
+
+	for name, quantity := range container.Resources.Limits {
+		usage[corev1.ResourceName("limits."+string(name))] = quantity.DeepCopy()
+	}
+
+	return usage
+}
+
+// addResourceList adds the quantities of one resource list to another.
+func addResourceList(total, added corev1.ResourceList) {
+	for name, quantity := range added {
+		sum := total[name].DeepCopy()
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
+
+// resourceGVK returns the GroupVersionKind of a child resource.
+func resourceGVK(resource common.ComponentResource) schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   resource.GetGroup(),
+		Version: resource.GetVersion(),
+		Kind:    resource.GetKind(),
+	}
+}
+
+// resourceMapping returns the mapping of a child resource to its api resource.
+func resourceMapping(r common.ComponentReconciler, resource common.ComponentResource) (*meta.RESTMapping, error) {
+	mapper := r.GetClient().RESTMapper()
+	if mapper == nil {
+		return nil, errors.New("unable to discover apis; client does not provide a rest mapper")
+	}
+
+	gvk := resourceGVK(resource)
+
+	return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// describeScope describes the namespace of a request for use in a message.
+func describeScope(namespace string) string {
+	if namespace == "" {
+		return "at the cluster scope"
+	}
+
+	return fmt.Sprintf("in namespace [%s]", namespace)
+}
+
+// sortedKeys returns the keys of a set in order so that messages are deterministic.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// sortedResourceNames returns the resource names of a resource list in order so that messages are
+// deterministic.
+func sortedResourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	return names
+}
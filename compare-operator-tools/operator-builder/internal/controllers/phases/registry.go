@@ -0,0 +1,73 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"fmt"
+	"sync"
+)
+
+// registry stores the phases which may be referenced by name from a pipeline.  Phases are shared
+// between reconciliations and must therefore not store any state of their own.
+var registry = struct {
+	sync.RWMutex
+	phases map[string]Phase
+}{
+	phases: map[string]Phase{
+		"DependencyPhase":      &DependencyPhase{},
+		"PreFlightPhase":       &PreFlightPhase{},
+		"CreateResourcesPhase": &CreateResourcesPhase{},
+		"CheckReadyPhase":      &CheckReadyPhase{},
+		"CompletePhase":        &CompletePhase{},
+	},
+}
+
+// RegisterPhase registers a phase by name so that it may be referenced from a pipeline.  It
+// returns an error if a phase has already been registered with the same name.
+func RegisterPhase(name string, phase Phase) error {
+	if name == "" {
+		return fmt.Errorf("unable to register phase %T; name must not be empty", phase)
+	}
+
+	if phase == nil {
+		return fmt.Errorf("unable to register phase %s; phase must not be nil", name)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, found := registry.phases[name]; found {
+		return fmt.Errorf("unable to register phase %s; a phase with the same name is already registered", name)
+	}
+
+	registry.phases[name] = phase
+
+	return nil
+}
+
+// GetPhase returns the phase which has been registered with the name.
+func GetPhase(name string) (Phase, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	phase, found := registry.phases[name]
+	if !found {
+		return nil, fmt.Errorf("unable to find phase %s; phase is not registered", name)
+	}
+
+	return phase, nil
+}
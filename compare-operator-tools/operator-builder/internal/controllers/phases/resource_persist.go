@@ -17,11 +17,12 @@ limitations under the License.
 package phases
 
 import (
-	"time"
+	"fmt"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/resources"
 )
 
 // PersistResourcePhase.Execute executes persisting resources to the Kubernetes database.
@@ -50,21 +51,28 @@ func persistResource(
 	// persist resource
 	r := resource.GetReconciler()
 	if err := r.CreateOrUpdate(resource.GetObject()); err != nil {
-		if IsOptimisticLockError(err) {
-			return nil
-		} else {
+		if !IsOptimisticLockError(err) {
 			r.GetLogger().V(0).Info(err.Error())
-
-			return err
 		}
+
+		return err
 	}
 
 	// set attributes related to the persistence of this child resource
 	condition.LastResourcePhase = getResourcePhaseName(phase)
-	condition.LastModified = time.Now().UTC().String()
 	condition.Message = "resource created successfully"
 	condition.Created = true
+	condition.DesiredStateHash = resource.GetObject().GetAnnotations()[resources.DesiredStateHashAnnotation]
+	condition.ObservedGeneration = resource.GetObject().GetGeneration()
+
+	// report the adoption of a resource which existed in the cluster before it was persisted
+	if policy := resource.GetObject().GetAnnotations()[resources.AdoptedAnnotation]; policy != "" {
+		condition.Adopted = true
+		condition.Message = fmt.Sprintf("resource adopted successfully with adoption policy [%s]", policy)
+	}
 
 	// update the condition to notify that we have created a child resource
-	return updateResourceConditions(r, *resource.ToCommonResource(), &condition)
+	updateResourceConditions(r, *resource.ToCommonResource(), &condition)
+
+	return nil
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -32,6 +33,26 @@ type Phase interface {
 	DefaultRequeue() ctrl.Result
 }
 
+// MessagePhase defines a phase which reports a message of its own in its condition when it
+// completes, e.g. to explain that part of the phase was skipped.
+type MessagePhase interface {
+	Phase
+
+	// Message returns the message of the completed phase, or an empty string to report the
+	// default message.
+	Message(common.ComponentReconciler) string
+}
+
+// RequeuePhase defines a phase which requests that the component is reconciled again some time
+// after it completes, e.g. to renew a certificate before it expires.
+type RequeuePhase interface {
+	Phase
+
+	// RequeueAfter returns the interval after which the component is reconciled again, or zero
+	// when the completed phase does not need to run again.
+	RequeueAfter(common.ComponentReconciler) time.Duration
+}
+
 // ResourcePhase defines the specific phase of reconcilication associated with creating resources.
 type ResourcePhase interface {
 	Execute(common.ComponentResource, common.ResourceCondition) (ctrl.Result, bool, error)
@@ -39,7 +60,11 @@ type ResourcePhase interface {
 
 // Below are the phase types which satisfy the Phase interface.
 type DependencyPhase struct{}
-type PreFlightPhase struct{}
+type PreFlightPhase struct {
+	// Checks are the pre-flight checks which must pass prior to attempting resource creation.
+	// The default pre-flight checks are used when it is not set.
+	Checks []PreFlightCheck
+}
 type CreateResourcesPhase struct{}
 type CheckReadyPhase struct{}
 type CompletePhase struct{}
@@ -75,7 +100,52 @@ func GetFailCondition(phase Phase, err error) common.PhaseCondition {
 	}
 }
 
+// getPhaseMessage returns the message which a phase reports when it completes, or an empty string
+// when the phase does not report a message of its own.
+func getPhaseMessage(r common.ComponentReconciler, phase Phase) string {
+	// phases from a pipeline wrap the registered phase
+	if pipelinePhase, ok := phase.(*PipelinePhase); ok {
+		phase = pipelinePhase.Phase
+	}
+
+	messagePhase, ok := phase.(MessagePhase)
+	if !ok {
+		return ""
+	}
+
+	return messagePhase.Message(r)
+}
+
+// getCompletedResult returns the result which a completed phase requeues the request with.  The
+// request is not requeued unless the phase asks to run again after an interval.
+func getCompletedResult(r common.ComponentReconciler, phase Phase) ctrl.Result {
+	// phases from a pipeline wrap the registered phase
+	if pipelinePhase, ok := phase.(*PipelinePhase); ok {
+		phase = pipelinePhase.Phase
+	}
+
+	requeuePhase, ok := phase.(RequeuePhase)
+	if !ok {
+		return DefaultReconcileResult()
+	}
+
+	requeueAfter := requeuePhase.RequeueAfter(r)
+	if requeueAfter <= 0 {
+		return DefaultReconcileResult()
+	}
+
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: requeueAfter,
+	}
+}
+
 func getPhaseName(phase Phase) string {
+	// phases from a pipeline are named by the name that they were registered with
+	if pipelinePhase, ok := phase.(*PipelinePhase); ok {
+		return pipelinePhase.Name
+	}
+
 	objectElements := strings.Split(fmt.Sprintf("%s", reflect.TypeOf(phase)), ".")
 
 	return objectElements[len(objectElements)-1]
@@ -18,11 +18,14 @@ package utils
 
 import (
 	"reflect"
+	"sync"
 
+	"github.com/go-logr/logr"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -45,39 +48,37 @@ func IgnoreNotFound(err error) error {
 	return err
 }
 
-// CreatePhases defines the phases for create and the order in which they run during the reconcile process.
-func CreatePhases() []controllerphases.Phase {
-	return []controllerphases.Phase{
-		&controllerphases.DependencyPhase{},
-		&controllerphases.PreFlightPhase{},
-		&controllerphases.CreateResourcesPhase{},
-		&controllerphases.CheckReadyPhase{},
-		&controllerphases.CompletePhase{},
+// Phases returns which phases of a pipeline to run given the component.
+func Phases(component common.Component, pipeline *controllerphases.Pipeline) ([]*controllerphases.PipelinePhase, error) {
+	if !component.GetReadyStatus() {
+		return pipeline.CreatePhases()
 	}
-}
 
-// UpdatePhases defines the phases for update and the order in which they run during the reconcile process.
-func UpdatePhases() []controllerphases.Phase {
-	// at this time create/update are identical; return the create phases
-	return CreatePhases()
+	return pipeline.UpdatePhases()
 }
 
-// Phases returns which phases to run given the component.
-func Phases(component common.Component) []controllerphases.Phase {
-	var phases []controllerphases.Phase
-	if !component.GetReadyStatus() {
-		phases = CreatePhases()
-	} else {
-		phases = UpdatePhases()
-	}
-
-	return phases
+// WatchReconciler is the reconciler which the watches of the child resources of its components are
+// registered with.  A watch outlives the request which registers it, so the watch only reads from
+// the reconciler, which is shared by each request.
+type WatchReconciler interface {
+	GetController() controller.Controller
+	GetLogger() logr.Logger
+	GetWatches() []client.Object
+	SetWatch(client.Object)
+
+	// GetWatchLock returns the lock which serializes the registration of watches, as the
+	// controller which the watches are registered with is shared by concurrent reconciles.
+	GetWatchLock() sync.Locker
+
+	// GetDesiredResources returns the desired child resources of the component which controls an
+	// object, as of the last reconcile of the component.
+	GetDesiredResources(client.Object) []common.ComponentResource
 }
 
-// getDesiredObject returns the desired object from a list stored on the
-// reconciler.
-func getDesiredObject(compared *resources.Resource) (desired *resources.Resource) {
-	for _, resource := range compared.Reconciler.GetResources() {
+// getDesiredObject returns the desired object from the desired child resources which are recorded
+// on the reconciler.
+func getDesiredObject(r WatchReconciler, compared *resources.Resource) (desired *resources.Resource) {
+	for _, resource := range r.GetDesiredResources(compared.Object) {
 		if resource.EqualGVK(compared) && resource.EqualNamespaceName(compared) {
 			return resource.(*resources.Resource)
 		}
@@ -88,7 +89,7 @@ func getDesiredObject(compared *resources.Resource) (desired *resources.Resource
 
 // needsReconciliation performs some simple checks and returns whether or not a
 // resource needs to be updated.
-func needsReconciliation(existing, requested resources.Resource) bool {
+func needsReconciliation(r WatchReconciler, existing, requested resources.Resource) bool {
 	// skip if the resources versions are the same
 	if existing.Object.GetResourceVersion() == requested.Object.GetResourceVersion() {
 		return false
@@ -104,15 +105,14 @@ func needsReconciliation(existing, requested resources.Resource) bool {
 	// get the desired object from the reconciler and ensure that we both
 	// found that desired object and that the desired object fields are equal
 	// to the existing object fields
-	desired := getDesiredObject(&requested)
+	desired := getDesiredObject(r, &requested)
 	if desired == nil {
 		return true
 	}
 
 	equal, err := resources.AreEqual(*desired, requested)
 	if err != nil {
-		requested.Reconciler.GetLogger().V(0).Error(err,
-			"unable to determine equality for reconciliation")
+		r.GetLogger().V(0).Error(err, "unable to determine equality for reconciliation")
 
 		return true
 	}
@@ -121,13 +121,15 @@ func needsReconciliation(existing, requested resources.Resource) bool {
 }
 
 // ResourcePredicates returns the filters which are used to filter out the common reconcile events
-// prior to reconciling the child resource of a component.
-func ResourcePredicates(r common.ComponentReconciler) predicate.Predicate {
+// prior to reconciling the child resource of a component.  The filters hold no state of their own,
+// so each event is compared against the desired resources which are recorded on the reconciler.
+func ResourcePredicates(r WatchReconciler) predicate.Predicate {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			return needsReconciliation(
-				*resources.NewResourceFromClient(e.ObjectOld, r),
-				*resources.NewResourceFromClient(e.ObjectNew, r),
+				r,
+				*resources.NewResourceFromClient(e.ObjectOld),
+				*resources.NewResourceFromClient(e.ObjectNew),
 			)
 		},
 		GenericFunc: func(e event.GenericEvent) bool {
@@ -160,11 +162,17 @@ func ComponentPredicates() predicate.Predicate {
 	}
 }
 
-// Watch watches a resource.
+// Watch watches a resource which is controlled by an owner of the provided type.  The watch is
+// registered once for each kind of resource.
 func Watch(
-	r common.ComponentReconciler,
+	r WatchReconciler,
+	owner runtime.Object,
 	resource client.Object,
 ) error {
+	lock := r.GetWatchLock()
+	lock.Lock()
+	defer lock.Unlock()
+
 	// check if the resource is already being watched
 	var watched bool
 
@@ -185,7 +193,7 @@ func Watch(
 			&source.Kind{Type: resource},
 			&handler.EnqueueRequestForOwner{
 				IsController: true,
-				OwnerType:    r.GetComponent().(runtime.Object),
+				OwnerType:    owner,
 			},
 			ResourcePredicates(r),
 		); err != nil {
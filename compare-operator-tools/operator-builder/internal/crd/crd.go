@@ -0,0 +1,143 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//go:generate go run gen.go
+
+// Package crd provides the custom resource definitions of the workloads, which are compiled in so
+// that offline tooling defaults and validates workloads as the API server does.
+package crd
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+)
+
+// Schema is the schema of a version of a custom resource definition, which workloads are defaulted
+// and validated against.
+type Schema struct {
+	// Validation is the schema which workloads are validated against.
+	Validation *apiextensions.CustomResourceValidation
+
+	// Structural is the structural form of the schema, which workloads are defaulted from.
+	Structural *structuralschema.Structural
+}
+
+var (
+	webStoreOnce   sync.Once
+	webStoreSchema *Schema
+	webStoreErr    error
+)
+
+// WebStore returns the custom resource definition of the WebStore kind.
+func WebStore() (*extensionsv1.CustomResourceDefinition, error) {
+	crd := &extensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal([]byte(webStoreManifest), crd); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal custom resource definition; %w", err)
+	}
+
+	return crd, nil
+}
+
+// WebStoreSchema returns the schema of the WebStore version which the workloads are served by.
+func WebStoreSchema() (*Schema, error) {
+	webStoreOnce.Do(func() {
+		crd, err := WebStore()
+		if err != nil {
+			webStoreErr = err
+
+			return
+		}
+
+		webStoreSchema, webStoreErr = NewSchema(crd, appsv1alpha1.GroupVersion.Version)
+	})
+
+	return webStoreSchema, webStoreErr
+}
+
+// Default sets the default values of the WebStore schema on the content of an unstructured
+// WebStore.  As on the API server, only fields which are absent are defaulted, so that fields which
+// are explicitly set to their zero value are kept.
+func Default(content map[string]interface{}) error {
+	schema, err := WebStoreSchema()
+	if err != nil {
+		return err
+	}
+
+	schema.Default(content)
+
+	return nil
+}
+
+// Validate validates the content of an unstructured WebStore against the WebStore schema, as the
+// API server does on admission.  Defaults are expected to have already been applied.
+func Validate(content map[string]interface{}) (field.ErrorList, error) {
+	schema, err := WebStoreSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Validate(content)
+}
+
+// NewSchema returns the schema of a version of a custom resource definition.
+func NewSchema(crd *extensionsv1.CustomResourceDefinition, version string) (*Schema, error) {
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Name != version || crd.Spec.Versions[i].Schema == nil {
+			continue
+		}
+
+		validation := &apiextensions.CustomResourceValidation{}
+		if err := extensionsv1.Convert_v1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(
+			crd.Spec.Versions[i].Schema, validation, nil,
+		); err != nil {
+			return nil, fmt.Errorf("unable to convert schema of version %s; %w", version, err)
+		}
+
+		structural, err := structuralschema.NewStructural(validation.OpenAPIV3Schema)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create structural schema of version %s; %w", version, err)
+		}
+
+		return &Schema{Validation: validation, Structural: structural}, nil
+	}
+
+	return nil, fmt.Errorf("unable to find schema of version %s", version)
+}
+
+// Default sets the default values of the schema on the content of an unstructured workload.
+func (schema *Schema) Default(content map[string]interface{}) {
+	defaulting.Default(content, schema.Structural)
+}
+
+// Validate validates the content of an unstructured workload against the schema.
+func (schema *Schema) Validate(content map[string]interface{}) (field.ErrorList, error) {
+	validator, _, err := validation.NewSchemaValidator(schema.Validation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create schema validator; %w", err)
+	}
+
+	return validation.ValidateCustomResource(nil, content, validator), nil
+}
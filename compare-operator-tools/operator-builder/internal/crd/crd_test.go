@@ -0,0 +1,127 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/scottd018/demos/internal/crd"
+)
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name string
+		spec map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "absent fields are defaulted",
+			spec: map[string]interface{}{},
+			want: map[string]interface{}{
+				"webstoreImage":    "nginx:1.17",
+				"webStoreReplicas": int64(2),
+				"adoptionPolicy":   "IfUnowned",
+				"strategy":         "RollingUpdate",
+				"canaryWeight":     int64(20),
+			},
+		},
+		{
+			name: "zero values are kept",
+			spec: map[string]interface{}{
+				"webStoreReplicas": int64(0),
+				"rollout":          map[string]interface{}{"canaryWeight": int64(0)},
+			},
+			want: map[string]interface{}{
+				"webstoreImage":    "nginx:1.17",
+				"webStoreReplicas": int64(0),
+				"adoptionPolicy":   "IfUnowned",
+				"strategy":         "RollingUpdate",
+				"canaryWeight":     int64(0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := map[string]interface{}{"spec": tt.spec}
+
+			if err := crd.Default(content); err != nil {
+				t.Fatalf("Default() error = %v", err)
+			}
+
+			got := map[string]interface{}{}
+
+			for field, path := range map[string][]string{
+				"webstoreImage":    {"spec", "webstoreImage"},
+				"webStoreReplicas": {"spec", "webStoreReplicas"},
+				"adoptionPolicy":   {"spec", "adoption", "policy"},
+				"strategy":         {"spec", "rollout", "strategy"},
+				"canaryWeight":     {"spec", "rollout", "canaryWeight"},
+			} {
+				got[field], _, _ = unstructured.NestedFieldNoCopy(content, path...)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Default() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		replicas   int64
+		wantFields []string
+	}{
+		{name: "replicas within the bounds of the schema are valid", replicas: 100},
+		{name: "replicas above the bounds of the schema are invalid", replicas: 101, wantFields: []string{"spec.webStoreReplicas"}},
+		{name: "replicas below the bounds of the schema are invalid", replicas: -1, wantFields: []string{"spec.webStoreReplicas"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := map[string]interface{}{
+				"apiVersion": "apps.acme.com/v1alpha1",
+				"kind":       "WebStore",
+				"metadata":   map[string]interface{}{"name": "webstore-sample"},
+				"spec":       map[string]interface{}{"webStoreReplicas": tt.replicas},
+			}
+
+			if err := crd.Default(content); err != nil {
+				t.Fatalf("Default() error = %v", err)
+			}
+
+			errs, err := crd.Validate(content)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			var fields []string
+			for _, fieldErr := range errs {
+				fields = append(fields, fieldErr.Field)
+			}
+
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("Validate() errors = %v, want errors for %v", errs, tt.wantFields)
+			}
+		})
+	}
+}
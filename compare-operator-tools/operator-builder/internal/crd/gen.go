@@ -0,0 +1,85 @@
+// +build ignore
+
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This program generates zz_generated.manifests.go, which holds the custom resource definitions
+// from config/crd/bases so that they are compiled into the binaries which need them.  It is run by
+// go generate from the internal/crd directory.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	headerFile = "../../hack/boilerplate.go.txt"
+	outputFile = "zz_generated.manifests.go"
+)
+
+// manifests are the custom resource definitions which are generated, keyed by the name of the
+// constant which holds them.
+var manifests = []struct {
+	name string
+	file string
+}{
+	{name: "webStoreManifest", file: "../../config/crd/bases/apps.acme.com_webstores.yaml"},
+}
+
+func main() {
+	if err := generate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate writes the custom resource definitions as constants of the crd package.
+func generate() error {
+	header, err := ioutil.ReadFile(headerFile)
+	if err != nil {
+		return fmt.Errorf("unable to read header; %v", err)
+	}
+
+	var source bytes.Buffer
+
+	source.Write(header)
+	source.WriteString("\n\n// Code generated by gen.go. DO NOT EDIT.\n\npackage crd\n")
+
+	for _, manifest := range manifests {
+		content, err := ioutil.ReadFile(manifest.file)
+		if err != nil {
+			return fmt.Errorf("unable to read custom resource definition; %v", err)
+		}
+
+		// raw string literals may not contain a backtick, so backticks are concatenated as strings
+		literal := "`" + strings.ReplaceAll(string(content), "`", "` + \"`\" + `") + "`"
+
+		fmt.Fprintf(&source, "\n// %s is the content of %s.\nconst %s = %s\n",
+			manifest.name, strings.TrimPrefix(manifest.file, "../../"), manifest.name, literal)
+	}
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return fmt.Errorf("unable to format generated source; %v", err)
+	}
+
+	return ioutil.WriteFile(outputFile, formatted, 0o644)
+}
@@ -24,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/scottd018/demos/apis/common"
@@ -57,47 +58,82 @@ func GetProperObject(destination metav1.Object, source metav1.Object) error {
 
 // getValueFromCollection gets a specific value from the WebStore resource.
 func getValueFromCollection(reconciler common.ComponentReconciler, path ...string) (string, error) {
-	// retrieve a list of platform configs
-	collectionConfigs, err := GetCollectionConfigs(reconciler)
+	// retrieve the collection referenced by the component
+	collection, err := GetCollection(reconciler)
 	if err != nil {
 		return "", err
 	}
 
-	if len(collectionConfigs.Items) > 1 {
-		return "", errors.New("Must have only one collection resource present in the cluster")
-	}
-
-	// get the first platform config
-	collectionConfig := collectionConfigs.Items[0]
-
-	// get the value from the platform config
-	collectionConfigValue, found, err := unstructured.NestedString(collectionConfig.Object, path...)
+	// get the value from the collection
+	collectionValue, found, err := unstructured.NestedString(collection.Object, path...)
 	if !found || err != nil {
-		return "", fmt.Errorf("unable to get path %s from platform configuration; %v\n", path, err)
+		return "", fmt.Errorf("unable to get path %s from collection; %v", path, err)
 	}
 
-	return collectionConfigValue, nil
+	return collectionValue, nil
 }
 
-// GetCollectionConfigs returns all of the collection resources from the cluster.
-func GetCollectionConfigs(
+// GetCollection returns the collection resource which is referenced by the component of a
+// reconciler.  A collection referenced by a label selector must match exactly one collection.
+func GetCollection(
 	r common.ComponentReconciler,
-) (unstructured.UnstructuredList, error) {
-	collectionConfigs := unstructured.UnstructuredList{}
-	collectionConfigGVK := schema.GroupVersionKind{
-		Group:   fmt.Sprintf("%s.%s", CollectionAPIGroup, Domain),
-		Version: CollectionAPIVersion,
-		Kind:    CollectionAPIKind,
+) (*unstructured.Unstructured, error) {
+	reference := r.GetComponent().GetCollectionReference()
+
+	// get the collection by name when a name is referenced
+	if reference.Name != "" {
+		collection := &unstructured.Unstructured{}
+		collection.SetGroupVersionKind(collectionGVK())
+
+		if err := r.Get(
+			r.GetContext(),
+			types.NamespacedName{Name: reference.Name, Namespace: reference.Namespace},
+			collection,
+		); err != nil {
+			return nil, fmt.Errorf("unable to get collection of kind [%s] with name [%s] in namespace [%s]; %w",
+				CollectionAPIKind, reference.Name, reference.Namespace, err)
+		}
+
+		return collection, nil
 	}
 
-	// get a list of configurations from the cluster
-	collectionConfigs.SetGroupVersionKind(collectionConfigGVK)
+	if reference.Selector == nil {
+		return nil, errors.New("collection reference requires a name or a selector")
+	}
 
-	if err := r.List(r.GetContext(), &collectionConfigs, &client.ListOptions{}); err != nil {
-		return collectionConfigs, err
+	// otherwise get the collection by label selector
+	selector, err := metav1.LabelSelectorAsSelector(reference.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collection selector; %w", err)
 	}
 
-	return collectionConfigs, nil
+	collections := &unstructured.UnstructuredList{}
+	collections.SetGroupVersionKind(collectionGVK())
+
+	if err := r.List(
+		r.GetContext(),
+		collections,
+		client.InNamespace(reference.Namespace),
+		client.MatchingLabelsSelector{Selector: selector},
+	); err != nil {
+		return nil, fmt.Errorf("unable to list collections of kind [%s]; %w", CollectionAPIKind, err)
+	}
+
+	if len(collections.Items) != 1 {
+		return nil, fmt.Errorf("expected 1 collection of kind [%s] matching selector [%s] in namespace [%s]; found %v",
+			CollectionAPIKind, selector, reference.Namespace, len(collections.Items))
+	}
+
+	return &collections.Items[0], nil
+}
+
+// collectionGVK returns the GroupVersionKind of the collection resource.
+func collectionGVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   fmt.Sprintf("%s.%s", CollectionAPIGroup, Domain),
+		Version: CollectionAPIVersion,
+		Kind:    CollectionAPIKind,
+	}
 }
 
 // GetCollectionName returns the name of the platform from the WebStore resource.
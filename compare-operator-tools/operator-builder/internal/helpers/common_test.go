@@ -0,0 +1,131 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers_test
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/helpers"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// webStoreFixture returns a WebStore with the provided name, namespace and labels.
+func webStoreFixture(name, namespace string, labels map[string]string) *appsv1alpha1.WebStore {
+	return &appsv1alpha1.WebStore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
+
+func TestGetCollection(t *testing.T) {
+	storeA := webStoreFixture("store-a", "team-a", map[string]string{"store": "a"})
+	storeB := webStoreFixture("store-b", "team-b", map[string]string{"store": "b"})
+	storeC := webStoreFixture("store-c", "team-b", map[string]string{"store": "b"})
+
+	tests := []struct {
+		name      string
+		reference common.CollectionReference
+		component *appsv1alpha1.WebStore
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "defaults to the component itself",
+			component: webStoreFixture("store-a", "team-a", nil),
+			want:      "team-a/store-a",
+		},
+		{
+			name:      "resolves by name in the component namespace",
+			reference: common.CollectionReference{Name: "store-c"},
+			component: webStoreFixture("consumer", "team-b", nil),
+			want:      "team-b/store-c",
+		},
+		{
+			name:      "resolves by name in another namespace",
+			reference: common.CollectionReference{Name: "store-a", Namespace: "team-a"},
+			component: webStoreFixture("consumer", "team-b", nil),
+			want:      "team-a/store-a",
+		},
+		{
+			name: "resolves by selector",
+			reference: common.CollectionReference{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"store": "a"}},
+			},
+			component: webStoreFixture("consumer", "team-a", nil),
+			want:      "team-a/store-a",
+		},
+		{
+			name: "selector only matches within the referenced namespace",
+			reference: common.CollectionReference{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"store": "a"}},
+			},
+			component: webStoreFixture("consumer", "team-b", nil),
+			wantErr:   true,
+		},
+		{
+			name: "ambiguous selector",
+			reference: common.CollectionReference{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"store": "b"}},
+			},
+			component: webStoreFixture("consumer", "team-b", nil),
+			wantErr:   true,
+		},
+		{
+			name:      "missing collection",
+			reference: common.CollectionReference{Name: "missing"},
+			component: webStoreFixture("consumer", "team-a", nil),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.component.Spec.Collection = tt.reference
+
+			// the component is seeded by the fake request, so it is not seeded again
+			objects := []client.Object{}
+			for _, store := range []*appsv1alpha1.WebStore{storeA, storeB, storeC} {
+				if store.Name != tt.component.Name || store.Namespace != tt.component.Namespace {
+					objects = append(objects, store.DeepCopy())
+				}
+			}
+
+			r := testutil.NewFakeRequest(tt.component, objects...)
+
+			got, err := helpers.GetCollection(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetCollection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if key := client.ObjectKeyFromObject(got).String(); key != tt.want {
+				t.Errorf("GetCollection() = %s, want %s", key, tt.want)
+			}
+		})
+	}
+}
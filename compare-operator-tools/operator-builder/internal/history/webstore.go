@@ -0,0 +1,229 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+)
+
+const (
+	// PhaseName is the name that the WebStoreRevisionHistoryPhase is registered with.
+	PhaseName = "RevisionHistoryPhase"
+
+	// WebStoreLabel is the label which identifies the WebStore that a revision belongs to.
+	WebStoreLabel = "apps.acme.com/webstore"
+)
+
+// WebStoreRevisionHistoryPhase records each successfully reconciled WebStore spec as a revision,
+// and prunes the revisions which exceed the revision history limit of the spec.
+type WebStoreRevisionHistoryPhase struct{}
+
+// WebStoreRevisionHistoryPhase.DefaultRequeue returns the default result to requeue the phase.
+func (phase *WebStoreRevisionHistoryPhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+// WebStoreRevisionHistoryPhase.Execute records the spec of the WebStore as the latest revision.  A
+// spec which matches a previous revision, such as after a rollback, moves that revision to the
+// latest revision number rather than creating a duplicate.
+func (phase *WebStoreRevisionHistoryPhase) Execute(r common.ComponentReconciler) (proceedToNextPhase bool, err error) {
+	component, ok := r.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return false, fmt.Errorf("unable to record revision history for component of type %T", r.GetComponent())
+	}
+
+	revisions, err := ListRevisions(r.GetContext(), r, component)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := NewRevision(component, nextRevision(revisions))
+	if err != nil {
+		return false, err
+	}
+
+	if existing := findRevision(revisions, current); existing != nil {
+		if existing.Revision != latestRevision(revisions) {
+			r.GetLogger().V(0).Info(fmt.Sprintf("restoring revision [%s] as revision [%d]", existing.Name, current.Revision))
+
+			existing.Revision = current.Revision
+			if err := r.Update(r.GetContext(), existing); err != nil {
+				return false, fmt.Errorf("unable to update revision [%s]; %v", existing.Name, err)
+			}
+		}
+
+		current = existing
+	} else {
+		if err := ctrl.SetControllerReference(component, current, r.GetScheme()); err != nil {
+			return false, fmt.Errorf("unable to set owner reference on revision [%s]; %v", current.Name, err)
+		}
+
+		r.GetLogger().V(0).Info(fmt.Sprintf("recording revision [%s] as revision [%d]", current.Name, current.Revision))
+
+		if err := r.Create(r.GetContext(), current); err != nil {
+			// the revision may have been created by a previous reconciliation which the cache has
+			// not yet observed, so wait for the cache to catch up
+			if errors.IsAlreadyExists(err) {
+				return false, nil
+			}
+
+			return false, fmt.Errorf("unable to create revision [%s]; %v", current.Name, err)
+		}
+
+		revisions = append(revisions, *current)
+	}
+
+	// the status is persisted when the phase exits
+	component.Status.Revision = current.Revision
+
+	return true, pruneRevisions(r, component, revisions)
+}
+
+// ListRevisions returns the revisions of a WebStore, ordered from the oldest to the latest.
+func ListRevisions(ctx context.Context, reader client.Reader, component *appsv1alpha1.WebStore) ([]appsv1.ControllerRevision, error) {
+	revisionList := &appsv1.ControllerRevisionList{}
+	if err := reader.List(
+		ctx,
+		revisionList,
+		client.InNamespace(component.Namespace),
+		client.MatchingLabels{WebStoreLabel: component.Name},
+	); err != nil {
+		return nil, fmt.Errorf("unable to list revisions for webstore [%s]; %v", component.Name, err)
+	}
+
+	// a previous WebStore of the same name may have left revisions behind which have not yet
+	// been garbage collected
+	revisions := []appsv1.ControllerRevision{}
+
+	for _, revision := range revisionList.Items {
+		if metav1.IsControlledBy(&revision, component) {
+			revisions = append(revisions, revision)
+		}
+	}
+
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	return revisions, nil
+}
+
+// NewRevision returns a revision which holds the spec of a WebStore.  The revision history limit
+// is not recorded, so that restoring a revision does not change how many revisions are retained.
+func NewRevision(component *appsv1alpha1.WebStore, number int64) (*appsv1.ControllerRevision, error) {
+	spec := component.Spec.DeepCopy()
+	spec.RevisionHistoryLimit = nil
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal spec of webstore [%s]; %v", component.Name, err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return &appsv1.ControllerRevision{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "ControllerRevision",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", component.Name, hex.EncodeToString(sum[:])[:10]),
+			Namespace: component.Namespace,
+			Labels:    map[string]string{WebStoreLabel: component.Name},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: number,
+	}, nil
+}
+
+// SpecFromRevision returns the WebStore spec which is held by a revision.
+func SpecFromRevision(revision *appsv1.ControllerRevision) (*appsv1alpha1.WebStoreSpec, error) {
+	spec := &appsv1alpha1.WebStoreSpec{}
+	if err := json.Unmarshal(revision.Data.Raw, spec); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal spec from revision [%s]; %v", revision.Name, err)
+	}
+
+	return spec, nil
+}
+
+// findRevision returns the revision which holds the same spec as the desired revision.
+func findRevision(revisions []appsv1.ControllerRevision, desired *appsv1.ControllerRevision) *appsv1.ControllerRevision {
+	for i := range revisions {
+		if bytes.Equal(revisions[i].Data.Raw, desired.Data.Raw) {
+			return &revisions[i]
+		}
+	}
+
+	return nil
+}
+
+// latestRevision returns the number of the latest revision, or 0 if there are no revisions.
+func latestRevision(revisions []appsv1.ControllerRevision) int64 {
+	if len(revisions) == 0 {
+		return 0
+	}
+
+	return revisions[len(revisions)-1].Revision
+}
+
+// nextRevision returns the number of the revision which follows the latest revision.
+func nextRevision(revisions []appsv1.ControllerRevision) int64 {
+	return latestRevision(revisions) + 1
+}
+
+// pruneRevisions deletes the oldest revisions until no more than the revision history limit of the
+// spec remain alongside the current revision, which is always the latest revision.  The limit is
+// defaulted by the API server, so revisions are only kept without limit for a web store which has
+// not been defaulted.
+func pruneRevisions(r common.ComponentReconciler, component *appsv1alpha1.WebStore, revisions []appsv1.ControllerRevision) error {
+	if component.Spec.RevisionHistoryLimit == nil {
+		return nil
+	}
+
+	limit := int(*component.Spec.RevisionHistoryLimit)
+
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	for i := 0; i < len(revisions)-limit-1; i++ {
+		r.GetLogger().V(4).Info(fmt.Sprintf("pruning revision [%s] with revision [%d]", revisions[i].Name, revisions[i].Revision))
+
+		if err := r.GetClient().Delete(r.GetContext(), &revisions[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete revision [%s]; %v", revisions[i].Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history_test
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/history"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// historyFixture returns a WebStore with the revision history limit.
+func historyFixture(limit int32) *appsv1alpha1.WebStore {
+	return testutil.WebStoreFixture("webstore-sample", appsv1alpha1.WebStoreSpec{
+		WebstoreImage:        "nginx:1.17",
+		RevisionHistoryLimit: &limit,
+	})
+}
+
+// newHistoryReconciler returns a reconciler for the component which is backed by a fake client
+// seeded with the component.
+func newHistoryReconciler(component *appsv1alpha1.WebStore) *appscontrollers.WebStoreRequest {
+	return testutil.NewRequest(testutil.NewClient(component), component)
+}
+
+// reconcileImage records the revision history of the component after changing its image, and
+// returns the number of its current revision.
+func reconcileImage(t *testing.T, r *appscontrollers.WebStoreRequest, image string) int64 {
+	r.Component.Spec.WebstoreImage = image
+
+	proceed, err := (&history.WebStoreRevisionHistoryPhase{}).Execute(r)
+	if !proceed || err != nil {
+		t.Fatalf("WebStoreRevisionHistoryPhase.Execute() = %v, %v", proceed, err)
+	}
+
+	return r.Component.Status.Revision
+}
+
+// listImages returns the images of the revisions of the component, keyed by revision number.
+func listImages(t *testing.T, r *appscontrollers.WebStoreRequest) map[int64]string {
+	revisions, err := history.ListRevisions(r.Context, r, r.Component)
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+
+	images := map[int64]string{}
+
+	for i := range revisions {
+		if !metav1.IsControlledBy(&revisions[i], r.Component) {
+			t.Errorf("revision %s is not controlled by the webstore", revisions[i].Name)
+		}
+
+		spec, err := history.SpecFromRevision(&revisions[i])
+		if err != nil {
+			t.Fatalf("SpecFromRevision() error = %v", err)
+		}
+
+		if spec.RevisionHistoryLimit != nil {
+			t.Errorf("revision %s records the revision history limit", revisions[i].Name)
+		}
+
+		images[revisions[i].Revision] = spec.WebstoreImage
+	}
+
+	return images
+}
+
+func TestWebStoreRevisionHistoryPhase(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int32
+		images   []string
+		want     int64
+		wantHist map[int64]string
+	}{
+		{
+			name:     "records the first revision",
+			limit:    10,
+			images:   []string{"nginx:1.17"},
+			want:     1,
+			wantHist: map[int64]string{1: "nginx:1.17"},
+		},
+		{
+			name:     "does not record an unchanged spec",
+			limit:    10,
+			images:   []string{"nginx:1.17", "nginx:1.17"},
+			want:     1,
+			wantHist: map[int64]string{1: "nginx:1.17"},
+		},
+		{
+			name:     "records a changed spec as the next revision",
+			limit:    10,
+			images:   []string{"nginx:1.17", "nginx:1.21"},
+			want:     2,
+			wantHist: map[int64]string{1: "nginx:1.17", 2: "nginx:1.21"},
+		},
+		{
+			name:     "moves a restored spec to the next revision",
+			limit:    10,
+			images:   []string{"nginx:1.17", "nginx:1.21", "nginx:1.17"},
+			want:     3,
+			wantHist: map[int64]string{2: "nginx:1.21", 3: "nginx:1.17"},
+		},
+		{
+			name:     "prunes the revisions beyond the history limit",
+			limit:    1,
+			images:   []string{"nginx:1.17", "nginx:1.19", "nginx:1.21"},
+			want:     3,
+			wantHist: map[int64]string{2: "nginx:1.19", 3: "nginx:1.21"},
+		},
+		{
+			name:     "retains only the current revision without history",
+			limit:    0,
+			images:   []string{"nginx:1.17", "nginx:1.21"},
+			want:     2,
+			wantHist: map[int64]string{2: "nginx:1.21"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newHistoryReconciler(historyFixture(tt.limit))
+
+			var got int64
+			for _, image := range tt.images {
+				got = reconcileImage(t, r, image)
+			}
+
+			if got != tt.want {
+				t.Errorf("status.revision = %d, want %d", got, tt.want)
+			}
+
+			hist := listImages(t, r)
+			if len(hist) != len(tt.wantHist) {
+				t.Fatalf("revisions = %v, want %v", hist, tt.wantHist)
+			}
+
+			for revision, image := range tt.wantHist {
+				if hist[revision] != image {
+					t.Errorf("revisions = %v, want %v", hist, tt.wantHist)
+				}
+			}
+		})
+	}
+}
+
+func TestListRevisionsIgnoresOtherOwners(t *testing.T) {
+	component := historyFixture(10)
+	r := newHistoryReconciler(component)
+
+	reconcileImage(t, r, "nginx:1.17")
+
+	// a revision left behind by a previous webstore of the same name
+	orphan, err := history.NewRevision(component, 1)
+	if err != nil {
+		t.Fatalf("NewRevision() error = %v", err)
+	}
+
+	previous := component.DeepCopy()
+	previous.UID = "previous-webstore-uid"
+
+	orphan.Name = "webstore-sample-orphan"
+	orphan.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(previous, appsv1alpha1.GroupVersion.WithKind("WebStore"))}
+
+	if err := r.Create(r.Context, orphan); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	revisions, err := history.ListRevisions(r.Context, r, component)
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+
+	if len(revisions) != 1 {
+		t.Fatalf("ListRevisions() returned %d revisions, want 1", len(revisions))
+	}
+
+	all := &appsv1.ControllerRevisionList{}
+	if err := r.List(r.Context, all, client.InNamespace(component.Namespace)); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if len(all.Items) != 2 {
+		t.Errorf("List() returned %d revisions, want 2", len(all.Items))
+	}
+}
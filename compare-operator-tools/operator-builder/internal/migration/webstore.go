@@ -0,0 +1,138 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/prune"
+)
+
+// PhaseName is the name that the WebStoreMigrationPhase is registered with.
+const PhaseName = "NamingMigrationPhase"
+
+// WebStoreMigrationPhase migrates the child resources of a WebStore to a new naming.  The workload
+// resources of the new naming are created alongside the resources which serve the web store, so
+// the phase runs once the child resources are ready.  It then moves the serving resources to the
+// new naming and deletes the child resources of the previous naming.
+type WebStoreMigrationPhase struct{}
+
+// childKey identifies a child resource by its kind and name.
+type childKey struct {
+	schema.GroupVersionKind
+
+	Name string
+}
+
+// WebStoreMigrationPhase.DefaultRequeue returns the default result to requeue the phase.
+func (phase *WebStoreMigrationPhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+// WebStoreMigrationPhase.Execute migrates the child resources to the desired naming once they are
+// ready, and records the naming which serves the web store.
+func (phase *WebStoreMigrationPhase) Execute(r common.ComponentReconciler) (proceedToNextPhase bool, err error) {
+	component, ok := r.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return false, fmt.Errorf("unable to migrate resources for component of type %T", r.GetComponent())
+	}
+
+	served, desired := component.ServedNaming(), component.Naming()
+
+	// the status is persisted when the phase exits
+	if served == desired {
+		component.Status.Naming = desired
+
+		return true, nil
+	}
+
+	// render the child resources of the previous naming before the naming is switched
+	previous := component.DeepCopy()
+	previous.Spec.Naming = served
+	previous.Status.Naming = served
+
+	previousChildren, err := children(previous)
+	if err != nil {
+		return false, err
+	}
+
+	r.GetLogger().V(0).Info(fmt.Sprintf("migrating child resources from [%s] naming to [%s] naming", served, desired))
+
+	component.Status.Naming = desired
+
+	// persist the naming before the serving resources move to it, so that an interrupted migration
+	// resumes with the new naming rather than moving the serving resources back
+	if err := r.UpdateStatus(); err != nil {
+		return false, err
+	}
+
+	// persist the serving resources of the new naming so that traffic moves to its pods
+	if err := r.SetResources(); err != nil {
+		return false, err
+	}
+
+	if proceed, err := (&phases.CreateResourcesPhase{}).Execute(r); !proceed || err != nil {
+		return proceed, err
+	}
+
+	current := map[childKey]bool{}
+
+	for _, resource := range r.GetResources() {
+		current[childKey{GroupVersionKind: resource.GetObject().GetObjectKind().GroupVersionKind(), Name: resource.GetName()}] = true
+	}
+
+	for _, child := range previousChildren {
+		if current[child] {
+			continue
+		}
+
+		if err := prune.DeleteResource(r, component, child.GroupVersionKind, child.Name); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// children returns the kind and name of each child resource of a WebStore.
+func children(component *appsv1alpha1.WebStore) ([]childKey, error) {
+	keys := make([]childKey, 0, len(webstore.CreateFuncs))
+
+	for _, create := range webstore.CreateFuncs {
+		object, err := create(component)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render child resources of [%s] naming; %v", component.Spec.Naming, err)
+		}
+
+		clientObject, ok := object.(client.Object)
+		if !ok {
+			continue
+		}
+
+		keys = append(keys, childKey{GroupVersionKind: clientObject.GetObjectKind().GroupVersionKind(), Name: object.GetName()})
+	}
+
+	return keys, nil
+}
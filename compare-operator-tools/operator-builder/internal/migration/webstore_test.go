@@ -0,0 +1,241 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration_test
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/migration"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// migrationFixture returns a WebStore with the provided name and naming.
+func migrationFixture(name string, naming appsv1alpha1.WebStoreNaming) *appsv1alpha1.WebStore {
+	return testutil.WebStoreFixture(name, appsv1alpha1.WebStoreSpec{
+		WebStoreReplicas: 2,
+		WebstoreImage:    "nginx:1.17",
+		Naming:           naming,
+	})
+}
+
+// newMigrationClient returns a fake client seeded with the namespace and the components.
+func newMigrationClient(components ...*appsv1alpha1.WebStore) client.Client {
+	objects := []client.Object{testutil.NamespaceFixture(testutil.Namespace)}
+	for _, component := range components {
+		objects = append(objects, component)
+	}
+
+	return testutil.NewClient(objects...)
+}
+
+// createResources persists the child resources of the component.
+func createResources(r *appscontrollers.WebStoreRequest) (bool, error) {
+	if err := r.SetResources(); err != nil {
+		return false, err
+	}
+
+	return (&phases.CreateResourcesPhase{}).Execute(r)
+}
+
+// getServiceSelector returns the selector of the named service, or nil if it does not exist.
+func getServiceSelector(t *testing.T, r *appscontrollers.WebStoreRequest, name string) map[string]string {
+	service := &corev1.Service{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: name, Namespace: testutil.Namespace}, service); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+
+		t.Fatalf("unable to get service %s; %v", name, err)
+	}
+
+	return service.Spec.Selector
+}
+
+// deploymentExists determines if the named deployment exists.
+func deploymentExists(t *testing.T, r *appscontrollers.WebStoreRequest, name string) bool {
+	err := r.Get(r.Context, types.NamespacedName{Name: name, Namespace: testutil.Namespace}, &appsv1.Deployment{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		t.Fatalf("unable to get deployment %s; %v", name, err)
+	}
+
+	return err == nil
+}
+
+func TestWebStoreNamingMigration(t *testing.T) {
+	component := migrationFixture("webstore-sample", appsv1alpha1.NamingLegacy)
+	c := newMigrationClient(component)
+	r := testutil.NewRequest(c, component)
+
+	if proceed, err := createResources(r); !proceed || err != nil {
+		t.Fatalf("createResources() = %v, %v", proceed, err)
+	}
+
+	// a web store which was reconciled before the naming was recorded is served by the legacy naming
+	component.Status.Naming = ""
+	component.Spec.Naming = appsv1alpha1.NamingInstance
+
+	if err := r.Update(r.Context, component); err != nil {
+		t.Fatalf("unable to update web store; %v", err)
+	}
+
+	if !component.IsMigrating() {
+		t.Fatalf("IsMigrating() = false, want true for a web store with legacy child resources")
+	}
+
+	if proceed, err := createResources(r); !proceed || err != nil {
+		t.Fatalf("createResources() = %v, %v", proceed, err)
+	}
+
+	// the pods of the new naming run alongside the legacy pods, which still serve the web store
+	if !deploymentExists(t, r, "webstore-sample-deploy") || !deploymentExists(t, r, "webstore-deploy") {
+		t.Fatalf("deployments of both namings must exist while migrating")
+	}
+
+	if selector := getServiceSelector(t, r, "webstore-svc"); selector["app"] != "webstore" {
+		t.Errorf("legacy service selector = %v, want the legacy pods until the migration phase", selector)
+	}
+
+	if getServiceSelector(t, r, "webstore-sample-svc") != nil {
+		t.Errorf("instance service was created before the migration phase")
+	}
+
+	if proceed, err := (&migration.WebStoreMigrationPhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("WebStoreMigrationPhase.Execute() = %v, %v", proceed, err)
+	}
+
+	want := map[string]string{webstore.NameLabel: "webstore", webstore.InstanceLabel: "webstore-sample"}
+	if selector := getServiceSelector(t, r, "webstore-sample-svc"); len(selector) != len(want) ||
+		selector[webstore.NameLabel] != want[webstore.NameLabel] ||
+		selector[webstore.InstanceLabel] != want[webstore.InstanceLabel] {
+		t.Errorf("instance service selector = %v, want %v", selector, want)
+	}
+
+	if getServiceSelector(t, r, "webstore-svc") != nil {
+		t.Errorf("legacy service was not deleted")
+	}
+
+	for _, name := range []string{"webstore-deploy", "webstore-deploy-blue", "webstore-deploy-green"} {
+		if deploymentExists(t, r, name) {
+			t.Errorf("legacy deployment %s was not deleted", name)
+		}
+	}
+
+	if !deploymentExists(t, r, "webstore-sample-deploy") {
+		t.Errorf("instance deployment was deleted")
+	}
+
+	if component.Status.Naming != appsv1alpha1.NamingInstance || component.IsMigrating() {
+		t.Errorf("status naming = %s, want %s", component.Status.Naming, appsv1alpha1.NamingInstance)
+	}
+}
+
+func TestWebStoreUnsetNaming(t *testing.T) {
+	// a new web store uses the instance naming
+	component := migrationFixture("webstore-sample", "")
+	if naming := component.Naming(); naming != appsv1alpha1.NamingInstance {
+		t.Errorf("Naming() = %s, want %s for a new web store", naming, appsv1alpha1.NamingInstance)
+	}
+
+	component = migrationFixture("webstore-sample", appsv1alpha1.NamingLegacy)
+	r := testutil.NewRequest(newMigrationClient(component), component)
+
+	if proceed, err := createResources(r); !proceed || err != nil {
+		t.Fatalf("createResources() = %v, %v", proceed, err)
+	}
+
+	// a web store which was reconciled before the naming existed keeps the legacy naming
+	component.Status.Naming = ""
+	component.Spec.Naming = ""
+
+	if naming := component.Naming(); naming != appsv1alpha1.NamingLegacy || component.IsMigrating() {
+		t.Errorf("Naming() = %s, want %s without migrating", naming, appsv1alpha1.NamingLegacy)
+	}
+
+	if proceed, err := (&migration.WebStoreMigrationPhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("WebStoreMigrationPhase.Execute() = %v, %v", proceed, err)
+	}
+
+	if !deploymentExists(t, r, "webstore-deploy") || deploymentExists(t, r, "webstore-sample-deploy") {
+		t.Errorf("child resources were migrated although the instance naming was not requested")
+	}
+
+	if component.Status.Naming != appsv1alpha1.NamingLegacy {
+		t.Errorf("status naming = %s, want %s", component.Status.Naming, appsv1alpha1.NamingLegacy)
+	}
+}
+
+func TestWebStoreNamingCollision(t *testing.T) {
+	tests := []struct {
+		name    string
+		naming  appsv1alpha1.WebStoreNaming
+		wantErr string
+	}{
+		{
+			name:    "legacy naming collides",
+			naming:  appsv1alpha1.NamingLegacy,
+			wantErr: "is already owned by WebStore [webstore-a]",
+		},
+		{
+			name:   "instance naming is unique",
+			naming: appsv1alpha1.NamingInstance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := migrationFixture("webstore-a", tt.naming)
+			second := migrationFixture("webstore-b", tt.naming)
+
+			c := newMigrationClient(first, second)
+
+			if proceed, err := createResources(testutil.NewRequest(c, first)); !proceed || err != nil {
+				t.Fatalf("createResources(webstore-a) = %v, %v", proceed, err)
+			}
+
+			r := testutil.NewRequest(c, second)
+
+			_, err := createResources(r)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("createResources(webstore-b) error = %v, want it to contain %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("createResources(webstore-b) error = %v", err)
+			}
+
+			a, b := getServiceSelector(t, r, "webstore-a-svc"), getServiceSelector(t, r, "webstore-b-svc")
+			if a[webstore.InstanceLabel] != "webstore-a" || b[webstore.InstanceLabel] != "webstore-b" {
+				t.Errorf("service selectors = %v, %v, want a distinct instance for each web store", a, b)
+			}
+		})
+	}
+}
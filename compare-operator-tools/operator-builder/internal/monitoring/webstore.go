@@ -0,0 +1,118 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/resources"
+)
+
+// PhaseName is the name that the WebStoreMonitoringPhase is registered with.
+const PhaseName = "MonitoringPhase"
+
+// SkippedRequeueAfter is the interval after which a WebStore is reconciled again while monitoring
+// resources are skipped.  Installing the Prometheus operator does not trigger a reconcile of the
+// WebStore, so the skipped resources would otherwise not be persisted until the WebStore changes.
+const SkippedRequeueAfter = 10 * time.Minute
+
+// WebStoreMonitoringPhase reports the monitoring resources of a WebStore which were skipped
+// because the Prometheus operator is not installed in the cluster.  The resources are skipped
+// rather than failing reconciliation, and are persisted by the first reconciliation after the
+// Prometheus operator is installed, which is checked again after an interval.
+type WebStoreMonitoringPhase struct{}
+
+// WebStoreMonitoringPhase.DefaultRequeue returns the default result to requeue the phase.
+func (phase *WebStoreMonitoringPhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+// WebStoreMonitoringPhase.Execute logs the monitoring resources which were skipped.  It never
+// blocks reconciliation, as the web store is served regardless of whether it is monitored.
+func (phase *WebStoreMonitoringPhase) Execute(r common.ComponentReconciler) (proceedToNextPhase bool, err error) {
+	skipped, err := SkippedResources(r)
+	if err != nil {
+		return false, err
+	}
+
+	for _, message := range skipped {
+		r.GetLogger().V(0).Info("skipped monitoring resource; " + message)
+	}
+
+	return true, nil
+}
+
+// WebStoreMonitoringPhase.Message reports the monitoring resources which were skipped in the
+// condition of the phase.
+func (phase *WebStoreMonitoringPhase) Message(r common.ComponentReconciler) string {
+	// errors are returned from the execution of the phase, which runs first
+	skipped, err := SkippedResources(r)
+	if err != nil || len(skipped) == 0 {
+		return ""
+	}
+
+	return "Skipped monitoring resources; " + strings.Join(skipped, "; ")
+}
+
+// WebStoreMonitoringPhase.RequeueAfter returns the interval after which the WebStore is reconciled
+// again to check whether the APIs of the skipped monitoring resources have been installed.
+func (phase *WebStoreMonitoringPhase) RequeueAfter(r common.ComponentReconciler) time.Duration {
+	// errors are returned from the execution of the phase, which runs first
+	skipped, err := SkippedResources(r)
+	if err != nil || len(skipped) == 0 {
+		return 0
+	}
+
+	return SkippedRequeueAfter
+}
+
+// SkippedResources returns a message for each enabled monitoring resource of a WebStore which is
+// skipped because its API is not available in the cluster.
+func SkippedResources(r common.ComponentReconciler) ([]string, error) {
+	component, ok := r.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return nil, fmt.Errorf("unable to check monitoring resources for component of type %T", r.GetComponent())
+	}
+
+	var skipped []string
+
+	for _, optional := range webstore.OptionalResources {
+		if optional.Group != webstore.MonitoringGroup || !optional.Enabled(component) {
+			continue
+		}
+
+		available, err := resources.APIIsAvailable(r, optional.GroupVersionKind)
+		if err != nil {
+			return nil, err
+		}
+
+		if !available {
+			skipped = append(skipped, fmt.Sprintf("kind: [%s], name: [%s]; api [%s] is not available in the cluster",
+				optional.Kind, optional.Name(component), optional.GroupVersionKind))
+		}
+	}
+
+	return skipped, nil
+}
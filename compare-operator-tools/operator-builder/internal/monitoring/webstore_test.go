@@ -0,0 +1,272 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring_test
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/monitoring"
+	"github.com/scottd018/demos/internal/pipelines"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// newMonitoringMapper returns a rest mapper which serves the apis of the WebStore child resources,
+// and the apis of the Prometheus operator when it is installed.
+func newMonitoringMapper(prometheusOperatorInstalled bool) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	if prometheusOperatorInstalled {
+		for _, kind := range []string{webstore.ServiceMonitorKind, webstore.PrometheusRuleKind} {
+			mapper.Add(schema.GroupVersionKind{Group: webstore.MonitoringGroup, Version: "v1", Kind: kind}, meta.RESTScopeNamespace)
+		}
+	}
+
+	return mapper
+}
+
+// monitoringFixture returns a WebStore which is monitored with alerts, or is not monitored.
+func monitoringFixture(monitored bool) *appsv1alpha1.WebStore {
+	spec := appsv1alpha1.WebStoreSpec{}
+
+	if monitored {
+		spec.Monitoring = &appsv1alpha1.WebStoreMonitoring{
+			Labels: map[string]string{"release": "prometheus"},
+			Alerts: &appsv1alpha1.WebStoreAlerts{},
+		}
+	}
+
+	return testutil.WebStoreFixture("webstore-sample", spec)
+}
+
+// newMonitoringReconciler returns a reconciler for the component whose resources have been set.
+func newMonitoringReconciler(t *testing.T, component *appsv1alpha1.WebStore, prometheusOperatorInstalled bool) *appscontrollers.WebStoreRequest {
+	r := testutil.NewRequest(&testutil.MapperClient{
+		Client: testutil.NewClient(component),
+		Mapper: newMonitoringMapper(prometheusOperatorInstalled),
+	}, component)
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	return r
+}
+
+// getResource returns the content of a child resource of the reconciler, or nil if it was skipped.
+func getResource(t *testing.T, r *appscontrollers.WebStoreRequest, kind, name string) map[string]interface{} {
+	for _, resource := range r.GetResources() {
+		if resource.GetKind() != kind || resource.GetName() != name {
+			continue
+		}
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(resource.GetObject())
+		if err != nil {
+			t.Fatalf("unable to convert %s %s; %v", kind, name, err)
+		}
+
+		return content
+	}
+
+	return nil
+}
+
+// getNames returns the names of the items of a list within the content of a child resource.
+func getNames(t *testing.T, content map[string]interface{}, fields ...string) []string {
+	items, _, err := unstructured.NestedSlice(content, fields...)
+	if err != nil {
+		t.Fatalf("unable to get %s; %v", strings.Join(fields, "."), err)
+	}
+
+	names := make([]string, len(items))
+
+	for i, item := range items {
+		names[i], _, _ = unstructured.NestedString(item.(map[string]interface{}), "name")
+	}
+
+	return names
+}
+
+// getMonitoringCondition runs the monitoring phase and returns the condition which it reports,
+// along with the result which the request is requeued with.
+func getMonitoringCondition(t *testing.T, r *appscontrollers.WebStoreRequest) (string, ctrl.Result) {
+	if err := pipelines.RegisterWebStorePhases(); err != nil {
+		t.Fatalf("RegisterWebStorePhases() error = %v", err)
+	}
+
+	phase, err := phases.GetPhase(monitoring.PhaseName)
+	if err != nil {
+		t.Fatalf("GetPhase() error = %v", err)
+	}
+
+	pipelinePhase := &phases.PipelinePhase{Phase: phase, Name: monitoring.PhaseName}
+
+	proceed, err := phase.Execute(r)
+	if !proceed || err != nil {
+		t.Fatalf("%s.Execute() = %v, %v", monitoring.PhaseName, proceed, err)
+	}
+
+	result, err := phases.HandlePhaseExit(r, pipelinePhase, proceed, err)
+	if err != nil {
+		t.Fatalf("HandlePhaseExit() error = %v", err)
+	}
+
+	for _, condition := range r.Component.Status.Conditions {
+		if condition.Phase == monitoring.PhaseName {
+			return condition.Message, result
+		}
+	}
+
+	t.Fatalf("condition for %s was not set", monitoring.PhaseName)
+
+	return "", result
+}
+
+func TestWebStoreMonitoring(t *testing.T) {
+	component := monitoringFixture(true)
+	component.Spec.Rollout.Strategy = appsv1alpha1.RolloutStrategyBlueGreen
+
+	r := newMonitoringReconciler(t, component, true)
+
+	serviceMonitor := getResource(t, r, webstore.ServiceMonitorKind, "webstore-sample-monitor")
+	if serviceMonitor == nil {
+		t.Fatalf("service monitor was not rendered")
+	}
+
+	if label, _, _ := unstructured.NestedString(serviceMonitor, "metadata", "labels", "release"); label != "prometheus" {
+		t.Errorf("service monitor release label = %q, want the monitoring labels", label)
+	}
+
+	if ports := getNames(t, getResource(t, r, "Service", "webstore-sample-svc"), "spec", "ports"); len(ports) != 2 ||
+		ports[1] != webstore.MetricsPortName {
+		t.Errorf("service ports = %v, want the metrics port", ports)
+	}
+
+	endpoints, _, _ := unstructured.NestedSlice(serviceMonitor, "spec", "endpoints")
+	if len(endpoints) != 1 || endpoints[0].(map[string]interface{})["port"] != webstore.MetricsPortName ||
+		endpoints[0].(map[string]interface{})["interval"] != "30s" {
+		t.Errorf("service monitor endpoints = %v, want the metrics port scraped every 30s", endpoints)
+	}
+
+	rule := getResource(t, r, webstore.PrometheusRuleKind, "webstore-sample-rules")
+	if rule == nil {
+		t.Fatalf("prometheus rule was not rendered")
+	}
+
+	groups, _, _ := unstructured.NestedSlice(rule, "spec", "groups")
+	if len(groups) != 1 || len(groups[0].(map[string]interface{})["rules"].([]interface{})) == 0 {
+		t.Errorf("prometheus rule groups = %v, want alerts", groups)
+	}
+
+	// the exporter runs alongside the web store container of each deployment
+	for _, name := range []string{"webstore-sample-deploy", "webstore-sample-deploy-blue", "webstore-sample-deploy-green"} {
+		containers := getNames(t, getResource(t, r, "Deployment", name), "spec", "template", "spec", "containers")
+		if len(containers) != 2 || containers[1] != webstore.ExporterContainerName {
+			t.Errorf("deployment %s containers = %v, want the nginx exporter", name, containers)
+		}
+	}
+
+	config, _, _ := unstructured.NestedString(getResource(t, r, "ConfigMap", webstore.NginxConfigMapName(r.Component, "")), "data", webstore.NginxConfigKey)
+	if !strings.Contains(config, "stub_status;") {
+		t.Errorf("nginx configuration does not serve its status to the exporter:\n%s", config)
+	}
+
+	message, result := getMonitoringCondition(t, r)
+	if strings.Contains(message, "Skipped") {
+		t.Errorf("condition message = %q, want no skipped resources reported", message)
+	}
+
+	if !result.IsZero() {
+		t.Errorf("result = %+v, want no requeue", result)
+	}
+}
+
+func TestWebStoreMonitoringWithoutPrometheusOperator(t *testing.T) {
+	r := newMonitoringReconciler(t, monitoringFixture(true), false)
+
+	for _, kind := range []string{webstore.ServiceMonitorKind, webstore.PrometheusRuleKind} {
+		for _, resource := range r.GetResources() {
+			if resource.GetKind() == kind {
+				t.Errorf("%s was rendered although its api is not available", kind)
+			}
+		}
+	}
+
+	// the pods are still monitored, so that they are scraped once the operator is installed
+	containers := getNames(t, getResource(t, r, "Deployment", "webstore-sample-deploy"), "spec", "template", "spec", "containers")
+	if len(containers) != 2 {
+		t.Errorf("deployment containers = %v, want the nginx exporter", containers)
+	}
+
+	message, result := getMonitoringCondition(t, r)
+	for _, kind := range []string{webstore.ServiceMonitorKind, webstore.PrometheusRuleKind} {
+		if !strings.Contains(message, kind) {
+			t.Errorf("condition message = %q, want the skipped %s reported", message, kind)
+		}
+	}
+
+	// the skipped resources are persisted once the operator is installed
+	if want := (ctrl.Result{Requeue: true, RequeueAfter: monitoring.SkippedRequeueAfter}); result != want {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+}
+
+func TestWebStoreWithoutMonitoring(t *testing.T) {
+	r := newMonitoringReconciler(t, monitoringFixture(false), false)
+
+	for _, kind := range []string{webstore.ServiceMonitorKind, webstore.PrometheusRuleKind} {
+		for _, resource := range r.GetResources() {
+			if resource.GetKind() == kind {
+				t.Errorf("%s was rendered although monitoring is disabled", kind)
+			}
+		}
+	}
+
+	containers := getNames(t, getResource(t, r, "Deployment", "webstore-sample-deploy"), "spec", "template", "spec", "containers")
+	if len(containers) != 1 {
+		t.Errorf("deployment containers = %v, want only the web store container", containers)
+	}
+
+	if ports := getNames(t, getResource(t, r, "Service", "webstore-sample-svc"), "spec", "ports"); len(ports) != 1 {
+		t.Errorf("service ports = %v, want only the http port", ports)
+	}
+
+	message, result := getMonitoringCondition(t, r)
+	if strings.Contains(message, "Skipped") {
+		t.Errorf("condition message = %q, want no skipped resources reported", message)
+	}
+
+	if !result.IsZero() {
+		t.Errorf("result = %+v, want no requeue", result)
+	}
+}
@@ -17,14 +17,49 @@ limitations under the License.
 package mutate
 
 import (
+	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
 	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/resources"
 )
 
 // WebStoreMutate performs the logic to mutate resources that belong to the parent.
 func WebStoreMutate(reconciler common.ComponentReconciler,
 	object *metav1.Object,
 ) (replacedObjects []metav1.Object, skip bool, err error) {
+	parent, ok := reconciler.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return []metav1.Object{*object}, false, nil
+	}
+
+	optional, found := webstore.GetOptionalResource(parent, *object)
+	if !found {
+		return []metav1.Object{*object}, false, nil
+	}
+
+	// optional resources are skipped unless they are enabled, so that their APIs are only required
+	// to be installed for web stores which use them
+	if !optional.Enabled(parent) {
+		return nil, true, nil
+	}
+
+	if optional.SkipWhenUnavailable {
+		available, err := resources.APIIsAvailable(reconciler, optional.GroupVersionKind)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !available {
+			reconciler.GetLogger().V(2).Info(fmt.Sprintf("skipping resource; kind: [%s], name: [%s]; api [%s] is not available",
+				optional.Kind, optional.Name(parent), optional.GroupVersionKind))
+
+			return nil, true, nil
+		}
+	}
+
 	return []metav1.Object{*object}, false, nil
 }
@@ -0,0 +1,142 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scottd018/demos/internal/certificates"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/history"
+	"github.com/scottd018/demos/internal/migration"
+	"github.com/scottd018/demos/internal/monitoring"
+	"github.com/scottd018/demos/internal/prune"
+	"github.com/scottd018/demos/internal/rollout"
+)
+
+// WebStoreOptions defines the configuration of the WebStore pipeline.
+type WebStoreOptions struct {
+	// RequeueAfter defines the interval after which a request is requeued when a phase is not
+	// ready, by the name of the phase.  The default requeue result of the phase is used for phases
+	// which have no interval.
+	RequeueAfter map[string]time.Duration
+}
+
+// webStoreRegistration registers the WebStore phases once, as a phase may only be registered once.
+var webStoreRegistration = struct {
+	sync.Once
+	err error
+}{}
+
+// DefaultWebStoreOptions returns the configuration of the WebStore pipeline which is used when no
+// configuration is provided.
+func DefaultWebStoreOptions() WebStoreOptions {
+	return WebStoreOptions{
+		RequeueAfter: map[string]time.Duration{
+			"CheckReadyPhase": phases.DefaultCheckReadyRequeueAfter,
+		},
+	}
+}
+
+// RegisterWebStorePhases registers the phases of the WebStore pipeline which are not built in, so
+// that they may be referenced by name from a pipeline.  It may be called more than once, in which
+// case the phases are only registered by the first call.
+func RegisterWebStorePhases() error {
+	webStoreRegistration.Do(func() {
+		for _, registration := range []struct {
+			name  string
+			phase phases.Phase
+		}{
+			{name: rollout.AbortPhaseName, phase: &rollout.WebStoreAbortPhase{}},
+			{name: prune.PhaseName, phase: &prune.WebStorePrunePhase{}},
+			{name: monitoring.PhaseName, phase: &monitoring.WebStoreMonitoringPhase{}},
+			{name: certificates.PhaseName, phase: &certificates.WebStoreCertificatePhase{}},
+			{name: migration.PhaseName, phase: &migration.WebStoreMigrationPhase{}},
+			{name: rollout.PromotePhaseName, phase: &rollout.WebStorePromotePhase{}},
+			{name: history.PhaseName, phase: &history.WebStoreRevisionHistoryPhase{}},
+		} {
+			if err := phases.RegisterPhase(registration.name, registration.phase); err != nil {
+				webStoreRegistration.err = fmt.Errorf("unable to register WebStore phases; %w", err)
+
+				return
+			}
+		}
+	})
+
+	return webStoreRegistration.err
+}
+
+// WebStorePipeline returns the phases that a WebStore runs through during the reconcile process.
+// The dependencies of a WebStore are only checked until it has been successfully reconciled, so
+// the update phases skip the DependencyPhase.  Custom phases may be added to the pipeline once
+// they have been registered with phases.RegisterPhase.
+func WebStorePipeline(options WebStoreOptions) (*phases.Pipeline, error) {
+	if err := RegisterWebStorePhases(); err != nil {
+		return nil, err
+	}
+
+	names := []string{
+		"DependencyPhase",
+		"PreFlightPhase",
+		rollout.AbortPhaseName,
+		"CreateResourcesPhase",
+		prune.PhaseName,
+		monitoring.PhaseName,
+		certificates.PhaseName,
+		"CheckReadyPhase",
+		migration.PhaseName,
+		rollout.PromotePhaseName,
+		history.PhaseName,
+		"CompletePhase",
+	}
+
+	pipeline := &phases.Pipeline{}
+
+	for _, name := range names {
+		definition := phases.PhaseDefinition{Name: name, RequeueAfter: options.RequeueAfter[name]}
+
+		pipeline.Create = append(pipeline.Create, definition)
+
+		if name != "DependencyPhase" {
+			pipeline.Update = append(pipeline.Update, definition)
+		}
+	}
+
+	// an interval for a phase outside of the pipeline is most likely a misspelled phase name
+	for name := range options.RequeueAfter {
+		if !containsPhase(names, name) {
+			return nil, fmt.Errorf(
+				"unable to configure requeue interval of phase %s; phase is not in the WebStore pipeline", name,
+			)
+		}
+	}
+
+	return pipeline, nil
+}
+
+// containsPhase determines if a phase name is in a list of phase names.
+func containsPhase(names []string, name string) bool {
+	for i := range names {
+		if names[i] == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelines_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/pipelines"
+	"github.com/scottd018/demos/internal/prune"
+)
+
+func TestRegisterWebStorePhases(t *testing.T) {
+	// the phases are registered once, so registering them again succeeds
+	for i := 0; i < 2; i++ {
+		if err := pipelines.RegisterWebStorePhases(); err != nil {
+			t.Fatalf("RegisterWebStorePhases() error = %v", err)
+		}
+	}
+
+	if _, err := phases.GetPhase(prune.PhaseName); err != nil {
+		t.Errorf("GetPhase() error = %v", err)
+	}
+}
+
+func TestWebStorePipeline(t *testing.T) {
+	tests := []struct {
+		name             string
+		requeueAfter     map[string]time.Duration
+		wantRequeueAfter map[string]time.Duration
+		wantErr          bool
+	}{
+		{
+			name:             "default options",
+			requeueAfter:     pipelines.DefaultWebStoreOptions().RequeueAfter,
+			wantRequeueAfter: map[string]time.Duration{"CheckReadyPhase": phases.DefaultCheckReadyRequeueAfter},
+		},
+		{
+			name:             "configured requeue intervals",
+			requeueAfter:     map[string]time.Duration{"CheckReadyPhase": time.Minute, prune.PhaseName: time.Second},
+			wantRequeueAfter: map[string]time.Duration{"CheckReadyPhase": time.Minute, prune.PhaseName: time.Second},
+		},
+		{
+			name:         "requeue interval of a phase outside of the pipeline",
+			requeueAfter: map[string]time.Duration{"CheckReadyPhas": time.Minute},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := pipelines.WebStorePipeline(pipelines.WebStoreOptions{RequeueAfter: tt.requeueAfter})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WebStorePipeline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if err := pipeline.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			// the update phases are the create phases without the dependency phase
+			if len(pipeline.Update) != len(pipeline.Create)-1 {
+				t.Errorf("update phases = %d, want %d", len(pipeline.Update), len(pipeline.Create)-1)
+			}
+
+			for _, definition := range pipeline.Update {
+				if definition.Name == "DependencyPhase" {
+					t.Errorf("update phases contain the DependencyPhase")
+				}
+			}
+
+			for _, definitions := range [][]phases.PhaseDefinition{pipeline.Create, pipeline.Update} {
+				for _, definition := range definitions {
+					if definition.RequeueAfter != tt.wantRequeueAfter[definition.Name] {
+						t.Errorf("phase %s requeue after = %v, want %v",
+							definition.Name, definition.RequeueAfter, tt.wantRequeueAfter[definition.Name])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prune
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+)
+
+// PhaseName is the name that the WebStorePrunePhase is registered with.
+const PhaseName = "PruneResourcesPhase"
+
+// WebStorePrunePhase deletes the optional child resources of a WebStore which have been disabled,
+// as they are no longer rendered and would otherwise remain in the cluster until the WebStore is
+// deleted.
+type WebStorePrunePhase struct{}
+
+// WebStorePrunePhase.DefaultRequeue returns the default result to requeue the phase.
+func (phase *WebStorePrunePhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+// WebStorePrunePhase.Execute deletes the disabled optional child resources which are controlled by
+// the WebStore.
+func (phase *WebStorePrunePhase) Execute(r common.ComponentReconciler) (proceedToNextPhase bool, err error) {
+	component, ok := r.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return false, fmt.Errorf("unable to prune resources for component of type %T", r.GetComponent())
+	}
+
+	for _, optional := range webstore.OptionalResources {
+		if optional.Enabled(component) {
+			continue
+		}
+
+		if err := DeleteResource(r, component, optional.GroupVersionKind, optional.Name(component)); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// DeleteResource deletes a child resource when it exists and is controlled by the WebStore.
+func DeleteResource(
+	r common.ComponentReconciler,
+	component *appsv1alpha1.WebStore,
+	gvk schema.GroupVersionKind,
+	name string,
+) error {
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(gvk)
+
+	if err := r.Get(r.GetContext(), client.ObjectKey{Name: name, Namespace: component.Namespace}, object); err != nil {
+		// the resource can not exist when its api is not installed in the cluster
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return nil
+		}
+
+		return fmt.Errorf("unable to get resource; kind: [%s], name: [%s]; %v", gvk.Kind, name, err)
+	}
+
+	if !metav1.IsControlledBy(object, component) {
+		return nil
+	}
+
+	r.GetLogger().V(0).Info(fmt.Sprintf("deleting resource; kind: [%s], name: [%s], namespace: [%s]",
+		gvk.Kind, name, component.Namespace))
+
+	if err := r.GetClient().Delete(r.GetContext(), object); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("unable to delete resource; kind: [%s], name: [%s]; %v", gvk.Kind, name, err)
+	}
+
+	return nil
+}
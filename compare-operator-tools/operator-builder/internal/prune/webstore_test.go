@@ -0,0 +1,252 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prune_test
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/prune"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// pruneFixture returns a WebStore whose network policy is enabled or disabled.
+func pruneFixture(enabled bool) *appsv1alpha1.WebStore {
+	return testutil.WebStoreFixture("webstore-sample", appsv1alpha1.WebStoreSpec{
+		NetworkPolicy: appsv1alpha1.WebStoreNetworkPolicy{Enabled: &enabled},
+	})
+}
+
+// newPruneReconciler returns a reconciler for the component whose child resources have been
+// persisted, and which is backed by a fake client seeded with the component and the objects.
+func newPruneReconciler(t *testing.T, component *appsv1alpha1.WebStore, objects ...client.Object) *appscontrollers.WebStoreRequest {
+	r := testutil.NewFakeRequest(component, objects...)
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	if proceed, err := (&phases.CreateResourcesPhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("CreateResourcesPhase.Execute() = %v, %v", proceed, err)
+	}
+
+	return r
+}
+
+// getNetworkPolicy returns the persisted network policy, or nil if it does not exist.
+func getNetworkPolicy(t *testing.T, r *appscontrollers.WebStoreRequest) *networkingv1.NetworkPolicy {
+	policy := &networkingv1.NetworkPolicy{}
+	if err := r.Get(r.Context, client.ObjectKey{Name: "webstore-sample-netpol", Namespace: testutil.Namespace}, policy); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+
+		t.Fatalf("unable to get network policy; %v", err)
+	}
+
+	return policy
+}
+
+func TestWebStoreNetworkPolicySelectsWebStorePods(t *testing.T) {
+	component := pruneFixture(true)
+	component.Spec.Rollout.Strategy = appsv1alpha1.RolloutStrategyCanary
+
+	r := newPruneReconciler(t, component)
+
+	policy := getNetworkPolicy(t, r)
+	if policy == nil {
+		t.Fatalf("network policy was not persisted")
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		t.Fatalf("LabelSelectorAsSelector() error = %v", err)
+	}
+
+	// the network policy selects the pods of each deployment, which the service also selects
+	for _, name := range []string{"webstore-sample-deploy", "webstore-sample-deploy-blue", "webstore-sample-deploy-green"} {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(r.Context, client.ObjectKey{Name: name, Namespace: testutil.Namespace}, deployment); err != nil {
+			t.Fatalf("unable to get deployment %s; %v", name, err)
+		}
+
+		if !selector.Matches(labels.Set(deployment.Spec.Template.Labels)) {
+			t.Errorf("network policy selector %s does not select the pods of deployment %s", selector, name)
+		}
+	}
+
+	service := &corev1.Service{}
+	if err := r.Get(r.Context, client.ObjectKey{Name: "webstore-sample-svc", Namespace: testutil.Namespace}, service); err != nil {
+		t.Fatalf("unable to get service; %v", err)
+	}
+
+	for key, value := range service.Spec.Selector {
+		if policy.Spec.PodSelector.MatchLabels[key] != value {
+			t.Errorf("network policy selector %s does not match the service selector %v", selector, service.Spec.Selector)
+		}
+	}
+
+	if len(policy.Spec.Ingress) != 1 || len(policy.Spec.Ingress[0].From) != 2 {
+		t.Fatalf("ingress = %+v, want a single rule from the ingress controller and the namespace", policy.Spec.Ingress)
+	}
+
+	from := policy.Spec.Ingress[0].From
+	if from[0].NamespaceSelector == nil ||
+		from[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"] != "ingress-nginx" {
+		t.Errorf("ingress peer = %+v, want the ingress controller namespace", from[0])
+	}
+
+	if from[1].PodSelector == nil || len(from[1].PodSelector.MatchLabels) != 0 || from[1].NamespaceSelector != nil {
+		t.Errorf("ingress peer = %+v, want every pod in the namespace", from[1])
+	}
+
+	if len(policy.Spec.PolicyTypes) != 1 || len(policy.Spec.Egress) != 0 {
+		t.Errorf("policy types = %v, egress = %+v, want egress unrestricted", policy.Spec.PolicyTypes, policy.Spec.Egress)
+	}
+}
+
+func TestWebStoreNetworkPolicyAdditionalRules(t *testing.T) {
+	component := pruneFixture(true)
+	component.Spec.NetworkPolicy.AdditionalPeers = []networkingv1.NetworkPolicyPeer{
+		{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}},
+	}
+	component.Spec.NetworkPolicy.Egress = []networkingv1.NetworkPolicyEgressRule{
+		{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.1.0.0/16"}}}},
+	}
+
+	policy := getNetworkPolicy(t, newPruneReconciler(t, component))
+
+	if from := policy.Spec.Ingress[0].From; len(from) != 3 || from[2].IPBlock == nil || from[2].IPBlock.CIDR != "10.0.0.0/8" {
+		t.Errorf("ingress peers = %+v, want the additional peer", from)
+	}
+
+	if len(policy.Spec.PolicyTypes) != 2 || len(policy.Spec.Egress) != 1 {
+		t.Errorf("policy types = %v, egress = %+v, want the egress rule", policy.Spec.PolicyTypes, policy.Spec.Egress)
+	}
+}
+
+func TestWebStorePrunePhase(t *testing.T) {
+	r := newPruneReconciler(t, pruneFixture(true))
+	if getNetworkPolicy(t, r) == nil {
+		t.Fatalf("network policy was not persisted")
+	}
+
+	// an enabled network policy is kept
+	if proceed, err := (&prune.WebStorePrunePhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("WebStorePrunePhase.Execute() = %v, %v", proceed, err)
+	}
+
+	if getNetworkPolicy(t, r) == nil {
+		t.Fatalf("enabled network policy was pruned")
+	}
+
+	// a disabled network policy is no longer rendered and is deleted
+	disabled := false
+	r.Component.Spec.NetworkPolicy.Enabled = &disabled
+
+	r.Resources = nil
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	for _, resource := range r.GetResources() {
+		if resource.GetKind() == webstore.NetworkPolicyKind {
+			t.Errorf("disabled network policy was rendered")
+		}
+	}
+
+	if proceed, err := (&prune.WebStorePrunePhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("WebStorePrunePhase.Execute() = %v, %v", proceed, err)
+	}
+
+	if getNetworkPolicy(t, r) != nil {
+		t.Errorf("disabled network policy was not pruned")
+	}
+}
+
+func TestWebStorePrunePhaseSlotResources(t *testing.T) {
+	component := pruneFixture(false)
+	component.Spec.Rollout.Strategy = appsv1alpha1.RolloutStrategyCanary
+
+	r := newPruneReconciler(t, component)
+
+	slotResources := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample-deploy-blue"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample-deploy-green"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample-nginx-conf-blue"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample-nginx-conf-green"}},
+	}
+
+	for _, object := range slotResources {
+		if err := r.Get(r.Context, client.ObjectKey{Name: object.GetName(), Namespace: testutil.Namespace}, object); err != nil {
+			t.Fatalf("unable to get slot resource %s; %v", object.GetName(), err)
+		}
+	}
+
+	// the slots are no longer rendered once the strategy changes back to a rolling update
+	r.Component.Spec.Rollout.Strategy = appsv1alpha1.RolloutStrategyRollingUpdate
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	for _, resource := range r.GetResources() {
+		for _, object := range slotResources {
+			if resource.GetName() == object.GetName() {
+				t.Errorf("slot resource %s was rendered for a rolling update", object.GetName())
+			}
+		}
+	}
+
+	if proceed, err := (&prune.WebStorePrunePhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("WebStorePrunePhase.Execute() = %v, %v", proceed, err)
+	}
+
+	for _, object := range slotResources {
+		err := r.Get(r.Context, client.ObjectKey{Name: object.GetName(), Namespace: testutil.Namespace}, object)
+		if !apierrs.IsNotFound(err) {
+			t.Errorf("slot resource %s was not pruned; %v", object.GetName(), err)
+		}
+	}
+}
+
+func TestWebStorePrunePhaseKeepsResourcesOwnedByOthers(t *testing.T) {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "webstore-sample-netpol", Namespace: testutil.Namespace},
+	}
+
+	r := newPruneReconciler(t, pruneFixture(false), policy)
+
+	if proceed, err := (&prune.WebStorePrunePhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("WebStorePrunePhase.Execute() = %v, %v", proceed, err)
+	}
+
+	if getNetworkPolicy(t, r) == nil {
+		t.Errorf("network policy which is not owned by the webstore was pruned")
+	}
+}
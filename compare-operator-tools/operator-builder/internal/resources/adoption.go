@@ -0,0 +1,39 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+// AdoptedAnnotation is the annotation which marks a child resource that existed in the cluster
+// before it was adopted by its parent.  It stores the adoption policy that the resource was adopted
+// with.
+const AdoptedAnnotation = "apps.acme.com/adopted"
+
+// AdoptedWith returns the adoption policy that a resource was adopted with, or an empty string if
+// the resource was not adopted.
+func (resource *Resource) AdoptedWith() string {
+	return resource.Object.GetAnnotations()[AdoptedAnnotation]
+}
+
+// SetAdopted marks a resource as adopted with the adoption policy.
+func (resource *Resource) SetAdopted(policy string) {
+	annotations := resource.Object.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[AdoptedAnnotation] = policy
+	resource.Object.SetAnnotations(annotations)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/scottd018/demos/apis/common"
+)
+
+// APIIsAvailable determines if the API of a kind is served by the cluster.
+func APIIsAvailable(r common.ComponentReconciler, gvk schema.GroupVersionKind) (bool, error) {
+	mapper := r.GetClient().RESTMapper()
+	if mapper == nil {
+		return false, errors.New("unable to discover apis; client does not provide a rest mapper")
+	}
+
+	if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("unable to discover api [%s]; %v", gvk, err)
+	}
+
+	return true, nil
+}
@@ -18,6 +18,7 @@ package resources
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/scottd018/demos/apis/common"
 )
@@ -26,7 +27,8 @@ const (
 	ConfigMapKind = "ConfigMap"
 )
 
-// ConfigMapIsReady performs the logic to determine if a secret is ready.
+// ConfigMapIsReady performs the logic to determine if a config map is ready.  A config map is only
+// ready when each of the expected keys holds a value.
 func ConfigMapIsReady(resource common.ComponentResource, expectedKeys ...string) (bool, error) {
 	var configMap v1.ConfigMap
 	if err := getObject(resource, &configMap, true); err != nil {
@@ -38,12 +40,37 @@ func ConfigMapIsReady(resource common.ComponentResource, expectedKeys ...string)
 		return false, nil
 	}
 
-	// check the status for a ready ca keypair secret
+	// check that each of the expected keys holds a value
 	for _, key := range expectedKeys {
-		if string(configMap.Data[key]) == "" {
+		if configMap.Data[key] == "" {
 			return false, nil
 		}
 	}
 
 	return true, nil
 }
+
+// configMapKeys returns the keys of the data of the desired config map, which are expected to be
+// present on the config map before it is considered ready.
+func configMapKeys(resource *Resource) ([]string, error) {
+	if resource.Object == nil {
+		return nil, nil
+	}
+
+	desired, err := resource.ToUnstructured()
+	if err != nil {
+		return nil, err
+	}
+
+	data, _, err := unstructured.NestedMap(desired.Object, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ignoredFields are the fields of a desired resource which are never compared against the actual
+// resource, because they are either identifying fields or are owned by the server.  Array indices
+// are represented as [] so that a single entry matches every element of an array.
+var ignoredFields = map[string]bool{
+	"apiVersion":                             true,
+	"kind":                                   true,
+	"status":                                 true,
+	"spec.volumeClaimTemplates[].apiVersion": true,
+	"spec.volumeClaimTemplates[].kind":       true,
+	"spec.volumeClaimTemplates[].status":     true,
+}
+
+// comparedMetadataFields are the only metadata fields of a desired resource which are compared
+// against the actual resource.  All other metadata is either identifying (e.g. name) or is
+// populated by the server (e.g. uid, resourceVersion, managedFields).
+var comparedMetadataFields = map[string]bool{
+	"labels":      true,
+	"annotations": true,
+}
+
+// findDrift walks the fields set on the desired object and compares them against the actual
+// object.  It returns the path of the first field which has drifted, or an empty string if
+// every desired field matches.  Fields which exist only on the actual object, such as defaults
+// populated by the server, are not considered drift.
+func findDrift(desired, actual map[string]interface{}) string {
+	for key, desiredValue := range desired {
+		if key == "metadata" {
+			if drift := findMetadataDrift(desiredValue, actual[key]); drift != "" {
+				return drift
+			}
+
+			continue
+		}
+
+		if drift := findValueDrift(key, key, desiredValue, actual[key]); drift != "" {
+			return drift
+		}
+	}
+
+	return ""
+}
+
+// findMetadataDrift compares the desired metadata fields which are owned by the controller
+// against the actual metadata.
+func findMetadataDrift(desired, actual interface{}) string {
+	desiredMetadata, ok := desired.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	actualMetadata, _ := actual.(map[string]interface{})
+
+	for key := range comparedMetadataFields {
+		path := "metadata." + key
+		if drift := findValueDrift(path, path, desiredMetadata[key], actualMetadata[key]); drift != "" {
+			return drift
+		}
+	}
+
+	return ""
+}
+
+// findValueDrift compares a single desired value against its actual value.  The path is the
+// field path used for reporting while the pattern is the field path used to match ignored
+// fields.
+func findValueDrift(path, pattern string, desired, actual interface{}) string {
+	if ignoredFields[pattern] || isUnset(desired) {
+		return ""
+	}
+
+	// an empty value is equal to an absent value, as the server omits empty fields
+	if isEmpty(desired) && actual == nil {
+		return ""
+	}
+
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		actualValue, ok := actual.(map[string]interface{})
+		if !ok {
+			return path
+		}
+
+		for key, value := range desiredValue {
+			// an explicit null requests that the field is absent from the actual object, which
+			// allows keys to be removed from maps, as a merge patch removes null fields
+			if value == nil {
+				if actualValue[key] != nil && !ignoredFields[pattern+"."+key] {
+					return path + "." + key
+				}
+
+				continue
+			}
+
+			if drift := findValueDrift(path+"."+key, pattern+"."+key, value, actualValue[key]); drift != "" {
+				return drift
+			}
+		}
+
+		return ""
+	case []interface{}:
+		// arrays are owned by the desired object as a whole, so items which are added to or
+		// removed from the actual array are drift
+		actualValue, ok := actual.([]interface{})
+		if !ok || len(actualValue) != len(desiredValue) {
+			return path
+		}
+
+		for i := range desiredValue {
+			if drift := findValueDrift(
+				fmt.Sprintf("%s[%d]", path, i),
+				pattern+"[]",
+				desiredValue[i],
+				actualValue[i],
+			); drift != "" {
+				return drift
+			}
+		}
+
+		return ""
+	default:
+		if !scalarsAreEqual(pattern, desired, actual) {
+			return path
+		}
+
+		return ""
+	}
+}
+
+// isUnset determines if a desired value carries no intent and therefore should not be compared.
+// Empty maps and arrays are desired, e.g. to remove the items of an actual array.
+func isUnset(value interface{}) bool {
+	return value == nil
+}
+
+// isEmpty determines if a value is an empty map or array.
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+// scalarsAreEqual compares two scalar values.  Numbers are compared by value regardless of how
+// they were decoded and resource quantities are compared semantically, as the server stores
+// them in their canonical form (e.g. 0.5 is stored as 500m).
+func scalarsAreEqual(pattern string, desired, actual interface{}) bool {
+	if isQuantityField(pattern) {
+		desiredQuantity, desiredErr := resource.ParseQuantity(fmt.Sprint(desired))
+		actualQuantity, actualErr := resource.ParseQuantity(fmt.Sprint(actual))
+
+		if desiredErr == nil && actualErr == nil {
+			return desiredQuantity.Cmp(actualQuantity) == 0
+		}
+	}
+
+	if desiredNumber, ok := toFloat(desired); ok {
+		actualNumber, ok := toFloat(actual)
+
+		return ok && desiredNumber == actualNumber
+	}
+
+	return desired == actual
+}
+
+// isQuantityField determines if a field path refers to a resource quantity.
+func isQuantityField(pattern string) bool {
+	return strings.Contains(pattern, ".resources.limits.") ||
+		strings.Contains(pattern, ".resources.requests.") ||
+		strings.HasPrefix(pattern, "spec.hard.")
+}
+
+// toFloat converts any numeric value into a float64 for comparison.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,631 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"testing/quick"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/scottd018/demos/internal/resources"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// fixture is a generator for a desired object along with a function which applies the defaults
+// that the API server would populate for that kind of object.
+type fixture struct {
+	name     string
+	generate func(*rand.Rand) client.Object
+	defaults func(map[string]interface{})
+}
+
+var fixtures = []fixture{
+	{name: "Deployment", generate: generateDeployment, defaults: deploymentDefaults},
+	{name: "Service", generate: generateService, defaults: serviceDefaults},
+	{name: "Ingress", generate: generateIngress, defaults: ingressDefaults},
+}
+
+func randomString(rng *rand.Rand, prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, rng.Intn(1000))
+}
+
+func randomLabels(rng *rand.Rand) map[string]string {
+	labels := map[string]string{"app": randomString(rng, "webstore")}
+
+	for i := 0; i < rng.Intn(3); i++ {
+		labels[randomString(rng, "label")] = randomString(rng, "value")
+	}
+
+	return labels
+}
+
+func generateDeployment(rng *rand.Rand) client.Object {
+	labels := randomLabels(rng)
+	replicas := int32(rng.Intn(10))
+
+	container := corev1.Container{
+		Name:  randomString(rng, "container"),
+		Image: fmt.Sprintf("nginx:1.%d", rng.Intn(22)),
+		Ports: []corev1.ContainerPort{{ContainerPort: int32(1024 + rng.Intn(60000))}},
+	}
+
+	// use a non-canonical quantity so that canonicalization by the server is exercised
+	if rng.Intn(2) == 0 {
+		container.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse(fmt.Sprintf("0.%d", 1+rng.Intn(9))),
+		}
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: randomString(rng, "deploy"), Namespace: "default", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+			},
+		},
+	}
+}
+
+func generateService(rng *rand.Rand) client.Object {
+	port := int32(1 + rng.Intn(1000))
+
+	return &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: randomString(rng, "svc"), Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Selector: randomLabels(rng),
+			Ports: []corev1.ServicePort{{
+				Name:       randomString(rng, "http"),
+				Port:       port,
+				TargetPort: intstr.FromInt(1024 + rng.Intn(60000)),
+			}},
+		},
+	}
+}
+
+func generateIngress(rng *rand.Rand) client.Object {
+	return &networkingv1beta1.Ingress{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1beta1", Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        randomString(rng, "ing"),
+			Namespace:   "default",
+			Annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"},
+		},
+		Spec: networkingv1beta1.IngressSpec{
+			Rules: []networkingv1beta1.IngressRule{{
+				Host: fmt.Sprintf("%s.acme.com", randomString(rng, "app")),
+				IngressRuleValue: networkingv1beta1.IngressRuleValue{
+					HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+						Paths: []networkingv1beta1.HTTPIngressPath{{
+							Path: fmt.Sprintf("/%s", randomString(rng, "path")),
+							Backend: networkingv1beta1.IngressBackend{
+								ServiceName: randomString(rng, "svc"),
+								ServicePort: intstr.FromInt(1 + rng.Intn(1000)),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+// setDefault sets a field on an object only if the field is not already set, in the same manner
+// as defaulting by the API server.
+func setDefault(object map[string]interface{}, value interface{}, fields ...string) {
+	if _, found, _ := unstructured.NestedFieldNoCopy(object, fields...); !found {
+		_ = unstructured.SetNestedField(object, value, fields...)
+	}
+}
+
+// eachItem calls a function on every map item of an array within an object.
+func eachItem(object map[string]interface{}, do func(map[string]interface{}), fields ...string) {
+	items, _, _ := unstructured.NestedFieldNoCopy(object, fields...)
+	list, _ := items.([]interface{})
+
+	for _, item := range list {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			do(itemMap)
+		}
+	}
+}
+
+func deploymentDefaults(object map[string]interface{}) {
+	setDefault(object, int64(10), "spec", "revisionHistoryLimit")
+	setDefault(object, int64(600), "spec", "progressDeadlineSeconds")
+	setDefault(object, map[string]interface{}{
+		"type": "RollingUpdate",
+		"rollingUpdate": map[string]interface{}{
+			"maxSurge":       "25%",
+			"maxUnavailable": "25%",
+		},
+	}, "spec", "strategy")
+	setDefault(object, "Always", "spec", "template", "spec", "restartPolicy")
+	setDefault(object, "ClusterFirst", "spec", "template", "spec", "dnsPolicy")
+	setDefault(object, int64(30), "spec", "template", "spec", "terminationGracePeriodSeconds")
+
+	eachItem(object, func(container map[string]interface{}) {
+		setDefault(container, "/dev/termination-log", "terminationMessagePath")
+		setDefault(container, "IfNotPresent", "imagePullPolicy")
+
+		// the server stores quantities in their canonical form
+		if cpu, found, _ := unstructured.NestedString(container, "resources", "requests", "cpu"); found {
+			quantity := resource.MustParse(cpu)
+			_ = unstructured.SetNestedField(container, fmt.Sprintf("%dm", quantity.MilliValue()), "resources", "requests", "cpu")
+		}
+
+		eachItem(container, func(port map[string]interface{}) {
+			setDefault(port, "TCP", "protocol")
+		}, "ports")
+	}, "spec", "template", "spec", "containers")
+
+	object["status"] = map[string]interface{}{
+		"observedGeneration": int64(1),
+		"replicas":           int64(2),
+		"readyReplicas":      int64(2),
+	}
+}
+
+func serviceDefaults(object map[string]interface{}) {
+	setDefault(object, "ClusterIP", "spec", "type")
+	setDefault(object, "10.96.0.10", "spec", "clusterIP")
+	setDefault(object, []interface{}{"10.96.0.10"}, "spec", "clusterIPs")
+	setDefault(object, "None", "spec", "sessionAffinity")
+
+	eachItem(object, func(port map[string]interface{}) {
+		setDefault(port, "TCP", "protocol")
+	}, "spec", "ports")
+
+	object["status"] = map[string]interface{}{"loadBalancer": map[string]interface{}{}}
+}
+
+func ingressDefaults(object map[string]interface{}) {
+	eachItem(object, func(rule map[string]interface{}) {
+		eachItem(rule, func(path map[string]interface{}) {
+			setDefault(path, "ImplementationSpecific", "pathType")
+		}, "http", "paths")
+	}, "spec", "rules")
+
+	object["status"] = map[string]interface{}{
+		"loadBalancer": map[string]interface{}{
+			"ingress": []interface{}{map[string]interface{}{"ip": "192.0.2.10"}},
+		},
+	}
+}
+
+// serverState returns the object as it would be returned from the API server after being created
+// from the desired object.
+func serverState(t *testing.T, desired client.Object, f fixture) *unstructured.Unstructured {
+	t.Helper()
+
+	actual, err := resources.NewResourceFromClient(desired).ToUnstructured()
+	if err != nil {
+		t.Fatalf("unable to convert desired object to unstructured: %v", err)
+	}
+
+	actual.SetUID("4b2ec8f4-4b53-4bbd-9c2e-5a4f0a4c2c11")
+	actual.SetResourceVersion("12345")
+	actual.SetGeneration(1)
+	actual.SetCreationTimestamp(metav1.Now())
+	actual.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: resources.FieldManager, Operation: metav1.ManagedFieldsOperationUpdate}})
+
+	f.defaults(actual.Object)
+
+	return actual
+}
+
+// desiredLeaves returns the paths to every scalar field of the desired object which is owned by
+// the controller and therefore subject to drift detection.
+func desiredLeaves(t *testing.T, desired client.Object) [][]interface{} {
+	t.Helper()
+
+	object, err := resources.NewResourceFromClient(desired).ToUnstructured()
+	if err != nil {
+		t.Fatalf("unable to convert desired object to unstructured: %v", err)
+	}
+
+	var leaves [][]interface{}
+
+	var walk func(path []interface{}, value interface{})
+	walk = func(path []interface{}, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				walk(append(append([]interface{}{}, path...), key), item)
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(append(append([]interface{}{}, path...), i), item)
+			}
+		case nil:
+		default:
+			leaves = append(leaves, path)
+		}
+	}
+
+	walk([]interface{}{"spec"}, object.Object["spec"])
+
+	for _, field := range []string{"labels", "annotations"} {
+		values, _, _ := unstructured.NestedStringMap(object.Object, "metadata", field)
+		for key := range values {
+			leaves = append(leaves, []interface{}{"metadata", field, key})
+		}
+	}
+
+	return leaves
+}
+
+// mutateLeaf changes the scalar value at a path within an object.
+func mutateLeaf(object map[string]interface{}, path []interface{}) {
+	var parent interface{} = object
+
+	for _, element := range path[:len(path)-1] {
+		switch key := element.(type) {
+		case string:
+			parent = parent.(map[string]interface{})[key]
+		case int:
+			parent = parent.([]interface{})[key]
+		}
+	}
+
+	mutate := func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case string:
+			return v + "-drift"
+		case int64:
+			return v + 1
+		case float64:
+			return v + 1
+		case bool:
+			return !v
+		}
+
+		return value
+	}
+
+	switch key := path[len(path)-1].(type) {
+	case string:
+		parentMap := parent.(map[string]interface{})
+		parentMap[key] = mutate(parentMap[key])
+	case int:
+		parentList := parent.([]interface{})
+		parentList[key] = mutate(parentList[key])
+	}
+}
+
+func TestAreEqualIgnoresServerPopulatedFields(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	for _, f := range fixtures {
+		f := f
+		t.Run(f.name, func(t *testing.T) {
+			property := func(seed int64) bool {
+				desired := f.generate(rand.New(rand.NewSource(seed)))
+				actual := serverState(t, desired, f)
+
+				equal, err := resources.AreEqual(
+					*resources.NewResourceFromClient(desired, r),
+					*resources.NewResourceFromClient(actual, r),
+				)
+				if err != nil {
+					t.Fatalf("AreEqual() returned unexpected error: %v", err)
+				}
+
+				return equal
+			}
+
+			if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+				t.Errorf("desired object was not equal to its server state: %v", err)
+			}
+		})
+	}
+}
+
+func TestAreEqualDetectsDrift(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	for _, f := range fixtures {
+		f := f
+		t.Run(f.name, func(t *testing.T) {
+			property := func(seed int64) bool {
+				rng := rand.New(rand.NewSource(seed))
+				desired := f.generate(rng)
+				actual := serverState(t, desired, f)
+
+				leaves := desiredLeaves(t, desired)
+				leaf := leaves[rng.Intn(len(leaves))]
+				mutateLeaf(actual.Object, leaf)
+
+				equal, err := resources.AreEqual(
+					*resources.NewResourceFromClient(desired, r),
+					*resources.NewResourceFromClient(actual, r),
+				)
+				if err != nil {
+					t.Fatalf("AreEqual() returned unexpected error: %v", err)
+				}
+
+				if equal {
+					t.Logf("drift at %v was not detected", leaf)
+				}
+
+				return !equal
+			}
+
+			if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+				t.Errorf("drifted object was equal to its desired state: %v", err)
+			}
+		})
+	}
+}
+
+func TestAreEqualDetectsArrayDrift(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	arrays := map[string][]string{
+		"Deployment": {"spec", "template", "spec", "containers"},
+		"Service":    {"spec", "ports"},
+		"Ingress":    {"spec", "rules"},
+	}
+
+	for _, f := range fixtures {
+		f := f
+		t.Run(f.name, func(t *testing.T) {
+			property := func(seed int64) bool {
+				desired := f.generate(rand.New(rand.NewSource(seed)))
+				actual := serverState(t, desired, f)
+
+				// add an item to the actual array which the desired state does not contain
+				items, _, _ := unstructured.NestedSlice(actual.Object, arrays[f.name]...)
+				items = append(items, items[0])
+				if err := unstructured.SetNestedSlice(actual.Object, items, arrays[f.name]...); err != nil {
+					t.Fatalf("unable to set array: %v", err)
+				}
+
+				equal, err := resources.AreEqual(
+					*resources.NewResourceFromClient(desired, r),
+					*resources.NewResourceFromClient(actual, r),
+				)
+				if err != nil {
+					t.Fatalf("AreEqual() returned unexpected error: %v", err)
+				}
+
+				return !equal
+			}
+
+			if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+				t.Errorf("object with an extra array item was equal to its desired state: %v", err)
+			}
+		})
+	}
+}
+
+func TestAreEqualDetectsEmptyArrayDrift(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	arrays := map[string][]string{
+		"Deployment": {"spec", "template", "spec", "containers", "0", "ports"},
+		"Service":    {"spec", "ports"},
+		"Ingress":    {"spec", "rules"},
+	}
+
+	for _, f := range fixtures {
+		f := f
+		t.Run(f.name, func(t *testing.T) {
+			property := func(seed int64) bool {
+				desired := f.generate(rand.New(rand.NewSource(seed)))
+				actual := serverState(t, desired, f)
+
+				// the desired state empties an array which holds items on the actual object
+				emptied, err := resources.NewResourceFromClient(desired).ToUnstructured()
+				if err != nil {
+					t.Fatalf("unable to convert desired object: %v", err)
+				}
+
+				if err := setEmptyArray(emptied.Object, arrays[f.name]...); err != nil {
+					t.Fatalf("unable to empty array: %v", err)
+				}
+
+				equal, err := resources.AreEqual(
+					*resources.NewResourceFromClient(emptied, r),
+					*resources.NewResourceFromClient(actual, r),
+				)
+				if err != nil {
+					t.Fatalf("AreEqual() returned unexpected error: %v", err)
+				}
+
+				return !equal
+			}
+
+			if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+				t.Errorf("object with array items was equal to an empty desired array: %v", err)
+			}
+		})
+	}
+}
+
+// setEmptyArray sets an empty array at a path within an object.  Numeric path elements index into
+// arrays.
+func setEmptyArray(object map[string]interface{}, path ...string) error {
+	var parent interface{} = object
+
+	for _, element := range path[:len(path)-1] {
+		if index, err := strconv.Atoi(element); err == nil {
+			parent = parent.([]interface{})[index]
+
+			continue
+		}
+
+		parent = parent.(map[string]interface{})[element]
+	}
+
+	parentMap, ok := parent.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("parent of %v is not a map", path)
+	}
+
+	if items, ok := parentMap[path[len(path)-1]].([]interface{}); !ok || len(items) == 0 {
+		return fmt.Errorf("field %v does not hold array items", path)
+	}
+
+	parentMap[path[len(path)-1]] = []interface{}{}
+
+	return nil
+}
+
+func TestAreEqualEmptyDesiredValues(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	service := func(spec map[string]interface{}) *unstructured.Unstructured {
+		return newUnstructured(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "webstore-svc", "namespace": "default"},
+			"spec":       spec,
+		})
+	}
+
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		actual  map[string]interface{}
+		want    bool
+	}{
+		{
+			name:    "empty array is equal to an absent array",
+			desired: map[string]interface{}{"ports": []interface{}{}},
+			actual:  map[string]interface{}{},
+			want:    true,
+		},
+		{
+			name:    "empty array is not equal to an array with items",
+			desired: map[string]interface{}{"ports": []interface{}{}},
+			actual:  map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(80)}}},
+			want:    false,
+		},
+		{
+			name:    "empty map is equal to an absent map",
+			desired: map[string]interface{}{"selector": map[string]interface{}{}},
+			actual:  map[string]interface{}{},
+			want:    true,
+		},
+		{
+			name:    "empty map is not equal to a value which is not a map",
+			desired: map[string]interface{}{"selector": map[string]interface{}{}},
+			actual:  map[string]interface{}{"selector": "app=webstore"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			equal, err := resources.AreEqual(
+				*resources.NewResourceFromClient(service(tt.desired), r),
+				*resources.NewResourceFromClient(service(tt.actual), r),
+			)
+			if err != nil {
+				t.Fatalf("AreEqual() returned unexpected error: %v", err)
+			}
+
+			if equal != tt.want {
+				t.Errorf("AreEqual() = %v, want %v", equal, tt.want)
+			}
+		})
+	}
+}
+
+func TestAreEqualDoesNotModifyResources(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	desired := generateDeployment(rand.New(rand.NewSource(1)))
+	actual := serverState(t, desired, fixtures[0])
+	original := actual.DeepCopy()
+
+	if _, err := resources.AreEqual(
+		*resources.NewResourceFromClient(desired, r),
+		*resources.NewResourceFromClient(actual, r),
+	); err != nil {
+		t.Fatalf("AreEqual() returned unexpected error: %v", err)
+	}
+
+	if fmt.Sprint(actual.Object) != fmt.Sprint(original.Object) {
+		t.Errorf("AreEqual() modified the actual resource")
+	}
+}
+
+func TestAreEqualDetectsExplicitNull(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	service := func(selector map[string]interface{}) *unstructured.Unstructured {
+		return newUnstructured(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "webstore-svc", "namespace": "default"},
+			"spec":       map[string]interface{}{"selector": selector},
+		})
+	}
+
+	desired := service(map[string]interface{}{"app": "webstore", "slot": nil})
+
+	tests := []struct {
+		name   string
+		actual *unstructured.Unstructured
+		want   bool
+	}{
+		{
+			name:   "null field is absent",
+			actual: service(map[string]interface{}{"app": "webstore"}),
+			want:   true,
+		},
+		{
+			name:   "null field is present",
+			actual: service(map[string]interface{}{"app": "webstore", "slot": "blue"}),
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resources.AreEqual(
+				*resources.NewResourceFromClient(desired, r),
+				*resources.NewResourceFromClient(tt.actual, r),
+			)
+			if err != nil {
+				t.Fatalf("AreEqual() returned unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("AreEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
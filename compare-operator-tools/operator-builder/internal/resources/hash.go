@@ -0,0 +1,160 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/scottd018/demos/apis/common"
+)
+
+const (
+	// DesiredStateHashAnnotation is the annotation which stores the hash of the desired state of a
+	// child resource at the time that it was last persisted.
+	DesiredStateHashAnnotation = "apps.acme.com/desired-state-hash"
+
+	desiredStateHashLength = 16
+)
+
+// DesiredStateHash returns a hash of the desired state of a resource.  Only the fields which are
+// owned by the controller contribute to the hash, so that the hash is stable across fields that
+// are populated by the server.
+func (resource *Resource) DesiredStateHash() (string, error) {
+	object, err := resource.ToUnstructured()
+	if err != nil {
+		return "", err
+	}
+
+	// remove the fields which are not owned by the controller
+	delete(object.Object, "status")
+
+	metadata := map[string]interface{}{}
+
+	if labels := object.GetLabels(); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+
+	annotations := object.GetAnnotations()
+	delete(annotations, DesiredStateHashAnnotation)
+	delete(annotations, AdoptedAnnotation)
+
+	if len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+
+	object.Object["metadata"] = metadata
+
+	// json encoding of maps is sorted by key, so the encoded object is deterministic
+	content, err := json.Marshal(object.Object)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])[:desiredStateHashLength], nil
+}
+
+// SetDesiredStateHash calculates the hash of the desired state of a resource and stores it as an
+// annotation on the underlying resource object.
+func (resource *Resource) SetDesiredStateHash() (string, error) {
+	hash, err := resource.DesiredStateHash()
+	if err != nil {
+		return "", err
+	}
+
+	annotations := resource.Object.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[DesiredStateHashAnnotation] = hash
+	resource.Object.SetAnnotations(annotations)
+
+	return hash, nil
+}
+
+// DesiredStateIsPersisted determines if the desired state of a resource, as identified by its
+// hash, has already been persisted.  It requires the hash and generation recorded on the
+// component status to match the hash and generation of the current resource, as any change to
+// the spec of the current resource (e.g. a manual edit) will increment its generation.  Changes to
+// the labels and annotations do not increment the generation, so the desired labels and
+// annotations are compared against the current resource instead.  Resources which do not track
+// their generation are never considered persisted.
+func DesiredStateIsPersisted(component common.Component, desired, current *Resource, hash string) bool {
+	if current.Object.GetGeneration() == 0 {
+		return false
+	}
+
+	if current.Object.GetAnnotations()[DesiredStateHashAnnotation] != hash {
+		return false
+	}
+
+	generation, recorded := recordedGeneration(component, desired, hash)
+	if !recorded || generation != current.Object.GetGeneration() {
+		return false
+	}
+
+	return metadataIsPersisted(desired, current)
+}
+
+// metadataIsPersisted determines if the desired labels and annotations of a resource are set on
+// the current resource.
+func metadataIsPersisted(desired, current *Resource) bool {
+	desiredObject, err := desired.ToUnstructured()
+	if err != nil {
+		return false
+	}
+
+	currentObject, err := current.ToUnstructured()
+	if err != nil {
+		return false
+	}
+
+	return findMetadataDrift(desiredObject.Object["metadata"], currentObject.Object["metadata"]) == ""
+}
+
+// DesiredStateIsRecorded determines if the desired state of a resource, as identified by its hash,
+// has been recorded on the component status along with the generation that it was persisted at.
+// Resources of kinds which do not track their generation, such as ConfigMaps and Services, are
+// recorded without a generation, so this allows them to be ruled out before the current resource
+// is read.
+func DesiredStateIsRecorded(component common.Component, desired *Resource, hash string) bool {
+	generation, recorded := recordedGeneration(component, desired, hash)
+
+	return recorded && generation != 0
+}
+
+// recordedGeneration returns the generation that the desired state of a resource was persisted at,
+// as recorded on the component status, and whether the desired state has been recorded.
+func recordedGeneration(component common.Component, desired *Resource, hash string) (int64, bool) {
+	recorded := desired.ToCommonResource()
+
+	index := recorded.GetResourceIndex(component)
+	if index < 0 {
+		return 0, false
+	}
+
+	condition := component.GetResources()[index].ResourceCondition
+	if !condition.Created || condition.DesiredStateHash != hash {
+		return 0, false
+	}
+
+	return condition.ObservedGeneration, true
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources_test
+
+import (
+	"math/rand"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/resources"
+)
+
+func desiredStateHash(t *testing.T, object map[string]interface{}) string {
+	t.Helper()
+
+	hash, err := resources.NewResourceFromClient(newUnstructured(object)).DesiredStateHash()
+	if err != nil {
+		t.Fatalf("DesiredStateHash() returned unexpected error: %v", err)
+	}
+
+	return hash
+}
+
+func TestDesiredStateHash(t *testing.T) {
+	base := desiredStateHash(t, deploymentFixture(2, "nginx:1.17"))
+
+	withHashAnnotation := deploymentFixture(2, "nginx:1.17")
+	_ = unstructured.SetNestedStringMap(withHashAnnotation, map[string]string{
+		resources.DesiredStateHashAnnotation: "previous",
+	}, "metadata", "annotations")
+
+	withLabel := deploymentFixture(2, "nginx:1.17")
+	_ = unstructured.SetNestedStringMap(withLabel, map[string]string{"tier": "web"}, "metadata", "labels")
+
+	tests := []struct {
+		name   string
+		object map[string]interface{}
+		equal  bool
+	}{
+		{name: "identical objects have the same hash", object: deploymentFixture(2, "nginx:1.17"), equal: true},
+		{name: "server populated fields do not change the hash", object: withServerFields(deploymentFixture(2, "nginx:1.17")), equal: true},
+		{name: "the hash annotation does not change the hash", object: withHashAnnotation, equal: true},
+		{name: "a changed spec changes the hash", object: deploymentFixture(3, "nginx:1.17"), equal: false},
+		{name: "a changed label changes the hash", object: withLabel, equal: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := desiredStateHash(t, tt.object) == base; got != tt.equal {
+				t.Errorf("hashes equal = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+}
+
+func TestSetDesiredStateHash(t *testing.T) {
+	desired := generateDeployment(rand.New(rand.NewSource(1)))
+	resource := resources.NewResourceFromClient(desired)
+
+	hash, err := resource.SetDesiredStateHash()
+	if err != nil {
+		t.Fatalf("SetDesiredStateHash() returned unexpected error: %v", err)
+	}
+
+	if got := desired.GetAnnotations()[resources.DesiredStateHashAnnotation]; got != hash {
+		t.Errorf("annotation = %q, want %q", got, hash)
+	}
+
+	// setting the hash again must be idempotent
+	again, err := resource.SetDesiredStateHash()
+	if err != nil {
+		t.Fatalf("SetDesiredStateHash() returned unexpected error: %v", err)
+	}
+
+	if again != hash {
+		t.Errorf("hash changed after being set; got %q, want %q", again, hash)
+	}
+}
+
+func TestDesiredStateIsPersisted(t *testing.T) {
+	const hash = "0123456789abcdef"
+
+	labels := map[string]string{"app": "webstore"}
+
+	desiredObject := newUnstructured(deploymentFixture(2, "nginx:1.17"))
+	desiredObject.SetLabels(labels)
+	desired := resources.NewResourceFromClient(desiredObject)
+
+	current := func(annotation string, generation int64) *resources.Resource {
+		object := newUnstructured(withServerFields(deploymentFixture(2, "nginx:1.17")))
+		object.SetGeneration(generation)
+		object.SetLabels(labels)
+		object.SetAnnotations(map[string]string{resources.DesiredStateHashAnnotation: annotation})
+
+		return resources.NewResourceFromClient(object)
+	}
+
+	relabelled := func(labels map[string]string) *resources.Resource {
+		resource := current(hash, 3)
+		resource.Object.SetLabels(labels)
+
+		return resource
+	}
+
+	component := func(condition common.ResourceCondition) *appsv1alpha1.WebStore {
+		webstore := &appsv1alpha1.WebStore{ObjectMeta: metav1.ObjectMeta{Name: "webstore", Namespace: "default"}}
+		webstore.SetResource(common.Resource{
+			ResourceCommon:    desired.ToCommonResource().ResourceCommon,
+			ResourceCondition: condition,
+		})
+
+		return webstore
+	}
+
+	persisted := common.ResourceCondition{Created: true, DesiredStateHash: hash, ObservedGeneration: 3}
+
+	tests := []struct {
+		name      string
+		component *appsv1alpha1.WebStore
+		current   *resources.Resource
+		want      bool
+	}{
+		{
+			name:      "unchanged resource is persisted",
+			component: component(persisted),
+			current:   current(hash, 3),
+			want:      true,
+		},
+		{
+			name:      "resource without a status record is not persisted",
+			component: &appsv1alpha1.WebStore{},
+			current:   current(hash, 3),
+			want:      false,
+		},
+		{
+			name:      "resource with a changed desired state is not persisted",
+			component: component(common.ResourceCondition{Created: true, DesiredStateHash: "previous", ObservedGeneration: 3}),
+			current:   current(hash, 3),
+			want:      false,
+		},
+		{
+			name:      "resource with a changed generation is not persisted",
+			component: component(persisted),
+			current:   current(hash, 4),
+			want:      false,
+		},
+		{
+			name:      "resource with a changed annotation is not persisted",
+			component: component(persisted),
+			current:   current("previous", 3),
+			want:      false,
+		},
+		{
+			name:      "resource with a changed label is not persisted",
+			component: component(persisted),
+			current:   relabelled(map[string]string{"app": "drifted"}),
+			want:      false,
+		},
+		{
+			name:      "resource with a removed label is not persisted",
+			component: component(persisted),
+			current:   relabelled(nil),
+			want:      false,
+		},
+		{
+			name:      "resource with an additional label is persisted",
+			component: component(persisted),
+			current:   relabelled(map[string]string{"app": "webstore", "team": "a"}),
+			want:      true,
+		},
+		{
+			name:      "resource without a generation is not persisted",
+			component: component(common.ResourceCondition{Created: true, DesiredStateHash: hash}),
+			current:   current(hash, 0),
+			want:      false,
+		},
+		{
+			name:      "resource which has not been created is not persisted",
+			component: component(common.ResourceCondition{DesiredStateHash: hash, ObservedGeneration: 3}),
+			current:   current(hash, 3),
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resources.DesiredStateIsPersisted(tt.component, desired, tt.current, hash); got != tt.want {
+				t.Errorf("DesiredStateIsPersisted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDesiredStateIsRecorded(t *testing.T) {
+	const hash = "0123456789abcdef"
+
+	desired := resources.NewResourceFromClient(newUnstructured(deploymentFixture(2, "nginx:1.17")))
+
+	component := func(condition common.ResourceCondition) *appsv1alpha1.WebStore {
+		webstore := &appsv1alpha1.WebStore{ObjectMeta: metav1.ObjectMeta{Name: "webstore", Namespace: "default"}}
+		webstore.SetResource(common.Resource{
+			ResourceCommon:    desired.ToCommonResource().ResourceCommon,
+			ResourceCondition: condition,
+		})
+
+		return webstore
+	}
+
+	tests := []struct {
+		name      string
+		component *appsv1alpha1.WebStore
+		want      bool
+	}{
+		{
+			name:      "persisted resource is recorded",
+			component: component(common.ResourceCondition{Created: true, DesiredStateHash: hash, ObservedGeneration: 3}),
+			want:      true,
+		},
+		{
+			name:      "resource without a status record is not recorded",
+			component: &appsv1alpha1.WebStore{},
+			want:      false,
+		},
+		{
+			name:      "resource with a changed desired state is not recorded",
+			component: component(common.ResourceCondition{Created: true, DesiredStateHash: "previous", ObservedGeneration: 3}),
+			want:      false,
+		},
+		{
+			name:      "resource of a kind without a generation is not recorded",
+			component: component(common.ResourceCondition{Created: true, DesiredStateHash: hash}),
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resources.DesiredStateIsRecorded(tt.component, desired, hash); got != tt.want {
+				t.Errorf("DesiredStateIsRecorded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
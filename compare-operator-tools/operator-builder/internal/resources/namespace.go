@@ -17,7 +17,10 @@ limitations under the License.
 package resources
 
 import (
+	"fmt"
+
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/scottd018/demos/apis/common"
 )
@@ -60,5 +63,22 @@ func NamespaceForResourceIsReady(resource common.ComponentResource) (bool, error
 	namespace.Version = "v1"
 	namespace.Kind = NamespaceKind
 
-	return NamespaceIsReady(namespace)
+	ready, err := NamespaceIsReady(namespace)
+	if err != nil {
+		// namespaces are cluster-scoped, so a controller which is running with namespace-scoped
+		// permissions is unable to read them.  the namespace must exist for the controller to have
+		// permissions in it, so assume that it is ready and allow the persist to fail otherwise.
+		if errors.IsForbidden(err) {
+			resource.GetReconciler().GetLogger().V(4).Info(fmt.Sprintf(
+				"unable to read namespace [%s] with current permissions; assuming namespace is ready",
+				namespace.Name,
+			))
+
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	return ready, nil
 }
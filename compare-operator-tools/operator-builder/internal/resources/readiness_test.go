@@ -0,0 +1,474 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/resources"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+const (
+	testName      = "webstore"
+	testNamespace = "default"
+)
+
+type readinessTest struct {
+	name     string
+	existing []client.Object
+	want     bool
+	wantErr  bool
+}
+
+// runReadinessTests runs a set of readiness tests against a readiness function.  The resource
+// under test is always looked up by the test name and namespace.
+func runReadinessTests(
+	t *testing.T,
+	namespace string,
+	isReady func(common.ComponentResource) (bool, error),
+	tests []readinessTest,
+) {
+	t.Helper()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &resources.Resource{
+				Reconciler: testutil.NewFakeRequest(webStoreFixture(), tt.existing...),
+			}
+			resource.Name = testName
+			resource.Namespace = namespace
+
+			got, err := isReady(resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ready = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testMeta returns the object metadata for an object under test.
+func testMeta(namespace string, generation int64) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:       testName,
+		Namespace:  namespace,
+		Generation: generation,
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestNamespaceIsReady(t *testing.T) {
+	namespace := func(phase corev1.NamespacePhase) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: testMeta("", 0),
+			Status:     corev1.NamespaceStatus{Phase: phase},
+		}
+	}
+
+	runReadinessTests(t, "", resources.NamespaceIsReady, []readinessTest{
+		{name: "missing namespace is not ready", want: false},
+		{name: "active namespace is ready", existing: []client.Object{namespace(corev1.NamespaceActive)}, want: true},
+		{name: "terminating namespace is not ready", existing: []client.Object{namespace(corev1.NamespaceTerminating)}, want: false},
+		{name: "namespace without a phase is not ready", existing: []client.Object{namespace("")}, want: false},
+	})
+}
+
+// namespaceErrorClient is a client which returns an error when reading namespaces, e.g. as a
+// client with only namespace-scoped permissions would.
+type namespaceErrorClient struct {
+	client.Client
+	err error
+}
+
+func (c namespaceErrorClient) Get(ctx context.Context, key types.NamespacedName, obj client.Object) error {
+	if _, ok := obj.(*corev1.Namespace); ok {
+		return c.err
+	}
+
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestNamespaceForResourceIsReady(t *testing.T) {
+	active := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: testNamespace},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+
+	tests := []struct {
+		name     string
+		existing []client.Object
+		err      error
+		want     bool
+		wantErr  bool
+	}{
+		{name: "resource in an active namespace is ready", existing: []client.Object{active}, want: true},
+		{name: "resource in a missing namespace is not ready", want: false},
+		{
+			name: "resource in a namespace which is forbidden from being read is ready",
+			err:  apierrs.NewForbidden(corev1.Resource("namespaces"), testNamespace, errors.New("cluster-scoped")),
+			want: true,
+		},
+		{
+			name:    "resource in a namespace which is unable to be read returns an error",
+			err:     apierrs.NewInternalError(errors.New("unavailable")),
+			want:    false,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := testutil.NewFakeRequest(webStoreFixture(), tt.existing...)
+			if tt.err != nil {
+				r.Client = namespaceErrorClient{Client: r.Client, err: tt.err}
+			}
+
+			resource := &resources.Resource{Reconciler: r}
+			resource.Name = testName
+			resource.Namespace = testNamespace
+
+			got, err := resources.NamespaceForResourceIsReady(resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ready = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomResourceDefinitionIsReady(t *testing.T) {
+	crd := &extensionsv1.CustomResourceDefinition{ObjectMeta: testMeta("", 0)}
+
+	runReadinessTests(t, "", resources.CustomResourceDefinitionIsReady, []readinessTest{
+		{name: "missing custom resource definition is not ready", want: false},
+		{name: "existing custom resource definition is ready", existing: []client.Object{crd}, want: true},
+	})
+}
+
+func TestSecretIsReady(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: testMeta(testNamespace, 0),
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("")},
+	}
+
+	isReady := func(keys ...string) func(common.ComponentResource) (bool, error) {
+		return func(resource common.ComponentResource) (bool, error) {
+			return resources.SecretIsReady(resource, keys...)
+		}
+	}
+
+	runReadinessTests(t, testNamespace, isReady(), []readinessTest{
+		{name: "missing secret is not ready", want: false},
+		{name: "existing secret is ready", existing: []client.Object{secret}, want: true},
+	})
+	runReadinessTests(t, testNamespace, isReady("username"), []readinessTest{
+		{name: "secret with expected keys is ready", existing: []client.Object{secret}, want: true},
+	})
+	runReadinessTests(t, testNamespace, isReady("username", "password"), []readinessTest{
+		{name: "secret with an empty expected key is not ready", existing: []client.Object{secret}, want: false},
+	})
+}
+
+func TestConfigMapIsReady(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: testMeta(testNamespace, 0),
+		Data:       map[string]string{"nginx.conf": "events {}", "empty": ""},
+	}
+
+	isReady := func(keys ...string) func(common.ComponentResource) (bool, error) {
+		return func(resource common.ComponentResource) (bool, error) {
+			return resources.ConfigMapIsReady(resource, keys...)
+		}
+	}
+
+	runReadinessTests(t, testNamespace, isReady(), []readinessTest{
+		{name: "missing config map is not ready", want: false},
+		{name: "existing config map is ready", existing: []client.Object{configMap}, want: true},
+	})
+	runReadinessTests(t, testNamespace, isReady("nginx.conf"), []readinessTest{
+		{name: "config map with expected keys is ready", existing: []client.Object{configMap}, want: true},
+	})
+	runReadinessTests(t, testNamespace, isReady("nginx.conf", "empty"), []readinessTest{
+		{name: "config map with an empty expected key is not ready", existing: []client.Object{configMap}, want: false},
+	})
+}
+
+func TestConfigMapIsReadyExpectsDesiredKeys(t *testing.T) {
+	existing := &corev1.ConfigMap{
+		ObjectMeta: testMeta(testNamespace, 0),
+		Data:       map[string]string{"nginx.conf": "events {}"},
+	}
+
+	desired := func(keys ...string) *resources.Resource {
+		data := map[string]interface{}{}
+		for _, key := range keys {
+			data[key] = "events {}"
+		}
+
+		return resources.NewResourceFromClient(newUnstructured(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": testName, "namespace": testNamespace},
+			"data":       data,
+		}), testutil.NewFakeRequest(webStoreFixture(), existing))
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		want bool
+	}{
+		{name: "config map with the desired keys is ready", keys: []string{"nginx.conf"}, want: true},
+		{name: "config map without a desired key is not ready", keys: []string{"nginx.conf", "mime.types"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := desired(tt.keys...).IsReady()
+			if err != nil {
+				t.Fatalf("IsReady() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("IsReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeploymentIsReady(t *testing.T) {
+	deployment := func(generation int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: testMeta(testNamespace, generation), Status: status}
+	}
+
+	runReadinessTests(t, testNamespace, resources.DeploymentIsReady, []readinessTest{
+		{name: "missing deployment is not ready", want: false},
+		{
+			name:     "deployment with all replicas ready is ready",
+			existing: []client.Object{deployment(2, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, ReadyReplicas: 2})},
+			want:     true,
+		},
+		{
+			name:     "deployment with an unobserved generation is not ready",
+			existing: []client.Object{deployment(3, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, ReadyReplicas: 2})},
+			want:     false,
+		},
+		{
+			name:     "deployment with unready replicas is not ready",
+			existing: []client.Object{deployment(2, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, ReadyReplicas: 1})},
+			want:     false,
+		},
+	})
+}
+
+func TestDaemonSetIsReady(t *testing.T) {
+	daemonSet := func(status appsv1.DaemonSetStatus) *appsv1.DaemonSet {
+		return &appsv1.DaemonSet{ObjectMeta: testMeta(testNamespace, 0), Status: status}
+	}
+
+	runReadinessTests(t, testNamespace, resources.DaemonSetIsReady, []readinessTest{
+		{name: "missing daemonset is not ready", want: false},
+		{
+			name:     "daemonset with all pods scheduled and ready is ready",
+			existing: []client.Object{daemonSet(appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 3})},
+			want:     true,
+		},
+		{
+			name:     "daemonset with unready pods is not ready",
+			existing: []client.Object{daemonSet(appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 2})},
+			want:     false,
+		},
+		{
+			name:     "daemonset with no ready pods is not ready",
+			existing: []client.Object{daemonSet(appsv1.DaemonSetStatus{})},
+			want:     false,
+		},
+		{
+			name: "daemonset with unavailable pods is not ready",
+			existing: []client.Object{daemonSet(appsv1.DaemonSetStatus{
+				DesiredNumberScheduled: 3, NumberReady: 3, NumberUnavailable: 1,
+			})},
+			want: false,
+		},
+	})
+}
+
+func TestStatefulSetIsReady(t *testing.T) {
+	statefulSet := func(generation int64, replicas *int32, status appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: testMeta(testNamespace, generation),
+			Spec:       appsv1.StatefulSetSpec{Replicas: replicas},
+			Status:     status,
+		}
+	}
+
+	isReady := func(resource common.ComponentResource) (bool, error) {
+		return resources.StatefulSetIsReady(resource)
+	}
+
+	runReadinessTests(t, testNamespace, isReady, []readinessTest{
+		{name: "missing statefulset is not ready", want: false},
+		{
+			name: "statefulset with all replicas ready is ready",
+			existing: []client.Object{statefulSet(1, int32Ptr(3), appsv1.StatefulSetStatus{
+				ObservedGeneration: 1, Replicas: 3, ReadyReplicas: 3, UpdatedReplicas: 3,
+			})},
+			want: true,
+		},
+		{
+			name: "statefulset with an unobserved generation is not ready",
+			existing: []client.Object{statefulSet(2, int32Ptr(3), appsv1.StatefulSetStatus{
+				ObservedGeneration: 1, Replicas: 3, ReadyReplicas: 3,
+			})},
+			want: false,
+		},
+		{
+			name: "statefulset without replicas is not ready",
+			existing: []client.Object{statefulSet(1, nil, appsv1.StatefulSetStatus{
+				ObservedGeneration: 1,
+			})},
+			want: false,
+		},
+		{
+			name: "statefulset with unready replicas is not ready",
+			existing: []client.Object{statefulSet(1, int32Ptr(3), appsv1.StatefulSetStatus{
+				ObservedGeneration: 1, Replicas: 3, ReadyReplicas: 2,
+			})},
+			want: false,
+		},
+		{
+			name: "statefulset which is scaling down is not ready",
+			existing: []client.Object{statefulSet(1, int32Ptr(2), appsv1.StatefulSetStatus{
+				ObservedGeneration: 1, Replicas: 3, ReadyReplicas: 3,
+			})},
+			want: false,
+		},
+	})
+}
+
+func TestJobIsReady(t *testing.T) {
+	completed := metav1.Now()
+
+	job := func(status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{ObjectMeta: testMeta(testNamespace, 0), Status: status}
+	}
+
+	runReadinessTests(t, testNamespace, resources.JobIsReady, []readinessTest{
+		{name: "missing job is not ready", want: false},
+		{
+			name:     "active job is not ready",
+			existing: []client.Object{job(batchv1.JobStatus{Active: 1})},
+			want:     false,
+		},
+		{
+			name:     "job without a completion time is not ready",
+			existing: []client.Object{job(batchv1.JobStatus{})},
+			want:     false,
+		},
+		{
+			name:     "successfully completed job is ready",
+			existing: []client.Object{job(batchv1.JobStatus{CompletionTime: &completed, Succeeded: 1})},
+			want:     true,
+		},
+		{
+			name:     "unsuccessfully completed job returns an error",
+			existing: []client.Object{job(batchv1.JobStatus{CompletionTime: &completed, Failed: 1})},
+			want:     false,
+			wantErr:  true,
+		},
+	})
+}
+
+func TestServiceIsReady(t *testing.T) {
+	service := func(spec corev1.ServiceSpec, status corev1.ServiceStatus) *corev1.Service {
+		return &corev1.Service{ObjectMeta: testMeta(testNamespace, 0), Spec: spec, Status: status}
+	}
+
+	runReadinessTests(t, testNamespace, resources.ServiceIsReady, []readinessTest{
+		{name: "missing service is not ready", want: false},
+		{
+			name: "external name service is ready",
+			existing: []client.Object{service(corev1.ServiceSpec{
+				Type: corev1.ServiceTypeExternalName, ExternalName: "webstore.acme.com",
+			}, corev1.ServiceStatus{})},
+			want: true,
+		},
+		{
+			name: "cluster ip service with an address is ready",
+			existing: []client.Object{service(corev1.ServiceSpec{
+				Type: corev1.ServiceTypeClusterIP, ClusterIP: "10.0.0.10",
+			}, corev1.ServiceStatus{})},
+			want: true,
+		},
+		{
+			name: "headless service is ready",
+			existing: []client.Object{service(corev1.ServiceSpec{
+				Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone,
+			}, corev1.ServiceStatus{})},
+			want: true,
+		},
+		{
+			name: "cluster ip service without an address is not ready",
+			existing: []client.Object{service(corev1.ServiceSpec{
+				Type: corev1.ServiceTypeClusterIP,
+			}, corev1.ServiceStatus{})},
+			want: false,
+		},
+		{
+			name: "load balancer service without an ingress is not ready",
+			existing: []client.Object{service(corev1.ServiceSpec{
+				Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.10",
+			}, corev1.ServiceStatus{})},
+			want: false,
+		},
+		{
+			name: "load balancer service with an ingress is ready",
+			existing: []client.Object{service(corev1.ServiceSpec{
+				Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.10",
+			}, corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}},
+				},
+			})},
+			want: true,
+		},
+	})
+}
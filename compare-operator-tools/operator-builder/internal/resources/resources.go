@@ -17,15 +17,11 @@ limitations under the License.
 package resources
 
 import (
+	"encoding/json"
 	"fmt"
 
-	"github.com/banzaicloud/k8s-objectmatcher/patch"
-	"github.com/banzaicloud/operator-tools/pkg/reconciler"
-	"github.com/imdario/mergo"
-
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,12 +38,16 @@ func (resource *Resource) Create() error {
 	resource.Reconciler.GetLogger().V(0).Info(fmt.Sprintf("creating resource; kind: [%s], name: [%s], namespace: [%s]",
 		resource.Kind, resource.Name, resource.Namespace))
 
+	if object, ok := resource.Object.(*unstructured.Unstructured); ok {
+		RemoveNullFields(object.Object)
+	}
+
 	if err := resource.Reconciler.Create(
 		resource.Reconciler.GetContext(),
 		resource.Object,
 		&client.CreateOptions{FieldManager: FieldManager},
 	); err != nil {
-		return fmt.Errorf("unable to create resource; %v", err)
+		return fmt.Errorf("unable to create resource; %w", err)
 	}
 
 	return nil
@@ -61,6 +61,15 @@ func (resource *Resource) Update(oldResource *Resource) error {
 	}
 
 	if needsUpdate {
+		replace, err := SelectorIsChanged(*resource, *oldResource)
+		if err != nil {
+			return err
+		}
+
+		if replace {
+			return resource.Replace(oldResource)
+		}
+
 		resource.Reconciler.GetLogger().V(0).Info(fmt.Sprintf("updating resource; kind: [%s], name: [%s], namespace: [%s]",
 			resource.Kind, resource.Name, resource.Namespace))
 
@@ -70,7 +79,7 @@ func (resource *Resource) Update(oldResource *Resource) error {
 			client.Merge,
 			&client.PatchOptions{FieldManager: FieldManager},
 		); err != nil {
-			return fmt.Errorf("unable to update resource; %v", err)
+			return fmt.Errorf("unable to update resource; %w", err)
 		}
 	}
 
@@ -98,14 +107,43 @@ func NewResourceFromClient(resource client.Object, reconciler ...common.Componen
 	return newResource
 }
 
-// ToUnstructured returns an unstructured representation of a Resource.
+// ToUnstructured returns an unstructured representation of a Resource.  The returned object is
+// always a copy, so it is safe to modify without affecting the underlying resource object.
 func (resource *Resource) ToUnstructured() (*unstructured.Unstructured, error) {
-	innerObject, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&resource.Object)
+	// round trip through json rather than using the unstructured converter, as the
+	// converter returns the underlying content of unstructured objects rather than a copy and
+	// unstructured objects which are built in memory may contain non-json types (e.g. int)
+	content, err := json.Marshal(resource.Object)
 	if err != nil {
 		return nil, err
 	}
 
-	return &unstructured.Unstructured{Object: innerObject}, nil
+	object := &unstructured.Unstructured{}
+	if err := object.UnmarshalJSON(content); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// RemoveNullFields removes the fields of an object which are explicitly null.  A null field in a
+// desired resource requests that the field is absent, which a merge patch honors on update, but
+// which must be removed before the resource is created.
+func RemoveNullFields(object map[string]interface{}) {
+	for key, value := range object {
+		switch v := value.(type) {
+		case nil:
+			delete(object, key)
+		case map[string]interface{}:
+			RemoveNullFields(v)
+		case []interface{}:
+			for _, item := range v {
+				if itemObject, ok := item.(map[string]interface{}); ok {
+					RemoveNullFields(itemObject)
+				}
+			}
+		}
+	}
 }
 
 // ToCommonResource converts a resources.Resource into a common API resource.
@@ -134,7 +172,12 @@ func (resource *Resource) IsReady() (bool, error) {
 	case SecretKind:
 		return SecretIsReady(resource)
 	case ConfigMapKind:
-		return ConfigMapIsReady(resource)
+		keys, err := configMapKeys(resource)
+		if err != nil {
+			return false, err
+		}
+
+		return ConfigMapIsReady(resource, keys...)
 	case DeploymentKind:
 		return DeploymentIsReady(resource)
 	case DaemonSetKind:
@@ -163,60 +206,32 @@ func AreReady(resources ...common.ComponentResource) (bool, error) {
 	return true, nil
 }
 
-// AreEqual determines if two resources are equal.
+// AreEqual determines if two resources are equal.  Only the fields which are set on the desired
+// resource are compared against the actual resource, so that defaults and other fields which are
+// populated by the server are not seen as differences.
 func AreEqual(desired, actual Resource) (bool, error) {
-	mergedResource, err := actual.ToUnstructured()
+	drift, err := Drift(desired, actual)
 	if err != nil {
 		return false, err
 	}
 
-	actualResource, err := actual.ToUnstructured()
-	if err != nil {
-		return false, err
-	}
+	return drift == "", nil
+}
 
+// Drift returns the path of the first field of the desired resource which differs from the actual
+// resource, or an empty string if the actual resource matches the desired resource.
+func Drift(desired, actual Resource) (string, error) {
 	desiredResource, err := desired.ToUnstructured()
 	if err != nil {
-		return false, err
-	}
-
-	// ensure that resource versions and observed generation do not interfere
-	// with calculating equality
-	desiredResource.SetResourceVersion(actualResource.GetResourceVersion())
-	desiredResource.SetGeneration(actualResource.GetGeneration())
-
-	// ensure that a current cluster-scoped resource is not evaluated against
-	// a manifest which may include a namespace
-	if actualResource.GetNamespace() == "" {
-		desiredResource.SetNamespace(actualResource.GetNamespace())
+		return "", err
 	}
 
-	// merge the overrides from the desired resource into the actual resource
-	mergo.Merge(
-		&mergedResource.Object,
-		desiredResource.Object,
-		mergo.WithOverride,
-		mergo.WithSliceDeepCopy,
-	)
-
-	// calculate the actual differences
-	diffOptions := []patch.CalculateOption{
-		reconciler.IgnoreManagedFields(),
-		patch.IgnoreStatusFields(),
-		patch.IgnoreVolumeClaimTemplateTypeMetaAndStatus(),
-		patch.IgnorePDBSelector(),
-	}
-
-	diffResults, err := patch.DefaultPatchMaker.Calculate(
-		actualResource,
-		mergedResource,
-		diffOptions...,
-	)
+	actualResource, err := actual.ToUnstructured()
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	return diffResults.IsEmpty(), nil
+	return findDrift(desiredResource.Object, actualResource.Object), nil
 }
 
 // NeedsUpdate determines if a resource needs to be updated.
@@ -224,9 +239,9 @@ func NeedsUpdate(desired, actual Resource) (bool, error) {
 	// check for equality first as this will let us avoid spamming user logs
 	// when resources that need to be skipped explicitly (e.g. CRDs) are seen
 	// as equal anyway
-	equal, err := AreEqual(desired, actual)
-	if equal || err != nil {
-		return !equal, err
+	drift, err := Drift(desired, actual)
+	if drift == "" || err != nil {
+		return err != nil, err
 	}
 
 	// always skip custom resource updates as they are sensitive to modification
@@ -245,6 +260,9 @@ func NeedsUpdate(desired, actual Resource) (bool, error) {
 		return false, nil
 	}
 
+	desired.Reconciler.GetLogger().V(4).Info(fmt.Sprintf("resource has drifted from desired state; kind: [%s], name: [%s], namespace: [%s], field: [%s]",
+		desired.Kind, desired.Name, desired.Namespace, drift))
+
 	return true, nil
 }
 
@@ -305,13 +323,11 @@ func getObject(source common.ComponentResource, destination client.Object, allow
 		Namespace: source.GetNamespace(),
 	}
 	if err := source.GetReconciler().Get(source.GetReconciler().GetContext(), namespacedName, destination); err != nil {
-		if allowMissing {
-			if errors.IsNotFound(err) {
-				return nil
-			}
-		} else {
-			return err
+		if allowMissing && errors.IsNotFound(err) {
+			return nil
 		}
+
+		return err
 	}
 
 	return nil
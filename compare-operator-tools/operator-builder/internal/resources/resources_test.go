@@ -0,0 +1,258 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/internal/resources"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// webStoreFixture returns the WebStore which the resources under test belong to.
+func webStoreFixture() *appsv1alpha1.WebStore {
+	return testutil.WebStoreFixture(testName, appsv1alpha1.WebStoreSpec{})
+}
+
+// newUnstructured returns an unstructured object from a map for use as a test fixture.
+func newUnstructured(object map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: object}
+}
+
+// deploymentFixture returns an unstructured deployment with the provided replicas and image.
+func deploymentFixture(replicas int64, image string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "webstore-deploy",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "webstore"},
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": "webstore"},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "webstore-container",
+							"image": image,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// withServerFields returns a copy of an unstructured fixture with fields that are populated by
+// the API server.
+func withServerFields(object map[string]interface{}) map[string]interface{} {
+	actual := runtime.DeepCopyJSON(object)
+
+	_ = unstructured.SetNestedField(actual, "12345", "metadata", "resourceVersion")
+	_ = unstructured.SetNestedField(actual, "a1b2c3", "metadata", "uid")
+	_ = unstructured.SetNestedField(actual, int64(3), "metadata", "generation")
+	_ = unstructured.SetNestedField(actual, map[string]interface{}{"readyReplicas": int64(2)}, "status")
+
+	return actual
+}
+
+func TestAreEqual(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		actual  map[string]interface{}
+		want    bool
+	}{
+		{
+			name:    "identical resources are equal",
+			desired: deploymentFixture(2, "nginx:1.17"),
+			actual:  deploymentFixture(2, "nginx:1.17"),
+			want:    true,
+		},
+		{
+			name:    "server populated fields are ignored",
+			desired: deploymentFixture(2, "nginx:1.17"),
+			actual:  withServerFields(deploymentFixture(2, "nginx:1.17")),
+			want:    true,
+		},
+		{
+			name:    "changed replicas are not equal",
+			desired: deploymentFixture(3, "nginx:1.17"),
+			actual:  withServerFields(deploymentFixture(2, "nginx:1.17")),
+			want:    false,
+		},
+		{
+			name:    "changed image is not equal",
+			desired: deploymentFixture(2, "nginx:1.21"),
+			actual:  withServerFields(deploymentFixture(2, "nginx:1.17")),
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			desired := resources.NewResourceFromClient(newUnstructured(tt.desired), r)
+			actual := resources.NewResourceFromClient(newUnstructured(tt.actual), r)
+
+			got, err := resources.AreEqual(*desired, *actual)
+			if err != nil {
+				t.Fatalf("AreEqual() returned unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("AreEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	crdFixture := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]interface{}{
+				"name": "webstores.apps.acme.com",
+			},
+			"spec": map[string]interface{}{
+				"group": "apps.acme.com",
+				"names": map[string]interface{}{
+					"kind": description,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		actual  map[string]interface{}
+		want    bool
+	}{
+		{
+			name:    "equal resources do not need an update",
+			desired: deploymentFixture(2, "nginx:1.17"),
+			actual:  withServerFields(deploymentFixture(2, "nginx:1.17")),
+			want:    false,
+		},
+		{
+			name:    "changed resources need an update",
+			desired: deploymentFixture(3, "nginx:1.17"),
+			actual:  withServerFields(deploymentFixture(2, "nginx:1.17")),
+			want:    true,
+		},
+		{
+			name:    "changed custom resource definitions are never updated",
+			desired: crdFixture("WebStore"),
+			actual:  crdFixture("OldWebStore"),
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			desired := resources.NewResourceFromClient(newUnstructured(tt.desired), r)
+			actual := resources.NewResourceFromClient(newUnstructured(tt.actual), r)
+
+			got, err := resources.NeedsUpdate(*desired, *actual)
+			if err != nil {
+				t.Fatalf("NeedsUpdate() returned unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("NeedsUpdate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorIsChanged(t *testing.T) {
+	r := testutil.NewFakeRequest(webStoreFixture())
+
+	withSelector := func(object map[string]interface{}, selector map[string]interface{}) map[string]interface{} {
+		_ = unstructured.SetNestedField(object, selector, "spec", "selector")
+
+		return object
+	}
+
+	excludingSlots := map[string]interface{}{
+		"matchLabels": map[string]interface{}{"app": "webstore"},
+		"matchExpressions": []interface{}{
+			map[string]interface{}{"key": "apps.acme.com/slot", "operator": "DoesNotExist"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		actual  map[string]interface{}
+		want    bool
+	}{
+		{
+			name:    "unchanged selectors do not need a replacement",
+			desired: deploymentFixture(3, "nginx:1.17"),
+			actual:  withServerFields(deploymentFixture(2, "nginx:1.17")),
+			want:    false,
+		},
+		{
+			name:    "changed selectors need a replacement",
+			desired: withSelector(deploymentFixture(2, "nginx:1.17"), excludingSlots),
+			actual:  withServerFields(deploymentFixture(2, "nginx:1.17")),
+			want:    true,
+		},
+		{
+			name:    "resources without a persisted selector do not need a replacement",
+			desired: deploymentFixture(2, "nginx:1.17"),
+			actual:  withSelector(deploymentFixture(2, "nginx:1.17"), nil),
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			desired := resources.NewResourceFromClient(newUnstructured(tt.desired), r)
+			actual := resources.NewResourceFromClient(newUnstructured(tt.actual), r)
+
+			got, err := resources.SelectorIsChanged(*desired, *actual)
+			if err != nil {
+				t.Fatalf("SelectorIsChanged() returned unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("SelectorIsChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
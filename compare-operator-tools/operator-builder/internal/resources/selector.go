@@ -0,0 +1,102 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// immutableSelectorKinds are the kinds of workload resources whose selector may not be changed once
+// they have been created.
+var immutableSelectorKinds = map[string]bool{
+	DeploymentKind:  true,
+	StatefulSetKind: true,
+	DaemonSetKind:   true,
+}
+
+// SelectorIsChanged determines if the desired selector of a workload resource differs from the
+// immutable selector of the resource in the cluster, in which case the resource must be replaced
+// rather than updated.
+func SelectorIsChanged(desired, actual Resource) (bool, error) {
+	if !immutableSelectorKinds[desired.Kind] {
+		return false, nil
+	}
+
+	desiredObject, err := desired.ToUnstructured()
+	if err != nil {
+		return false, err
+	}
+
+	actualObject, err := actual.ToUnstructured()
+	if err != nil {
+		return false, err
+	}
+
+	desiredSelector, _, err := unstructured.NestedFieldNoCopy(desiredObject.Object, "spec", "selector")
+	if err != nil {
+		return false, fmt.Errorf("unable to read selector of resource %s; %w", desired.Name, err)
+	}
+
+	actualSelector, _, err := unstructured.NestedFieldNoCopy(actualObject.Object, "spec", "selector")
+	if err != nil {
+		return false, fmt.Errorf("unable to read selector of resource %s; %w", actual.Name, err)
+	}
+
+	// a resource which has not yet been created has no selector to replace
+	if actualSelector == nil {
+		return false, nil
+	}
+
+	return !equality.Semantic.DeepEqual(desiredSelector, actualSelector), nil
+}
+
+// Replace replaces a resource in the cluster whose immutable selector has changed.  The resource
+// is deleted without its dependents, so that its pods keep running until the replacement adopts
+// them.  The replacement is created once the deletion has completed, so an error is returned
+// while the resource is being deleted in order to retry.
+func (resource *Resource) Replace(oldResource *Resource) error {
+	if oldResource.Object.GetDeletionTimestamp() == nil {
+		resource.Reconciler.GetLogger().V(0).Info(fmt.Sprintf(
+			"replacing resource with changed selector; kind: [%s], name: [%s], namespace: [%s]",
+			resource.Kind, resource.Name, resource.Namespace,
+		))
+
+		if err := resource.Reconciler.GetClient().Delete(
+			resource.Reconciler.GetContext(),
+			oldResource.Object,
+			client.PropagationPolicy(metav1.DeletePropagationOrphan),
+		); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("unable to delete resource for replacement; %w", err)
+		}
+	}
+
+	if err := resource.Create(); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("unable to replace resource %s until its deletion has completed; %w", resource.Name, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
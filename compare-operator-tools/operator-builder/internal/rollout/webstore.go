@@ -0,0 +1,211 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/common"
+	"github.com/scottd018/demos/internal/controllers/phases"
+)
+
+const (
+	// AbortPhaseName is the name that the WebStoreAbortPhase is registered with.
+	AbortPhaseName = "RolloutAbortPhase"
+
+	// PromotePhaseName is the name that the WebStorePromotePhase is registered with.
+	PromotePhaseName = "RolloutPromotePhase"
+)
+
+// WebStoreAbortPhase tracks whether the rollout of a new WebStore revision has been aborted.  It
+// runs before the child resources are persisted, as an aborted revision may never become ready.
+// The child resources of an aborted revision are scaled down when they are rendered.
+type WebStoreAbortPhase struct{}
+
+// WebStorePromotePhase promotes a new WebStore revision once the child resources are ready, by
+// recording it as the current revision and switching the active slot.  It also completes the switch
+// of a WebStore which is served in place to a staged strategy, once the active slot is ready.
+type WebStorePromotePhase struct{}
+
+// WebStoreAbortPhase.DefaultRequeue returns the default result to requeue the phase.
+func (phase *WebStoreAbortPhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+// WebStoreAbortPhase.Execute records whether a new revision is progressing or has been aborted.
+func (phase *WebStoreAbortPhase) Execute(r common.ComponentReconciler) (proceedToNextPhase bool, err error) {
+	component, err := getWebStore(r)
+	if err != nil {
+		return false, err
+	}
+
+	candidate, err := component.CandidateRevision()
+	if err != nil {
+		return false, err
+	}
+
+	if candidate == nil {
+		return true, nil
+	}
+
+	rollout := component.Spec.Rollout
+	status := &component.Status.Rollout
+
+	switch {
+	case rollout.Abort && rollout.Strategy.IsStaged():
+		if status.Phase == appsv1alpha1.RolloutPhaseAborted {
+			return true, nil
+		}
+
+		r.GetLogger().V(0).Info(fmt.Sprintf("aborted rollout of revision [%s]; revision [%s] remains current",
+			candidate.Name, status.CurrentRevision.Name))
+
+		status.Phase = appsv1alpha1.RolloutPhaseAborted
+	case status.Phase == appsv1alpha1.RolloutPhaseProgressing, status.Phase == appsv1alpha1.RolloutPhasePaused:
+		// the promote phase moves a progressing revision forward once it is ready
+		return true, nil
+	default:
+		status.Phase = appsv1alpha1.RolloutPhaseProgressing
+	}
+
+	return true, nil
+}
+
+// WebStorePromotePhase.DefaultRequeue returns the default result to requeue the phase.
+func (phase *WebStorePromotePhase) DefaultRequeue() ctrl.Result {
+	return phases.Requeue()
+}
+
+// WebStorePromotePhase.Execute promotes the revision desired by the spec once the child resources
+// are ready.  The child resources of a staged rollout are rendered and persisted again after the
+// active slot is switched, so that traffic moves to the promoted revision immediately.
+func (phase *WebStorePromotePhase) Execute(r common.ComponentReconciler) (proceedToNextPhase bool, err error) {
+	component, err := getWebStore(r)
+	if err != nil {
+		return false, err
+	}
+
+	rollout := component.Spec.Rollout
+	status := &component.Status.Rollout
+	desired, err := component.Spec.Revision()
+	if err != nil {
+		return false, err
+	}
+
+	// the revision desired by the spec is current when no revision has yet been promoted, as it was
+	// rendered into the active slot
+	if status.CurrentRevision == nil {
+		status.CurrentRevision = &desired
+		status.ActiveSlot = component.ActiveSlot()
+		status.Strategy = rollout.Strategy
+		status.Phase = appsv1alpha1.RolloutPhasePromoted
+
+		return true, nil
+	}
+
+	staged := rollout.Strategy.IsStaged()
+
+	// the active slot serves the revision desired by the spec alongside the webstore-deploy
+	// Deployment while switching to a staged strategy, so it takes over now that it is ready
+	if staged && component.ServesInPlace() {
+		r.GetLogger().V(0).Info(fmt.Sprintf("switching to the %s strategy; slot [%s] replaces the in-place deployment",
+			rollout.Strategy, component.ActiveSlot()))
+
+		if status.CurrentRevision.Name != desired.Name {
+			status.PreviousRevision = status.CurrentRevision
+		}
+
+		status.CurrentRevision = &desired
+		status.ActiveSlot = component.ActiveSlot()
+		status.Strategy = rollout.Strategy
+		status.Phase = appsv1alpha1.RolloutPhasePromoted
+
+		return promote(r)
+	}
+
+	candidate, err := component.CandidateRevision()
+	if err != nil {
+		return false, err
+	}
+
+	// there is nothing to promote, or a new revision was reverted before it was promoted
+	if candidate == nil {
+		status.Strategy = rollout.Strategy
+		status.Phase = appsv1alpha1.RolloutPhasePromoted
+
+		return true, nil
+	}
+
+	if staged && rollout.Abort {
+		return true, nil
+	}
+
+	if staged && rollout.Paused {
+		if status.Phase == appsv1alpha1.RolloutPhasePaused {
+			return true, nil
+		}
+
+		status.Phase = appsv1alpha1.RolloutPhasePaused
+
+		return true, nil
+	}
+
+	r.GetLogger().V(0).Info(fmt.Sprintf("promoting revision [%s]; replacing revision [%s]",
+		desired.Name, status.CurrentRevision.Name))
+
+	status.PreviousRevision = status.CurrentRevision
+	status.CurrentRevision = &desired
+	status.Strategy = rollout.Strategy
+	status.Phase = appsv1alpha1.RolloutPhasePromoted
+
+	// a rolling update has already replaced the deployment in place, so there is nothing to render
+	if !staged {
+		return true, r.UpdateStatus()
+	}
+
+	status.ActiveSlot = component.CandidateSlot()
+
+	return promote(r)
+}
+
+// promote persists a promotion and then renders and persists the child resources again, so that
+// traffic moves to the promoted slot immediately.
+func promote(r common.ComponentReconciler) (bool, error) {
+	// persist the promotion before the child resources are rendered from it
+	if err := r.UpdateStatus(); err != nil {
+		return false, err
+	}
+
+	if err := r.SetResources(); err != nil {
+		return false, err
+	}
+
+	return (&phases.CreateResourcesPhase{}).Execute(r)
+}
+
+// getWebStore returns the WebStore that the reconciler is operating against.
+func getWebStore(r common.ComponentReconciler) (*appsv1alpha1.WebStore, error) {
+	component, ok := r.GetComponent().(*appsv1alpha1.WebStore)
+	if !ok {
+		return nil, fmt.Errorf("unable to run rollout phases for component of type %T", r.GetComponent())
+	}
+
+	return component, nil
+}
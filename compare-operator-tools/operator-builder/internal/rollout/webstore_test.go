@@ -0,0 +1,564 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout_test
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	"github.com/scottd018/demos/apis/apps/v1alpha1/webstore"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/controllers/phases"
+	"github.com/scottd018/demos/internal/pipelines"
+	"github.com/scottd018/demos/internal/rollout"
+	"github.com/scottd018/demos/internal/testutil"
+)
+
+// rolloutFixture returns a WebStore with the rollout strategy and image, whose current revision
+// runs the provided image from the blue slot and was rolled out with the same strategy.  No
+// revision is current when the image is empty.
+func rolloutFixture(t *testing.T, strategy appsv1alpha1.WebStoreRolloutStrategy, image, currentImage string) *appsv1alpha1.WebStore {
+	component := testutil.WebStoreFixture("webstore-sample", appsv1alpha1.WebStoreSpec{
+		WebStoreReplicas: 5,
+		WebstoreImage:    image,
+		Rollout:          appsv1alpha1.WebStoreRollout{Strategy: strategy},
+	})
+
+	if currentImage != "" {
+		previous := testutil.WebStoreFixture("webstore-sample", appsv1alpha1.WebStoreSpec{WebstoreImage: currentImage})
+
+		current, err := previous.Spec.Revision()
+		if err != nil {
+			t.Fatalf("Revision() error = %v", err)
+		}
+
+		component.Status.Rollout = appsv1alpha1.WebStoreRolloutStatus{
+			Phase:           appsv1alpha1.RolloutPhasePromoted,
+			ActiveSlot:      appsv1alpha1.RolloutSlotBlue,
+			CurrentRevision: &current,
+			Strategy:        strategy,
+		}
+	}
+
+	return component
+}
+
+// newRolloutReconciler returns a reconciler for the component whose resources have been set, and
+// which is backed by a fake client seeded with the component and the provided objects.
+func newRolloutReconciler(
+	t *testing.T,
+	component *appsv1alpha1.WebStore,
+	objects ...client.Object,
+) *appscontrollers.WebStoreRequest {
+	r := testutil.NewFakeRequest(component, objects...)
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	return r
+}
+
+// persist persists the child resources of the reconciler.
+func persist(t *testing.T, r *appscontrollers.WebStoreRequest) {
+	if proceed, err := (&phases.CreateResourcesPhase{}).Execute(r); !proceed || err != nil {
+		t.Fatalf("CreateResourcesPhase.Execute() = %v, %v", proceed, err)
+	}
+}
+
+// getDeployment returns the replicas and image of a persisted deployment.
+func getDeployment(t *testing.T, r *appscontrollers.WebStoreRequest, name string) (int32, string) {
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: name, Namespace: testutil.Namespace}, deployment); err != nil {
+		t.Fatalf("unable to get deployment %s; %v", name, err)
+	}
+
+	return *deployment.Spec.Replicas, deployment.Spec.Template.Spec.Containers[0].Image
+}
+
+// getContainer returns the web store container and the pod spec of a persisted deployment.
+func getContainer(t *testing.T, r *appscontrollers.WebStoreRequest, name string) (corev1.Container, corev1.PodSpec) {
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: name, Namespace: testutil.Namespace}, deployment); err != nil {
+		t.Fatalf("unable to get deployment %s; %v", name, err)
+	}
+
+	return deployment.Spec.Template.Spec.Containers[0], deployment.Spec.Template.Spec
+}
+
+// getNginxConfig returns the nginx configuration of a persisted config map, and the checksum of
+// the configuration which is recorded on the pod template of a persisted deployment.
+func getNginxConfig(t *testing.T, r *appscontrollers.WebStoreRequest, configMapName, deploymentName string) (string, string) {
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: configMapName, Namespace: testutil.Namespace}, configMap); err != nil {
+		t.Fatalf("unable to get config map %s; %v", configMapName, err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: deploymentName, Namespace: testutil.Namespace}, deployment); err != nil {
+		t.Fatalf("unable to get deployment %s; %v", deploymentName, err)
+	}
+
+	return configMap.Data[webstore.NginxConfigKey], deployment.Spec.Template.Annotations[webstore.ConfigChecksumAnnotation]
+}
+
+// getServiceSelector returns the selector of the persisted service.
+func getServiceSelector(t *testing.T, r *appscontrollers.WebStoreRequest) map[string]string {
+	service := &corev1.Service{}
+	if err := r.Get(r.Context, types.NamespacedName{Name: "webstore-sample-svc", Namespace: testutil.Namespace}, service); err != nil {
+		t.Fatalf("unable to get service; %v", err)
+	}
+
+	return service.Spec.Selector
+}
+
+// executePhase executes a registered phase against the reconciler.
+func executePhase(t *testing.T, r *appscontrollers.WebStoreRequest, name string) bool {
+	if err := pipelines.RegisterWebStorePhases(); err != nil {
+		t.Fatalf("RegisterWebStorePhases() error = %v", err)
+	}
+
+	phase, err := phases.GetPhase(name)
+	if err != nil {
+		t.Fatalf("GetPhase() error = %v", err)
+	}
+
+	proceed, err := phase.Execute(r)
+	if err != nil {
+		t.Fatalf("%s.Execute() error = %v", name, err)
+	}
+
+	return proceed
+}
+
+// wantDeployment checks the replicas and image of a persisted deployment.
+func wantDeployment(t *testing.T, r *appscontrollers.WebStoreRequest, name string, replicas int32, image string) {
+	t.Helper()
+
+	gotReplicas, gotImage := getDeployment(t, r, name)
+	if gotReplicas != replicas || gotImage != image {
+		t.Errorf("deployment %s = %d replicas of %s, want %d replicas of %s", name, gotReplicas, gotImage, replicas, image)
+	}
+}
+
+func TestWebStorePromotePhaseInitialRevision(t *testing.T) {
+	r := newRolloutReconciler(t, rolloutFixture(t, appsv1alpha1.RolloutStrategyBlueGreen, "nginx:1.17", ""))
+
+	if !executePhase(t, r, rollout.PromotePhaseName) {
+		t.Errorf("PromotePhase did not proceed")
+	}
+
+	status := r.Component.Status.Rollout
+	if status.CurrentRevision == nil || status.CurrentRevision.WebstoreImage != "nginx:1.17" {
+		t.Fatalf("current revision = %+v, want nginx:1.17", status.CurrentRevision)
+	}
+
+	if status.PreviousRevision != nil || status.ActiveSlot != appsv1alpha1.RolloutSlotBlue {
+		t.Errorf("status = %+v, want no previous revision and the blue slot active", status)
+	}
+}
+
+func TestWebStoreBlueGreenRollout(t *testing.T) {
+	r := newRolloutReconciler(t, rolloutFixture(t, appsv1alpha1.RolloutStrategyBlueGreen, "nginx:1.18", "nginx:1.17"))
+	persist(t, r)
+
+	// the new revision runs alongside the current revision while the service stays on the blue slot
+	wantDeployment(t, r, "webstore-sample-deploy", 0, "nginx:1.18")
+	wantDeployment(t, r, "webstore-sample-deploy-blue", 5, "nginx:1.17")
+	wantDeployment(t, r, "webstore-sample-deploy-green", 5, "nginx:1.18")
+
+	if slot := getServiceSelector(t, r)[webstore.SlotLabel]; slot != appsv1alpha1.RolloutSlotBlue {
+		t.Errorf("service selects slot %q, want blue", slot)
+	}
+
+	executePhase(t, r, rollout.AbortPhaseName)
+
+	if phase := r.Component.Status.Rollout.Phase; phase != appsv1alpha1.RolloutPhaseProgressing {
+		t.Errorf("rollout phase = %s, want Progressing", phase)
+	}
+
+	// promotion switches the service to the green slot and scales down the blue slot
+	if !executePhase(t, r, rollout.PromotePhaseName) {
+		t.Errorf("PromotePhase did not proceed")
+	}
+
+	status := r.Component.Status.Rollout
+	if status.Phase != appsv1alpha1.RolloutPhasePromoted || status.ActiveSlot != appsv1alpha1.RolloutSlotGreen {
+		t.Errorf("status = %+v, want the green slot promoted", status)
+	}
+
+	if status.CurrentRevision.WebstoreImage != "nginx:1.18" || status.PreviousRevision.WebstoreImage != "nginx:1.17" {
+		t.Errorf("revisions = %+v, %+v, want nginx:1.18 replacing nginx:1.17",
+			status.CurrentRevision, status.PreviousRevision)
+	}
+
+	wantDeployment(t, r, "webstore-sample-deploy-blue", 0, "nginx:1.18")
+	wantDeployment(t, r, "webstore-sample-deploy-green", 5, "nginx:1.18")
+
+	if slot := getServiceSelector(t, r)[webstore.SlotLabel]; slot != appsv1alpha1.RolloutSlotGreen {
+		t.Errorf("service selects slot %q, want green", slot)
+	}
+}
+
+func TestWebStoreCanaryRollout(t *testing.T) {
+	r := newRolloutReconciler(t, rolloutFixture(t, appsv1alpha1.RolloutStrategyCanary, "nginx:1.18", "nginx:1.17"))
+	persist(t, r)
+
+	// twenty percent of five replicas run the new revision and the service selects both slots
+	wantDeployment(t, r, "webstore-sample-deploy-blue", 4, "nginx:1.17")
+	wantDeployment(t, r, "webstore-sample-deploy-green", 1, "nginx:1.18")
+
+	if _, found := getServiceSelector(t, r)[webstore.SlotLabel]; found {
+		t.Errorf("service selects a single slot during a canary rollout")
+	}
+
+	executePhase(t, r, rollout.PromotePhaseName)
+
+	wantDeployment(t, r, "webstore-sample-deploy-blue", 0, "nginx:1.18")
+	wantDeployment(t, r, "webstore-sample-deploy-green", 5, "nginx:1.18")
+}
+
+func TestWebStorePausedRollout(t *testing.T) {
+	component := rolloutFixture(t, appsv1alpha1.RolloutStrategyBlueGreen, "nginx:1.18", "nginx:1.17")
+	component.Spec.Rollout.Paused = true
+
+	r := newRolloutReconciler(t, component)
+
+	if !executePhase(t, r, rollout.PromotePhaseName) {
+		t.Errorf("PromotePhase did not proceed")
+	}
+
+	status := r.Component.Status.Rollout
+	if status.Phase != appsv1alpha1.RolloutPhasePaused || status.CurrentRevision.WebstoreImage != "nginx:1.17" {
+		t.Errorf("status = %+v, want nginx:1.17 current and the rollout paused", status)
+	}
+}
+
+func TestWebStoreAbortedRollout(t *testing.T) {
+	component := rolloutFixture(t, appsv1alpha1.RolloutStrategyCanary, "nginx:1.18", "nginx:1.17")
+	component.Spec.Rollout.Abort = true
+
+	r := newRolloutReconciler(t, component)
+	persist(t, r)
+
+	// the new revision is scaled down and the current revision serves every replica
+	wantDeployment(t, r, "webstore-sample-deploy-blue", 5, "nginx:1.17")
+	wantDeployment(t, r, "webstore-sample-deploy-green", 0, "nginx:1.18")
+
+	executePhase(t, r, rollout.AbortPhaseName)
+	executePhase(t, r, rollout.PromotePhaseName)
+
+	status := r.Component.Status.Rollout
+	if status.Phase != appsv1alpha1.RolloutPhaseAborted || status.CurrentRevision.WebstoreImage != "nginx:1.17" {
+		t.Errorf("status = %+v, want nginx:1.17 current and the rollout aborted", status)
+	}
+}
+
+func TestWebStoreRollingUpdateRollout(t *testing.T) {
+	r := newRolloutReconciler(t, rolloutFixture(t, appsv1alpha1.RolloutStrategyRollingUpdate, "nginx:1.18", "nginx:1.17"))
+	persist(t, r)
+
+	// the deployment is updated in place and the slots are not rendered
+	wantDeployment(t, r, "webstore-sample-deploy", 5, "nginx:1.18")
+
+	for _, resource := range r.GetResources() {
+		if resource.GetName() == "webstore-sample-deploy-blue" || resource.GetName() == "webstore-sample-nginx-conf-blue" {
+			t.Errorf("%s %s was rendered for a rolling update", resource.GetKind(), resource.GetName())
+		}
+	}
+
+	executePhase(t, r, rollout.PromotePhaseName)
+
+	status := r.Component.Status.Rollout
+	if status.CurrentRevision.WebstoreImage != "nginx:1.18" || status.ActiveSlot != appsv1alpha1.RolloutSlotBlue {
+		t.Errorf("status = %+v, want nginx:1.18 current without switching slots", status)
+	}
+}
+
+func TestWebStoreStrategySwitchRemovesSlotSelector(t *testing.T) {
+	r := newRolloutReconciler(t, rolloutFixture(t, appsv1alpha1.RolloutStrategyBlueGreen, "nginx:1.17", "nginx:1.17"))
+	persist(t, r)
+
+	if _, found := getServiceSelector(t, r)[webstore.SlotLabel]; !found {
+		t.Fatalf("service does not select a slot during a blue/green rollout")
+	}
+
+	r.Component.Spec.Rollout.Strategy = appsv1alpha1.RolloutStrategyRollingUpdate
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	persist(t, r)
+
+	if selector := getServiceSelector(t, r); len(selector) != 2 || selector[webstore.InstanceLabel] != "webstore-sample" {
+		t.Errorf("service selector = %v, want only the name and instance labels", selector)
+	}
+}
+
+// inPlaceDeploymentFixture returns the webstore-deploy Deployment of a WebStore as it was persisted
+// before its selector excluded the pods of the slots.
+func inPlaceDeploymentFixture(component *appsv1alpha1.WebStore, replicas int32, image string) *appsv1.Deployment {
+	labels := map[string]string{webstore.NameLabel: webstore.AppName, webstore.InstanceLabel: component.Name}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "webstore-sample-deploy",
+			Namespace:       testutil.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(component, component.GetComponentGVK())},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "webstore-container", Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func TestWebStoreStrategySwitchFromRollingUpdate(t *testing.T) {
+	component := rolloutFixture(t, appsv1alpha1.RolloutStrategyRollingUpdate, "nginx:1.17", "nginx:1.17")
+	deployment := inPlaceDeploymentFixture(component, 5, "nginx:1.17")
+
+	// the spec is switched to a staged strategy while the status still records the rolling update
+	component.Spec.Rollout.Strategy = appsv1alpha1.RolloutStrategyBlueGreen
+	r := newRolloutReconciler(t, component, deployment)
+
+	persist(t, r)
+
+	// the in-place deployment keeps serving alongside the active slot until the slot is ready
+	wantDeployment(t, r, "webstore-sample-deploy", 5, "nginx:1.17")
+	wantDeployment(t, r, "webstore-sample-deploy-blue", 5, "nginx:1.17")
+	wantDeployment(t, r, "webstore-sample-deploy-green", 0, "nginx:1.17")
+
+	if slot, found := getServiceSelector(t, r)[webstore.SlotLabel]; found {
+		t.Errorf("service selects slot %q, want the in-place and slot pods selected while switching", slot)
+	}
+
+	// the selector of the in-place deployment is replaced so that it excludes the pods of the slots
+	if err := r.Get(r.Context, types.NamespacedName{Name: deployment.Name, Namespace: testutil.Namespace}, deployment); err != nil {
+		t.Fatalf("unable to get deployment; %v", err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		t.Fatalf("LabelSelectorAsSelector() error = %v", err)
+	}
+
+	slotPodLabels := labels.Set{webstore.NameLabel: webstore.AppName, webstore.InstanceLabel: "webstore-sample", webstore.SlotLabel: "blue"}
+	if selector.Matches(slotPodLabels) {
+		t.Errorf("in-place deployment selector %s selects the pods of the slots", selector)
+	}
+
+	// the switch is promoted once the active slot is ready
+	executePhase(t, r, rollout.PromotePhaseName)
+
+	status := r.Component.Status.Rollout
+	if status.Strategy != appsv1alpha1.RolloutStrategyBlueGreen || status.ActiveSlot != appsv1alpha1.RolloutSlotBlue ||
+		status.CurrentRevision.WebstoreImage != "nginx:1.17" {
+		t.Errorf("status = %+v, want the blue slot serving nginx:1.17 with the blue/green strategy", status)
+	}
+
+	wantDeployment(t, r, "webstore-sample-deploy", 0, "nginx:1.17")
+	wantDeployment(t, r, "webstore-sample-deploy-blue", 5, "nginx:1.17")
+
+	if slot := getServiceSelector(t, r)[webstore.SlotLabel]; slot != appsv1alpha1.RolloutSlotBlue {
+		t.Errorf("service selects slot %q, want blue", slot)
+	}
+}
+
+func TestWebStorePodTemplateDefaults(t *testing.T) {
+	r := newRolloutReconciler(t, rolloutFixture(t, appsv1alpha1.RolloutStrategyRollingUpdate, "nginx:1.17", ""))
+	persist(t, r)
+
+	container, _ := getContainer(t, r, "webstore-sample-deploy")
+
+	if container.Resources.Requests.Cpu().String() != "100m" || container.Resources.Limits.Memory().String() != "256Mi" {
+		t.Errorf("resources = %+v, want the default requests and limits", container.Resources)
+	}
+
+	if container.LivenessProbe == nil || container.ReadinessProbe == nil ||
+		container.ReadinessProbe.HTTPGet.Port.IntValue() != 8080 {
+		t.Errorf("probes = %+v, %+v, want the default http probes", container.LivenessProbe, container.ReadinessProbe)
+	}
+
+	// the restricted pod security standard requires each of the following
+	securityContext := container.SecurityContext
+	switch {
+	case securityContext == nil:
+		t.Fatalf("security context is not set")
+	case securityContext.AllowPrivilegeEscalation == nil || *securityContext.AllowPrivilegeEscalation:
+		t.Errorf("security context allows privilege escalation")
+	case securityContext.RunAsNonRoot == nil || !*securityContext.RunAsNonRoot:
+		t.Errorf("security context does not require a non-root user")
+	case securityContext.Capabilities == nil || len(securityContext.Capabilities.Drop) != 1 ||
+		securityContext.Capabilities.Drop[0] != "ALL":
+		t.Errorf("security context does not drop all capabilities")
+	case securityContext.SeccompProfile == nil ||
+		securityContext.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault:
+		t.Errorf("security context does not use the runtime default seccomp profile")
+	}
+}
+
+func TestWebStorePodTemplatePassThrough(t *testing.T) {
+	component := rolloutFixture(t, appsv1alpha1.RolloutStrategyRollingUpdate, "nginx:1.17", "")
+	component.Spec.Env = []corev1.EnvVar{{Name: "STORE_NAME", Value: "acme"}}
+	component.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry-credentials"}}
+
+	r := newRolloutReconciler(t, component)
+	persist(t, r)
+
+	container, pod := getContainer(t, r, "webstore-sample-deploy")
+	if len(container.Env) != 1 || container.Env[0].Value != "acme" {
+		t.Errorf("env = %+v, want STORE_NAME=acme", container.Env)
+	}
+
+	if len(pod.ImagePullSecrets) != 1 || pod.ImagePullSecrets[0].Name != "registry-credentials" {
+		t.Errorf("image pull secrets = %+v, want registry-credentials", pod.ImagePullSecrets)
+	}
+
+	// fields which are unset on the web store are removed from the deployment
+	r.Component.Spec.Env = nil
+	r.Component.Spec.ImagePullSecrets = nil
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	persist(t, r)
+
+	if container, pod = getContainer(t, r, "webstore-sample-deploy"); len(container.Env) != 0 || len(pod.ImagePullSecrets) != 0 {
+		t.Errorf("env = %+v, image pull secrets = %+v, want both removed", container.Env, pod.ImagePullSecrets)
+	}
+}
+
+func TestWebStoreBlueGreenRolloutStagesPodTemplate(t *testing.T) {
+	component := rolloutFixture(t, appsv1alpha1.RolloutStrategyBlueGreen, "nginx:1.17", "nginx:1.17")
+	component.Spec.Env = []corev1.EnvVar{{Name: "STORE_NAME", Value: "acme"}}
+
+	r := newRolloutReconciler(t, component)
+	persist(t, r)
+
+	// a change to the pod template is a new revision, which runs from the candidate slot
+	if blue, _ := getContainer(t, r, "webstore-sample-deploy-blue"); len(blue.Env) != 0 {
+		t.Errorf("blue env = %+v, want the current revision without env", blue.Env)
+	}
+
+	if green, _ := getContainer(t, r, "webstore-sample-deploy-green"); len(green.Env) != 1 {
+		t.Errorf("green env = %+v, want the new revision with env", green.Env)
+	}
+}
+
+func TestWebStoreNginxConfig(t *testing.T) {
+	r := newRolloutReconciler(t, rolloutFixture(t, appsv1alpha1.RolloutStrategyRollingUpdate, "nginx:1.17", ""))
+	persist(t, r)
+
+	config, checksum := getNginxConfig(t, r, webstore.NginxConfigMapName(r.Component, ""), "webstore-sample-deploy")
+	if !strings.Contains(config, "listen 8080;") || !strings.Contains(config, "root /usr/share/nginx/html;") {
+		t.Errorf("nginx config = %s, want the default server block listening on 8080", config)
+	}
+
+	container, pod := getContainer(t, r, "webstore-sample-deploy")
+	if len(pod.Volumes) != 1 || pod.Volumes[0].ConfigMap == nil || pod.Volumes[0].ConfigMap.Name != webstore.NginxConfigMapName(r.Component, "") {
+		t.Errorf("volumes = %+v, want the nginx config map", pod.Volumes)
+	}
+
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != "/etc/nginx/nginx.conf" {
+		t.Errorf("volume mounts = %+v, want the nginx config mounted over nginx.conf", container.VolumeMounts)
+	}
+
+	// a change to the configuration changes the checksum, which restarts the pods
+	r.Component.Spec.Nginx = appsv1alpha1.WebStoreNginx{
+		Upstreams:    []appsv1alpha1.WebStoreNginxUpstream{{Name: "api", Servers: []string{"api.team-a.svc:8080"}}},
+		ServerBlocks: []string{"location /api/ {\n    proxy_pass http://api;\n}"},
+		Snippet:      "gzip on;",
+	}
+
+	if err := r.SetResources(); err != nil {
+		t.Fatalf("SetResources() error = %v", err)
+	}
+
+	persist(t, r)
+
+	updated, updatedChecksum := getNginxConfig(t, r, webstore.NginxConfigMapName(r.Component, ""), "webstore-sample-deploy")
+	for _, want := range []string{"upstream api {", "server api.team-a.svc:8080;", "gzip on;", "proxy_pass http://api;"} {
+		if !strings.Contains(updated, want) {
+			t.Errorf("nginx config = %s, want %q", updated, want)
+		}
+	}
+
+	if updatedChecksum == "" || updatedChecksum == checksum {
+		t.Errorf("config checksum = %q, want a checksum other than %q", updatedChecksum, checksum)
+	}
+}
+
+func TestWebStoreBlueGreenRolloutStagesNginxConfig(t *testing.T) {
+	component := rolloutFixture(t, appsv1alpha1.RolloutStrategyBlueGreen, "nginx:1.17", "nginx:1.17")
+	component.Spec.Nginx.Snippet = "gzip on;"
+
+	r := newRolloutReconciler(t, component)
+	persist(t, r)
+
+	// a change to the configuration is a new revision, which runs from the candidate slot
+	if blue, _ := getNginxConfig(t, r, webstore.NginxConfigMapName(r.Component, appsv1alpha1.RolloutSlotBlue), "webstore-sample-deploy-blue"); strings.Contains(blue, "gzip on;") {
+		t.Errorf("blue nginx config = %s, want the current revision without the snippet", blue)
+	}
+
+	if green, _ := getNginxConfig(t, r, webstore.NginxConfigMapName(r.Component, appsv1alpha1.RolloutSlotGreen), "webstore-sample-deploy-green"); !strings.Contains(green, "gzip on;") {
+		t.Errorf("green nginx config = %s, want the new revision with the snippet", green)
+	}
+}
+
+func TestWebStoreCanaryReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas int
+		weight   int
+		want     int
+	}{
+		{name: "no replicas", replicas: 0, weight: 50, want: 0},
+		{name: "single replica runs the new revision", replicas: 1, weight: 10, want: 1},
+		{name: "rounds up to a single replica", replicas: 10, weight: 1, want: 1},
+		{name: "splits the replicas by weight", replicas: 10, weight: 30, want: 3},
+		{name: "keeps a stable replica at a high weight", replicas: 2, weight: 51, want: 1},
+		{name: "keeps a stable replica at the maximum weight", replicas: 5, weight: 99, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component := rolloutFixture(t, appsv1alpha1.RolloutStrategyCanary, "nginx:1.18", "nginx:1.17")
+			component.Spec.WebStoreReplicas = tt.replicas
+			component.Spec.Rollout.CanaryWeight = tt.weight
+
+			if got := component.CanaryReplicas(); got != tt.want {
+				t.Errorf("CanaryReplicas() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
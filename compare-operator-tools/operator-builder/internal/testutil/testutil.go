@@ -0,0 +1,181 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testutil provides the fakes and fixtures which are shared by the tests of the WebStore
+// reconciler and its phases.
+package testutil
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
+	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/crd"
+)
+
+// Namespace is the namespace of the WebStore fixtures.
+const Namespace = "team-a"
+
+// FakeController is a controller which accepts watches without starting them.
+type FakeController struct{}
+
+func (*FakeController) Reconcile(context.Context, reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, nil
+}
+
+func (*FakeController) Watch(source.Source, handler.EventHandler, ...predicate.Predicate) error {
+	return nil
+}
+
+func (*FakeController) Start(context.Context) error {
+	return nil
+}
+
+func (*FakeController) GetLogger() logr.Logger {
+	return logr.Discard()
+}
+
+// MapperClient is a client which provides its own rest mapper.
+type MapperClient struct {
+	client.Client
+
+	Mapper meta.RESTMapper
+}
+
+// RESTMapper returns the rest mapper of the client.
+func (c *MapperClient) RESTMapper() meta.RESTMapper {
+	return c.Mapper
+}
+
+// NewScheme returns a scheme which holds the kubernetes, custom resource definition and WebStore
+// types.
+func NewScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(extensionsv1.AddToScheme(scheme))
+	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+
+	return scheme
+}
+
+// NewClient returns a fake client which is seeded with the objects.
+func NewClient(objects ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(NewScheme()).WithObjects(objects...).Build()
+}
+
+// NewReconciler returns a reconciler which is backed by the client.
+func NewReconciler(c client.Client) *appscontrollers.WebStoreReconciler {
+	return &appscontrollers.WebStoreReconciler{
+		Name:       "WebStore",
+		Client:     c,
+		Log:        logr.Discard(),
+		Scheme:     c.Scheme(),
+		Controller: &FakeController{},
+	}
+}
+
+// NewRequest returns a request to reconcile the component by a reconciler which is backed by the
+// client.
+func NewRequest(c client.Client, component *appsv1alpha1.WebStore) *appscontrollers.WebStoreRequest {
+	return NewReconciler(c).NewRequest(context.Background(), component)
+}
+
+// NewFakeRequest returns a request to reconcile the component by a reconciler which is backed by a
+// fake client seeded with the component, its active namespace and the objects.
+func NewFakeRequest(component *appsv1alpha1.WebStore, objects ...client.Object) *appscontrollers.WebStoreRequest {
+	return NewRequest(NewClient(append(objects, component, NamespaceFixture(component.Namespace))...), component)
+}
+
+// WebStoreFixture returns a WebStore with the name and spec in the fixture namespace, which is
+// defaulted as the API server would default a manifest that omits the unset fields of the spec.
+func WebStoreFixture(name string, spec appsv1alpha1.WebStoreSpec) *appsv1alpha1.WebStore {
+	specContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	utilruntime.Must(err)
+
+	removeUnsetFields(reflect.ValueOf(spec), specContent)
+
+	content := map[string]interface{}{"spec": specContent}
+	utilruntime.Must(crd.Default(content))
+
+	component := &appsv1alpha1.WebStore{}
+	utilruntime.Must(runtime.DefaultUnstructuredConverter.FromUnstructured(content, component))
+
+	component.ObjectMeta = metav1.ObjectMeta{Name: name, Namespace: Namespace, UID: types.UID(name + "-uid")}
+
+	return component
+}
+
+// removeUnsetFields removes the fields of a struct which hold their zero value from its
+// unstructured content, so that they are defaulted as if they were absent from a manifest.  Fields
+// which are set through a pointer are kept, even when they point to a zero value.
+func removeUnsetFields(value reflect.Value, content map[string]interface{}) {
+	for i := 0; i < value.NumField(); i++ {
+		field, fieldValue := value.Type().Field(i), value.Field(i)
+
+		fieldName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if fieldName == "" && field.Anonymous {
+			removeUnsetFields(fieldValue, content)
+
+			continue
+		}
+
+		if fieldName == "" || fieldName == "-" {
+			continue
+		}
+
+		if fieldValue.IsZero() {
+			delete(content, fieldName)
+
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Ptr {
+			fieldValue = fieldValue.Elem()
+		}
+
+		// fields which are not serialized as objects, such as quantities, are kept as they are
+		if fieldContent, ok := content[fieldName].(map[string]interface{}); ok && fieldValue.Kind() == reflect.Struct {
+			removeUnsetFields(fieldValue, fieldContent)
+		}
+	}
+}
+
+// NamespaceFixture returns an active namespace.
+func NamespaceFixture(name string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+}
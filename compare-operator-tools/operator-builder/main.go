@@ -18,22 +18,28 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	appsv1alpha1 "github.com/scottd018/demos/apis/apps/v1alpha1"
 	appscontrollers "github.com/scottd018/demos/controllers/apps"
+	"github.com/scottd018/demos/internal/pipelines"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -61,11 +67,25 @@ func main() {
 
 	var probeAddr string
 
+	var watchNamespaces string
+
+	var maxConcurrentReconciles int
+
+	var phaseRequeueAfter string
+
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated list of namespaces to watch for resources.  "+
+			"All namespaces are watched when empty, which requires cluster-scoped permissions.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The maximum number of resources of each kind which are reconciled at the same time.")
+	flag.StringVar(&phaseRequeueAfter, "phase-requeue-after", "",
+		"Comma-separated list of phase=interval pairs, e.g. CheckReadyPhase=10s, which set the interval "+
+			"after which a request is requeued when the phase is not ready.")
 
 	opts := zap.Options{
 		Development: true,
@@ -82,25 +102,49 @@ func main() {
 		}),
 	)
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	options := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "e6ca716f.acme.com",
-	})
+	}
+
+	if namespaces := parseNamespaces(watchNamespaces); len(namespaces) > 0 {
+		setupLog.Info("watching namespaces", "namespaces", namespaces)
+
+		options = watchNamespaceOptions(options, namespaces)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	pipelineOptions, err := webStorePipelineOptions(phaseRequeueAfter)
+	if err != nil {
+		setupLog.Error(err, "unable to parse phase requeue intervals")
+		os.Exit(1)
+	}
+
+	webStorePipeline, err := pipelines.WebStorePipeline(pipelineOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to create pipeline", "controller", "WebStore")
+		os.Exit(1)
+	}
+
 	reconcilers := []ReconcilerInitializer{
 		&appscontrollers.WebStoreReconciler{
 			Name:   "WebStore",
 			Client: mgr.GetClient(),
+			Cache:  mgr.GetCache(),
 			Log:    ctrl.Log.WithName("controllers").WithName("apps").WithName("WebStore"),
 			Scheme: mgr.GetScheme(),
+
+			Pipeline:                webStorePipeline,
+			MaxConcurrentReconciles: maxConcurrentReconciles,
 		},
 		//+kubebuilder:scaffold:reconcilers
 	}
@@ -129,3 +173,57 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseNamespaces parses a comma-separated list of namespaces.
+func parseNamespaces(namespaces string) []string {
+	var parsed []string
+
+	for _, namespace := range strings.Split(namespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			parsed = append(parsed, namespace)
+		}
+	}
+
+	return parsed
+}
+
+// webStorePipelineOptions returns the configuration of the WebStore pipeline, with the requeue
+// intervals of the phases parsed from a comma-separated list of phase=interval pairs.
+func webStorePipelineOptions(phaseRequeueAfter string) (pipelines.WebStoreOptions, error) {
+	options := pipelines.DefaultWebStoreOptions()
+
+	for _, pair := range strings.Split(phaseRequeueAfter, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		name, interval := pair, ""
+		if i := strings.Index(pair, "="); i >= 0 {
+			name, interval = strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		}
+
+		requeueAfter, err := time.ParseDuration(interval)
+		if err != nil || requeueAfter <= 0 {
+			return options, fmt.Errorf("invalid requeue interval %q for phase %s; must be a positive duration", interval, name)
+		}
+
+		options.RequeueAfter[name] = requeueAfter
+	}
+
+	return options, nil
+}
+
+// watchNamespaceOptions restricts the manager cache to a set of namespaces.  Namespaces are
+// cluster-scoped and cannot be read from a namespace-scoped cache, so they are read directly
+// from the API server instead.
+func watchNamespaceOptions(options ctrl.Options, namespaces []string) ctrl.Options {
+	if len(namespaces) == 1 {
+		options.Namespace = namespaces[0]
+	} else {
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+
+	options.ClientDisableCacheFor = append(options.ClientDisableCacheFor, &corev1.Namespace{})
+
+	return options
+}